@@ -40,12 +40,132 @@ const (
 	ArgAppSpec = "spec"
 	// ArgAppLogType the type of log.
 	ArgAppLogType = "type"
+	// ArgAppDeploymentLogs is the type of logs to display after a deployment.
+	ArgAppDeploymentLogs = "logs"
+	// ArgAppDeploymentWatch redraws a deployment's status until it finishes.
+	ArgAppDeploymentWatch = "watch"
 	// ArgAppDeployment is the deployment ID.
 	ArgAppDeployment = "deployment"
 	// ArgAppLogFollow follow logs.
 	ArgAppLogFollow = "follow"
+	// ArgAppLogComponents is a list of components to get logs for.
+	ArgAppLogComponents = "components"
+	// ArgAppLogOutputDir is the directory app logs are written to.
+	ArgAppLogOutputDir = "output-dir"
+	// ArgAppLogNoReconnect disables reconnecting to followed app logs.
+	ArgAppLogNoReconnect = "no-reconnect"
+	// ArgAppLogTail is the number of log lines to display.
+	ArgAppLogTail = "tail"
+	// ArgAppLogSince is the start of the window of logs to display.
+	ArgAppLogSince = "since"
+	// ArgAppLogUntil is the end of the window of logs to display.
+	ArgAppLogUntil = "until"
+	// ArgAppLogGrep is a regular expression that log lines must match to be displayed.
+	ArgAppLogGrep = "grep"
+	// ArgAppLogGrepInvert is a regular expression that excludes matching log lines.
+	ArgAppLogGrepInvert = "grep-v"
+	// ArgAppLogColor controls whether log lines are colored by log level.
+	ArgAppLogColor = "color"
+	// ArgAppLogJSONRaw writes followed logs as the raw websocket frames they are received in.
+	ArgAppLogJSONRaw = "json-raw"
+	// ArgAppConsoleCommand is a command to run in an app console instead of an interactive session.
+	ArgAppConsoleCommand = "command"
 	// ArgAppForceRebuild forces a deployment rebuild
 	ArgAppForceRebuild = "force-rebuild"
+	// ArgAppComponents is a list of app component names.
+	ArgAppComponents = "components"
+	// ArgAppSet overrides a value in an app spec.
+	ArgAppSet = "set"
+	// ArgAppSetInstanceCount overrides a component's instance count, e.g. web=3.
+	ArgAppSetInstanceCount = "set-instance-count"
+	// ArgAppSetInstanceSize overrides a component's instance size, e.g. web=professional-s.
+	ArgAppSetInstanceSize = "set-instance-size"
+	// ArgAppSetCreate allows app spec overrides to create missing values.
+	ArgAppSetCreate = "set-create"
+	// ArgAppSpecDir is a directory of app specs.
+	ArgAppSpecDir = "dir"
+	// ArgAppSpecOutputFile is the file an app spec is written to.
+	ArgAppSpecOutputFile = "output-file"
+	// ArgAppSpecRedactSecrets replaces the values of secret env vars in an app spec.
+	ArgAppSpecRedactSecrets = "redact-secrets"
+	// ArgAppComponentURLs shows the URLs of an app's components.
+	ArgAppComponentURLs = "component-urls"
+	// ArgAppSpecWrite writes a formatted app spec back to its file.
+	ArgAppSpecWrite = "write"
+	// ArgAppProposeDiff shows a diff between an existing app's spec and a proposed spec.
+	ArgAppProposeDiff = "diff"
+	// ArgAppProposeOnlyCost limits the output of a proposal to its costs.
+	ArgAppProposeOnlyCost = "only-cost"
+	// ArgAppProposeSpecFormat outputs only the proposed app spec, in the given format.
+	ArgAppProposeSpecFormat = "spec-format"
+	// ArgAppFilter is a filter applied to the list of apps.
+	ArgAppFilter = "filter"
+	// ArgAppByName resolves the target app by the name in its app spec.
+	ArgAppByName = "by-name"
+	// ArgAppUpsert updates the app named in an app spec instead of creating it if one exists.
+	ArgAppUpsert = "upsert"
+	// ArgAppOpenPrint prints an app's live URL instead of opening it.
+	ArgAppOpenPrint = "print"
+	// ArgAppBandwidthSince is the first day of the period to report app bandwidth usage for.
+	ArgAppBandwidthSince = "since"
+	// ArgAppBandwidthUntil is the last day of the period to report app bandwidth usage for.
+	ArgAppBandwidthUntil = "until"
+	// ArgAppBandwidthCSV outputs app bandwidth usage as CSV.
+	ArgAppBandwidthCSV = "csv"
+	// ArgAppMetricsPeriod is how far back to report an app's metrics.
+	ArgAppMetricsPeriod = "period"
+	// ArgWide displays additional columns in a list.
+	ArgWide = "wide"
+	// ArgSort is the field to sort a list by.
+	ArgSort = "sort"
+	// ArgSortDesc sorts a list in descending order.
+	ArgSortDesc = "sort-desc"
+	// ArgAppValidateRegion checks the region in an app spec against the available app regions.
+	ArgAppValidateRegion = "validate-region"
+	// ArgAppValidateSizes checks the instance sizes in an app spec against the available instance sizes.
+	ArgAppValidateSizes = "validate-sizes"
+	// ArgAppDeleteAll deletes every app, optionally restricted by ArgAppFilter.
+	ArgAppDeleteAll = "all"
+	// ArgConcurrency is the maximum number of API requests made in parallel.
+	ArgConcurrency = "concurrency"
+	// ArgMaxAPIFailures is the number of consecutive API failures tolerated while waiting.
+	ArgMaxAPIFailures = "max-api-failures"
+	// ArgAppActiveOnly limits a list of deployments to the active deployment.
+	ArgAppActiveOnly = "active-only"
+	// ArgAppInProgress includes the in-progress deployment in a list of deployments.
+	ArgAppInProgress = "in-progress"
+	// ArgPage is the page of results to fetch from a paginated API.
+	ArgPage = "page"
+	// ArgPerPage is the number of results per page to fetch from a paginated API.
+	ArgPerPage = "per-page"
+	// ArgLimit caps the total number of results displayed.
+	ArgLimit = "limit"
+	// ArgAppComponent is an app component name.
+	ArgAppComponent = "component"
+	// ArgAppEnvScope is the scope of an app environment variable.
+	ArgAppEnvScope = "scope"
+	// ArgAppEnvSecret marks an app environment variable as a secret.
+	ArgAppEnvSecret = "secret"
+	// ArgAppEnvShowSecret shows the values of secret app environment variables.
+	ArgAppEnvShowSecret = "show-secret"
+	// ArgAppTier is an app tier slug.
+	ArgAppTier = "tier"
+	// ArgAppCPUType is an app instance size CPU type.
+	ArgAppCPUType = "cpu-type"
+	// ArgAppTierPriceTotals adds the combined price of app instance sizes.
+	ArgAppTierPriceTotals = "tier-price-totals"
+	// ArgAppDomain is a custom domain of an app.
+	ArgAppDomain = "domain"
+	// ArgAppDomainType is the type of an app domain.
+	ArgAppDomainType = "type"
+	// ArgAppDomainWildcard marks an app domain as a wildcard domain.
+	ArgAppDomainWildcard = "wildcard"
+	// ArgAppDomainZone is the DigitalOcean DNS zone an app domain belongs to.
+	ArgAppDomainZone = "zone"
+	// ArgAppAlertEmails is the email addresses an app alert is sent to.
+	ArgAppAlertEmails = "emails"
+	// ArgAppAlertSlackWebhooks is the Slack webhook URLs an app alert is sent to.
+	ArgAppAlertSlackWebhooks = "slack-webhooks"
 	// ArgClusterName is a cluster name argument.
 	ArgClusterName = "cluster-name"
 	// ArgClusterVersionSlug is a cluster version argument.
@@ -78,6 +198,12 @@ const (
 	ArgSurgeUpgrade = "surge-upgrade"
 	// ArgCommandWait is a wait for a resource to be created argument.
 	ArgCommandWait = "wait"
+	// ArgDryRun previews the result of a command without making any changes.
+	ArgDryRun = "dry-run"
+	// ArgTimeout is the maximum amount of time to wait for a resource.
+	ArgTimeout = "timeout"
+	// ArgPollInterval is the amount of time between polls while waiting for a resource.
+	ArgPollInterval = "poll-interval"
 	// ArgSetCurrentContext is a flag to set the new kubeconfig context as current.
 	ArgSetCurrentContext = "set-current-context"
 	// ArgDropletID is a droplet id argument.
@@ -144,6 +270,8 @@ const (
 	ArgRegionSlug = "region"
 	// ArgSchemaOnly is a schema only argument.
 	ArgSchemaOnly = "schema-only"
+	// ArgAppSpecStrictEnv checks the environment variables of an app spec for common mistakes.
+	ArgAppSpecStrictEnv = "strict-env"
 	// ArgSizeSlug is a size slug argument.
 	ArgSizeSlug = "size"
 	// ArgsSSHKeyPath is a ssh argument.
@@ -182,6 +310,10 @@ const (
 	ArgSSHUser = "ssh-user"
 	// ArgFormat is columns to include in output argment.
 	ArgFormat = "format"
+	// ArgFormatTemplate is a Go template used to format output.
+	ArgFormatTemplate = "format-template"
+	// ArgIgnoreNotFound ignores jsonpath output expressions that match nothing.
+	ArgIgnoreNotFound = "ignore-not-found"
 	// ArgNoHeader hides the output header.
 	ArgNoHeader = "no-header"
 	// ArgPollTime is how long before the next poll argument.
@@ -205,6 +337,14 @@ const (
 
 	// ArgOutput is an output type argument.
 	ArgOutput = "output"
+	// ArgMaxRetries is the number of times a rate limited API request is retried.
+	ArgMaxRetries = "max-retries"
+	// ArgRequestTimeout is the maximum duration of a single HTTP request.
+	ArgRequestTimeout = "request-timeout"
+	// ArgQuiet suppresses notices and warnings.
+	ArgQuiet = "quiet"
+	// ArgInsecureSkipVerify disables TLS certificate verification.
+	ArgInsecureSkipVerify = "insecure-skip-verify"
 
 	// ArgVolumeSize is the size of a volume.
 	ArgVolumeSize = "size"
@@ -352,4 +492,10 @@ const (
 
 	// ArgDatabaseFirewallRuleUUID is the UUID for the firewall rules.
 	ArgDatabaseFirewallRuleUUID = "uuid"
+
+	// ArgDatabaseFirewallRulesFile is a path to a file of firewall rules.
+	ArgDatabaseFirewallRulesFile = "rules-file"
+
+	// ArgDatabaseFirewallRemoveRule the firewall rules to remove.
+	ArgDatabaseFirewallRemoveRule = "remove-rule"
 )
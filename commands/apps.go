@@ -14,25 +14,97 @@ limitations under the License.
 package commands
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
+	"mime"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/digitalocean/doctl"
 	"github.com/digitalocean/doctl/commands/displayers"
 	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/doctl/pkg/listen"
 	"github.com/digitalocean/godo"
+	"github.com/fatih/color"
+	"github.com/gobwas/glob"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
+	yamlv3 "gopkg.in/yaml.v3"
 	"sigs.k8s.io/yaml"
 )
 
+const (
+	// defaultAppWaitTimeout is the default maximum amount of time to wait
+	// for an app deployment to become active.
+	defaultAppWaitTimeout = 30 * time.Minute
+	// defaultAppPollInterval is the default amount of time between app
+	// deployment status checks.
+	defaultAppPollInterval = 5 * time.Second
+	// appSpecFetchTimeout is the maximum amount of time to wait when fetching
+	// an app spec from a URL, unless --request-timeout is set.
+	appSpecFetchTimeout = 30 * time.Second
+)
+
+// Exit codes used when waiting on an app deployment fails, so that scripts
+// can tell failure reasons apart. Other errors exit with code 1.
+const (
+	// exitCodeDeploymentBuildFailed is used when a deployment fails to build.
+	exitCodeDeploymentBuildFailed = 2
+	// exitCodeDeploymentDeployFailed is used when a deployment fails after
+	// building, while deploying.
+	exitCodeDeploymentDeployFailed = 3
+	// exitCodeDeploymentCanceled is used when a deployment is canceled.
+	exitCodeDeploymentCanceled = 4
+	// exitCodeDeploymentTimeout is used when a deployment does not become
+	// active within the wait timeout.
+	exitCodeDeploymentTimeout = 5
+)
+
+// appWaitExitCodesHelp documents the exit codes of commands that wait on a
+// deployment.
+var appWaitExitCodesHelp = fmt.Sprintf(`When using --%s, doctl exits with one of the following codes if the deployment does not become active:
+
+  %d  the deployment failed to build
+  %d  the deployment failed to deploy
+  %d  the deployment was canceled
+  %d  the deployment did not become active within --%s`,
+	doctl.ArgCommandWait,
+	exitCodeDeploymentBuildFailed,
+	exitCodeDeploymentDeployFailed,
+	exitCodeDeploymentCanceled,
+	exitCodeDeploymentTimeout,
+	doctl.ArgTimeout,
+)
+
+var (
+	// appWaitRetryBackoff is the delay before retrying after the first API
+	// failure while waiting on an app. It doubles with each consecutive failure.
+	appWaitRetryBackoff = time.Second
+	// appWaitMaxRetryBackoff caps the delay between retries while waiting on
+	// an app.
+	appWaitMaxRetryBackoff = 30 * time.Second
+)
+
 // Apps creates the apps command.
 func Apps() *Command {
 	cmd := &Command{
@@ -40,7 +112,7 @@ func Apps() *Command {
 			Use:     "apps",
 			Aliases: []string{"app", "a"},
 			Short:   "Display commands for working with apps",
-			Long:    "The subcommands of `doctl app` manage your App Platform apps. For documentation on app specs used by multiple commands, see https://www.digitalocean.com/docs/app-platform/concepts/app-spec.",
+			Long:    "The subcommands of `doctl app` manage your App Platform apps. Commands that take an app id also accept the app's name. For documentation on app specs used by multiple commands, see https://www.digitalocean.com/docs/app-platform/concepts/app-spec.",
 		},
 	}
 
@@ -49,63 +121,141 @@ func Apps() *Command {
 		RunAppsCreate,
 		"create",
 		"Create an app",
-		`Create an app with the given app spec.`,
+		`Create an app with the given app spec.
+
+With --`+doctl.ArgAppUpsert+`, the app whose name matches the name in the app spec is updated instead if it already exists. It is an error for more than one app to have that name.
+
+`+appWaitExitCodesHelp,
 		Writer,
 		aliasOpt("c"),
 		displayerType(&displayers.Apps{}),
 	)
-	AddStringFlag(create, doctl.ArgAppSpec, "", "", `Path to an app spec in JSON or YAML format. Set to "-" to read from stdin.`, requiredOpt())
+	AddStringSliceFlag(create, doctl.ArgAppSpec, "", nil, `Path or URL to an app spec in JSON or YAML format. Set to "-" to read from stdin. Repeat to merge multiple specs in order, with later specs overriding earlier ones.`, requiredOpt())
+	addAppSpecSetFlags(create)
+	AddBoolFlag(create, doctl.ArgAppUpsert, "", false, "Update the app with the same name as the app spec if one exists instead of creating a new app")
+	AddBoolFlag(create, doctl.ArgAppValidateRegion, "", false, "Warn if the region in the app spec is unknown or unavailable before creating the app")
+	AddBoolFlag(create, doctl.ArgAppValidateSizes, "", false, "Check the instance sizes in the app spec against the available instance sizes before creating the app")
+	AddBoolFlag(create, doctl.ArgCommandWait, "", false,
+		"Boolean that specifies whether to wait for the app's initial deployment to complete before returning control to the terminal")
+	AddDurationFlag(create, doctl.ArgTimeout, "", defaultAppWaitTimeout,
+		"The maximum amount of time to wait for the deployment to become active when using --"+doctl.ArgCommandWait)
+	AddDurationFlag(create, doctl.ArgPollInterval, "", defaultAppPollInterval,
+		"The amount of time between deployment status checks when using --"+doctl.ArgCommandWait)
+	AddIntFlag(create, doctl.ArgMaxAPIFailures, "", maxAPIFailures,
+		"The number of consecutive API errors to tolerate when using --"+doctl.ArgCommandWait)
 
-	CmdBuilder(
+	get := CmdBuilder(
 		cmd,
 		RunAppsGet,
 		"get <app id>",
 		"Get an app",
-		`Get an app with the provided id.
+		`Get an app with the provided id. You may pass the app's name instead of its id.
 
-Only basic information is included with the text output format. For complete app details including its app spec, use the JSON format.`,
+Only basic information is included with the text output format. For complete app details including its app spec, use the JSON format.
+
+With --`+doctl.ArgAppComponentURLs+`, the public URL of each route of each component in the spec of the active deployment is shown instead, along with the internal hostname and port of each service. Use the JSON output format to output them as JSON.`,
 		Writer,
 		aliasOpt("g"),
 		displayerType(&displayers.Apps{}),
 	)
+	AddBoolFlag(get, doctl.ArgAppComponentURLs, "", false, "Show the public URLs and internal hostnames of the app's components")
+	AddBoolFlag(get, doctl.ArgCommandWait, "", false,
+		"Boolean that specifies whether to wait for the app to have no in-progress deployment and an active deployment before returning control to the terminal")
+	AddDurationFlag(get, doctl.ArgTimeout, "", defaultAppWaitTimeout,
+		"The maximum amount of time to wait for the app to become stable when using --"+doctl.ArgCommandWait)
+	AddDurationFlag(get, doctl.ArgPollInterval, "", defaultAppPollInterval,
+		"The amount of time between app status checks when using --"+doctl.ArgCommandWait)
+	AddIntFlag(get, doctl.ArgMaxAPIFailures, "", maxAPIFailures,
+		"The number of consecutive API errors to tolerate when using --"+doctl.ArgCommandWait)
 
-	CmdBuilder(
+	list := CmdBuilder(
 		cmd,
 		RunAppsList,
 		"list",
 		"List all apps",
 		`List all apps.
 
-Only basic information is included with the text output format. For complete app details including the app specs, use the JSON format.`,
+Only basic information is included with the text output format. Use --`+doctl.ArgWide+` for additional columns, or the JSON format for complete app details including the app specs.`,
 		Writer,
 		aliasOpt("ls"),
 		displayerType(&displayers.Apps{}),
 	)
+	AddStringFlag(list, doctl.ArgAppFilter, "", "", "Only list apps whose name matches the filter, e.g. name=staging-*. Patterns without wildcards match any name containing them.")
+	AddStringFlag(list, doctl.ArgRegionSlug, "", "", "Only list apps in the given region, e.g. nyc")
+	AddBoolFlag(list, doctl.ArgWide, "", false, "Display additional columns: the live URL, region, tier, and the phase of the active deployment")
+	AddStringFlag(list, doctl.ArgSort, "", "", `Sort apps by a field; one of "name", "created", or "updated"`)
+	AddBoolFlag(list, doctl.ArgSortDesc, "", false, "Sort apps in descending order when using --"+doctl.ArgSort)
+
+	listBandwidth := CmdBuilder(
+		cmd,
+		RunAppsListBandwidth,
+		"list-bandwidth [<app id>...]",
+		"List the bandwidth used by apps",
+		`List the bandwidth used by each app over a period of days, in GiB. You may pass the apps' ids or names to only report on those apps.
+
+The period runs from the start of the day given with --`+doctl.ArgAppBandwidthSince+` to the end of the day given with --`+doctl.ArgAppBandwidthUntil+`, both in UTC. By default, it is the current month up to and including today.
+
+Pass --`+doctl.ArgAppBandwidthCSV+` to output the usage as CSV, e.g. for a spreadsheet.`,
+		Writer,
+		displayerType(&displayers.AppBandwidthUsages{}),
+	)
+	AddStringFlag(listBandwidth, doctl.ArgAppBandwidthSince, "", "", "The first day of the period, e.g. 2021-03-01. Defaults to the first day of the current month.")
+	AddStringFlag(listBandwidth, doctl.ArgAppBandwidthUntil, "", "", "The last day of the period, e.g. 2021-03-31. Defaults to today.")
+	AddBoolFlag(listBandwidth, doctl.ArgAppBandwidthCSV, "", false, "Output the app ID, name, and GiB used of each app as CSV")
+
+	getMetrics := CmdBuilder(
+		cmd,
+		RunAppsGetMetrics,
+		"get-metrics <app id>",
+		"Get an app's resource usage",
+		`Get the average and maximum CPU and memory usage of each component of an app over a recent period, as percentages of the component's instance size, followed by the bandwidth used by the app.
+
+Bandwidth is only reported per UTC day, so it covers every day that overlaps the period. With the JSON output format, the component metrics and the bandwidth are output together.`,
+		Writer,
+		displayerType(&displayers.AppMetrics{}),
+	)
+	AddDurationFlag(getMetrics, doctl.ArgAppMetricsPeriod, "", 24*time.Hour, "How far back to report metrics, e.g. 1h or 168h")
 
 	update := CmdBuilder(
 		cmd,
 		RunAppsUpdate,
-		"update <app id>",
+		"update [<app id>]",
 		"Update an app",
-		`Update the specified app with the given app spec. For more information about app specs, see https://www.digitalocean.com/docs/app-platform/concepts/app-spec`,
+		`Update the specified app with the given app spec. For more information about app specs, see https://www.digitalocean.com/docs/app-platform/concepts/app-spec
+
+Instead of an app id, you may pass the app's name, or pass --`+doctl.ArgAppByName+` to update the app whose name matches the name in the app spec.
+
+If the new spec removes database components from the app, their data may be lost, so you are asked to confirm the update unless --`+doctl.ArgForce+` is passed. To find removed components, the app's current spec is fetched before it is updated; --`+doctl.ArgForce+` skips that request. Without a terminal to answer the prompt, such an update is aborted, so scripts that mean to remove databases must pass --`+doctl.ArgForce+`.`,
 		Writer,
 		aliasOpt("u"),
 		displayerType(&displayers.Apps{}),
 	)
-	AddStringFlag(update, doctl.ArgAppSpec, "", "", `Path to an app spec in JSON or YAML format. Set to "-" to read from stdin.`, requiredOpt())
+	AddStringSliceFlag(update, doctl.ArgAppSpec, "", nil, `Path or URL to an app spec in JSON or YAML format. Set to "-" to read from stdin. Repeat to merge multiple specs in order, with later specs overriding earlier ones.`, requiredOpt())
+	addAppSpecSetFlags(update)
+	AddBoolFlag(update, doctl.ArgAppByName, "", false, "Find the app to update by the name in the app spec instead of passing an app id")
+	AddBoolFlag(update, doctl.ArgAppValidateRegion, "", false, "Warn if the region in the app spec is unknown or unavailable before updating the app")
+	AddBoolFlag(update, doctl.ArgAppValidateSizes, "", false, "Check the instance sizes in the app spec against the available instance sizes before updating the app")
+	AddBoolFlag(update, doctl.ArgForce, doctl.ArgShortForce, false, "Update the app without a confirmation prompt, even if database components are removed")
 
 	deleteApp := CmdBuilder(
 		cmd,
 		RunAppsDelete,
-		"delete <app id>",
-		"Deletes an app",
-		`Deletes an app with the provided id.
+		"delete <app id>...",
+		"Deletes one or more apps",
+		`Deletes the apps with the provided ids. You may pass app names instead of ids.
+
+This permanently deletes the apps and all their associated deployments. When multiple app ids are given, doctl attempts to delete each of them, reports which deletions failed, and exits with an error if any of them did.
 
-This permanently deletes the app and all its associated deployments.`,
+Use --all with --filter name=<pattern> to delete every app whose name matches the pattern, e.g. throwaway preview apps.
+
+When deleting several apps, use --`+doctl.ArgConcurrency+` to delete up to that many at the same time. Results are still reported in order.`,
 		Writer,
 		aliasOpt("d"),
 	)
 	AddBoolFlag(deleteApp, doctl.ArgForce, doctl.ArgShortForce, false, "Delete the App without a confirmation prompt")
+	AddBoolFlag(deleteApp, doctl.ArgAppDeleteAll, "", false, "Delete every app matching --filter. Without --filter, --force is required. Deleting every app in the account always requires a typed confirmation")
+	AddStringFlag(deleteApp, doctl.ArgAppFilter, "", "", "With --all, only delete apps whose name matches the filter, e.g. name=preview-*")
+	AddIntFlag(deleteApp, doctl.ArgConcurrency, "", 1, "The maximum number of apps to delete at the same time")
 
 	deploymentCreate := CmdBuilder(
 		cmd,
@@ -114,58 +264,203 @@ This permanently deletes the app and all its associated deployments.`,
 		"Create a deployment",
 		`Create a deployment for an app.
 
-Creating an app deployment will pull the latest changes from your repository and schedule a new deployment for your app.`,
+Creating an app deployment will pull the latest changes from your repository and schedule a new deployment for your app.
+
+Use --`+doctl.ArgAppSetInstanceCount+` and --`+doctl.ArgAppSetInstanceSize+` to scale services, workers, or jobs without editing the app spec, e.g. `+"`"+`--set-instance-count web=3`+"`"+`. The app's spec is updated with the new values, which deploys the app, so they stay in effect until the spec is changed again. --`+doctl.ArgAppForceRebuild+` cannot be used with them.
+
+`+appWaitExitCodesHelp,
 		Writer,
 		aliasOpt("cd"),
 		displayerType(&displayers.Deployments{}),
 	)
 	AddBoolFlag(deploymentCreate, doctl.ArgAppForceRebuild, "", false, "Force a re-build even if a previous build is eligible for reuse")
+	AddStringSliceFlag(deploymentCreate, doctl.ArgAppSetInstanceCount, "", nil,
+		"Set the instance count of a component before deploying, e.g. web=3. Repeat or comma-separate to set multiple components.")
+	AddStringSliceFlag(deploymentCreate, doctl.ArgAppSetInstanceSize, "", nil,
+		"Set the instance size of a component before deploying, e.g. web=professional-s. Repeat or comma-separate to set multiple components.")
 	AddBoolFlag(deploymentCreate, doctl.ArgCommandWait, "", false,
 		"Boolean that specifies whether to wait for apps deployment to complete before returning control to the terminal")
+	AddDurationFlag(deploymentCreate, doctl.ArgTimeout, "", defaultAppWaitTimeout,
+		"The maximum amount of time to wait for the deployment to become active when using --"+doctl.ArgCommandWait)
+	AddDurationFlag(deploymentCreate, doctl.ArgPollInterval, "", defaultAppPollInterval,
+		"The amount of time between deployment status checks when using --"+doctl.ArgCommandWait)
+	AddIntFlag(deploymentCreate, doctl.ArgMaxAPIFailures, "", maxAPIFailures,
+		"The number of consecutive API errors to tolerate when using --"+doctl.ArgCommandWait)
 
-	CmdBuilder(
+	getDeployment := CmdBuilder(
 		cmd,
 		RunAppsGetDeployment,
 		"get-deployment <app id> <deployment id>",
 		"Get a deployment",
 		`Get a deployment for an app.
 
-Only basic information is included with the text output format. For complete app details including its app specs, use the JSON format.`,
+Only basic information is included with the text output format. For complete app details including its app specs, use the JSON format.
+
+With the global `+"`"+`--verbose`+"`"+` flag, the text output also includes the deployment's progress steps along with their timestamps and any error reasons.
+
+Use --`+doctl.ArgCommandWait+` to wait for a deployment that is already in progress, for example one triggered by a push to your repository, to complete before displaying it.
+
+Use --`+doctl.ArgAppDeploymentLogs+` to also print the deployment's build, deploy, or run logs after it is displayed. With --`+doctl.ArgCommandWait+`, logs are printed even if the deployment fails.
+
+Use --`+doctl.ArgAppDeploymentWatch+` to watch the deployment instead: its status is redrawn in place every --`+doctl.ArgPollInterval+`, along with the time spent watching, until it reaches a final phase. When stdout is not a terminal, the status is printed again on every poll instead.
+
+`+appWaitExitCodesHelp,
 		Writer,
 		aliasOpt("gd"),
 		displayerType(&displayers.Deployments{}),
 	)
+	AddBoolFlag(getDeployment, doctl.ArgCommandWait, "", false,
+		"Boolean that specifies whether to wait for the deployment to complete before returning control to the terminal")
+	AddDurationFlag(getDeployment, doctl.ArgTimeout, "", defaultAppWaitTimeout,
+		"The maximum amount of time to wait for the deployment to become active when using --"+doctl.ArgCommandWait)
+	AddDurationFlag(getDeployment, doctl.ArgPollInterval, "", defaultAppPollInterval,
+		"The amount of time between deployment status checks when using --"+doctl.ArgCommandWait+" or --"+doctl.ArgAppDeploymentWatch)
+	AddIntFlag(getDeployment, doctl.ArgMaxAPIFailures, "", maxAPIFailures,
+		"The number of consecutive API errors to tolerate when using --"+doctl.ArgCommandWait+" or --"+doctl.ArgAppDeploymentWatch)
+	AddStringFlag(getDeployment, doctl.ArgAppDeploymentLogs, "", "", `The type of logs to print after the deployment; one of "build", "deploy", or "run"`)
+	AddBoolFlag(getDeployment, doctl.ArgAppDeploymentWatch, "", false,
+		"Redraw the deployment's status every poll interval until it reaches a final phase")
 
-	CmdBuilder(
+	listDeployments := CmdBuilder(
 		cmd,
 		RunAppsListDeployments,
 		"list-deployments <app id>",
 		"List all deployments",
 		`List all deployments for an app.
 
-Only basic information is included with the text output format. For complete app details including the app specs, use the JSON format.`,
+Only basic information is included with the text output format. For complete app details including the app specs, use the JSON format. When listing every deployment as JSON, deployments are written as each page is fetched.`,
 		Writer,
 		aliasOpt("lsd"),
 		displayerType(&displayers.Deployments{}),
 	)
+	AddIntFlag(listDeployments, doctl.ArgPage, "", 0, "Only fetch the given page of deployments. Defaults to fetching every page")
+	AddIntFlag(listDeployments, doctl.ArgPerPage, "", 0, "The number of deployments per page when using --page")
+	AddIntFlag(listDeployments, doctl.ArgLimit, "", 0, "Display at most this many deployments")
+	AddBoolFlag(listDeployments, doctl.ArgAppActiveOnly, "", false, "Only list the app's active deployment")
+	AddBoolFlag(listDeployments, doctl.ArgAppInProgress, "", false, "Only list the app's in-progress deployment. Combine with --active-only to list both")
+
+	events := CmdBuilder(
+		cmd,
+		RunAppsEvents,
+		"events <app id>",
+		"Show a timeline of an app's deployments",
+		`Show a chronological timeline of events for an app, built from its deployments: when each deployment was created and why, when it changed the app spec, when each of its steps started and ended, and its latest phase.
+
+Use --`+doctl.ArgAppLogSince+` to only show recent events.`,
+		Writer,
+		displayerType(&displayers.AppEvents{}),
+	)
+	AddStringFlag(events, doctl.ArgAppLogSince, "", "", "Only show events newer than an RFC3339 timestamp (e.g. 2021-03-01T15:04:05Z) or a relative duration (e.g. 24h)")
+
+	cancelDeployment := CmdBuilder(
+		cmd,
+		RunAppsCancelDeployment,
+		"cancel-deployment <app id> <deployment id>",
+		"Cancel a deployment",
+		`Cancel an in-progress deployment for an app.
+
+Deployments that are already active, errored, or canceled cannot be canceled.`,
+		Writer,
+		displayerType(&displayers.Deployments{}),
+	)
+	AddBoolFlag(cancelDeployment, doctl.ArgForce, doctl.ArgShortForce, false, "Cancel the deployment without a confirmation prompt")
+
+	restart := CmdBuilder(
+		cmd,
+		RunAppsRestart,
+		"restart <app id>",
+		"Restart an app",
+		`Restart an app's components without rebuilding them.
+
+By default all components are restarted; use --`+doctl.ArgAppComponents+` to restart only specific components. If the API does not support restarts, a new deployment without a forced rebuild is created instead, which redeploys all components.
+
+With --`+doctl.ArgCommandWait+`, the status of each component is shown while waiting, and the components that did not come back healthy are reported once the restart completes.
+
+`+appWaitExitCodesHelp,
+		Writer,
+		displayerType(&displayers.Deployments{}),
+	)
+	AddStringSliceFlag(restart, doctl.ArgAppComponents, "", nil, "The names of the components to restart. Defaults to all components.")
+	AddBoolFlag(restart, doctl.ArgCommandWait, "", false,
+		"Boolean that specifies whether to wait for the restart to complete before returning control to the terminal")
+	AddDurationFlag(restart, doctl.ArgTimeout, "", defaultAppWaitTimeout,
+		"The maximum amount of time to wait for the deployment to become active when using --"+doctl.ArgCommandWait)
+	AddDurationFlag(restart, doctl.ArgPollInterval, "", defaultAppPollInterval,
+		"The amount of time between deployment status checks when using --"+doctl.ArgCommandWait)
+	AddIntFlag(restart, doctl.ArgMaxAPIFailures, "", maxAPIFailures,
+		"The number of consecutive API errors to tolerate when using --"+doctl.ArgCommandWait)
+
+	rollback := CmdBuilder(
+		cmd,
+		RunAppsRollback,
+		"rollback <app id> <deployment id>",
+		"Roll back an app to a previous deployment",
+		`Roll back an app to the app spec of a previous deployment.
+
+The app is updated with the spec of the given deployment, which triggers a new deployment. A warning is shown if rolling back removes components, databases, or domains from the app.
+
+`+appWaitExitCodesHelp,
+		Writer,
+		displayerType(&displayers.Apps{}),
+	)
+	AddBoolFlag(rollback, doctl.ArgForce, doctl.ArgShortForce, false, "Roll back the app without a confirmation prompt")
+	AddBoolFlag(rollback, doctl.ArgCommandWait, "", false,
+		"Boolean that specifies whether to wait for the rollback deployment to complete before returning control to the terminal")
+	AddDurationFlag(rollback, doctl.ArgTimeout, "", defaultAppWaitTimeout,
+		"The maximum amount of time to wait for the deployment to become active when using --"+doctl.ArgCommandWait)
+	AddDurationFlag(rollback, doctl.ArgPollInterval, "", defaultAppPollInterval,
+		"The amount of time between deployment status checks when using --"+doctl.ArgCommandWait)
+	AddIntFlag(rollback, doctl.ArgMaxAPIFailures, "", maxAPIFailures,
+		"The number of consecutive API errors to tolerate when using --"+doctl.ArgCommandWait)
 
 	logs := CmdBuilder(
 		cmd,
 		RunAppsGetLogs,
 		"logs <app id> <component name (defaults to all components)>",
 		"Get logs",
-		`Get component logs for a deployment of an app.
+		`Get component logs for a deployment of an app. You may pass the app's name instead of its id.
 
 Three types of logs are supported and can be configured with --`+doctl.ArgAppLogType+`:
 - build
 - deploy
-- run `,
+- run
+
+When following logs without a component name, the logs of every component in the deployment are streamed together, with each line prefixed by its component name. Use --`+doctl.ArgAppLogComponents+` to get the logs of a subset of components in the same way. Their historic logs are merged in the order of the timestamps the lines start with.
+
+With --`+doctl.ArgFormat+` json or --output json, each log line is written as a JSON object with "component", "type", "time", and "message" fields.
+
+With --`+doctl.ArgAppLogJSONRaw+`, followed logs are written as the raw JSON messages received from the log stream, one per line, including any fields besides the log data. It streams the logs of a single component, or of the whole app when no component is given.`,
 		Writer,
 		aliasOpt("l"),
 	)
 	AddStringFlag(logs, doctl.ArgAppDeployment, "", "", "The deployment ID. Defaults to current deployment.")
 	AddStringFlag(logs, doctl.ArgAppLogType, "", strings.ToLower(string(godo.AppLogTypeRun)), "The type of logs.")
 	AddBoolFlag(logs, doctl.ArgAppLogFollow, "f", false, "Follow logs as they are emitted.")
+	AddStringSliceFlag(logs, doctl.ArgAppLogComponents, "", nil, "A comma-separated list of components to get logs for. Lines are prefixed with their component's name and historic logs are merged by timestamp. Cannot be used with a component name argument.")
+	AddStringFlag(logs, doctl.ArgAppLogOutputDir, "", "", "Write each component's logs to a separate <component>-<type>.log file in this directory.")
+	AddBoolFlag(logs, doctl.ArgAppLogNoReconnect, "", false, "Stop following logs when the connection drops instead of reconnecting.")
+	AddIntFlag(logs, doctl.ArgAppLogTail, "", 0, "Only display the last N lines of logs. When following, the last N lines are shown before new lines are streamed. 0 displays all lines.")
+	AddStringFlag(logs, doctl.ArgFormat, "", "text", `the format to output logs in; either "text" or "json". --output json also outputs JSON logs.`)
+	AddStringFlag(logs, doctl.ArgAppLogSince, "", "", "Only display logs newer than an RFC3339 timestamp (e.g. 2021-03-01T15:04:05Z) or a relative duration (e.g. 1h)")
+	AddStringFlag(logs, doctl.ArgAppLogUntil, "", "", "Only display logs older than an RFC3339 timestamp or a relative duration. Cannot be used with --follow.")
+	AddStringFlag(logs, doctl.ArgAppLogGrep, "", "", "Only display log lines matching this regular expression")
+	AddStringFlag(logs, doctl.ArgAppLogGrepInvert, "", "", "Only display log lines not matching this regular expression")
+	AddStringFlag(logs, doctl.ArgAppLogColor, "", "auto", `Color log lines by log level; one of "auto", "always", or "never". "auto" only colors logs written to a terminal.`)
+	AddBoolFlag(logs, doctl.ArgAppLogJSONRaw, "", false, "Write each message of the live log stream verbatim instead of only its log data. Requires --"+doctl.ArgAppLogFollow+".")
+
+	console := CmdBuilder(
+		cmd,
+		RunAppsConsole,
+		"console <app id> <component name>",
+		"Open a console on an app component",
+		`Open an interactive console on a running instance of a service or worker of an app, similar to `+"`"+`kubectl exec`+"`"+`. You may pass the app's name instead of its id.
+
+Your terminal's input is sent to the console's shell and its output is written to your terminal. The console ends when the shell exits.
+
+With --`+doctl.ArgAppConsoleCommand+`, the command is run in the console's shell instead, which exits once it finishes. Your terminal's input isn't read.`,
+		Writer,
+	)
+	AddStringFlag(console, doctl.ArgAppConsoleCommand, "", "", "A command to run non-interactively instead of opening an interactive console")
 
 	CmdBuilder(
 		cmd,
@@ -177,6 +472,18 @@ Three types of logs are supported and can be configured with --`+doctl.ArgAppLog
 		displayerType(&displayers.AppRegions{}),
 	)
 
+	open := CmdBuilder(
+		cmd,
+		RunAppsOpen,
+		"open <app id>",
+		"Open an app in the browser",
+		`Open the live URL of an app in your default browser.
+
+Pass --`+doctl.ArgAppOpenPrint+` to print the URL instead of opening it.`,
+		Writer,
+	)
+	AddBoolFlag(open, doctl.ArgAppOpenPrint, "", false, "Print the app's live URL instead of opening it")
+
 	propose := CmdBuilder(
 		cmd,
 		RunAppsPropose,
@@ -184,124 +491,154 @@ Three types of logs are supported and can be configured with --`+doctl.ArgAppLog
 		"Propose an app spec",
 		`Reviews and validates an app specification for a new or existing app. The request returns some information about the proposed app, including app cost and upgrade cost. If an existing app ID is specified, the app spec is treated as a proposed update to the existing app.
 
-Only basic information is included with the text output format. For complete app details including an updated app spec, use the JSON format.`,
+Only basic information is included with the text output format. For complete app details including an updated app spec, use the JSON format.
+
+To output only the normalized app spec returned by the API, pass --`+doctl.ArgAppProposeSpecFormat+` json or --`+doctl.ArgAppProposeSpecFormat+` yaml.
+
+When --`+doctl.ArgApp+` is given, pass --`+doctl.ArgAppProposeDiff+` to also print a unified diff between the existing app's spec and the proposed spec. With --`+doctl.ArgOutput+` json, the spec and a structured diff are output together as a single JSON object.
+
+Pass --`+doctl.ArgAppProposeOnlyCost+` to output only the monthly cost of the app and its cost on the higher and lower tiers.
+
+If the app spec contains several YAML documents separated by ---, each one is proposed independently and a pass/fail line with its monthly cost is printed for each. Use --`+doctl.ArgAppByName+` to propose each one as an update to the app of the same name.`,
 		Writer,
 		aliasOpt("c"),
 		displayerType(&displayers.Apps{}),
 	)
-	AddStringFlag(propose, doctl.ArgAppSpec, "", "", "Path to an app spec in JSON or YAML format. For more information about app specs, see https://www.digitalocean.com/docs/app-platform/concepts/app-spec", requiredOpt())
-	AddStringFlag(propose, doctl.ArgApp, "", "", "An optional existing app ID. If specified, the app spec will be treated as a proposed update to the existing app.")
+	AddStringFlag(propose, doctl.ArgAppSpec, "", "", "Path or URL to an app spec in JSON or YAML format. For more information about app specs, see https://www.digitalocean.com/docs/app-platform/concepts/app-spec", requiredOpt())
+	addAppSpecSetFlags(propose)
+	AddStringFlag(propose, doctl.ArgApp, "", "", "An optional existing app ID or name. If specified, the app spec will be treated as a proposed update to the existing app.")
+	AddBoolFlag(propose, doctl.ArgAppByName, "", false, "If --app is not specified, find the existing app by the name in the app spec")
+	AddBoolFlag(propose, doctl.ArgAppProposeDiff, "", false, "With --app, also print a diff between the existing app's spec and the proposed spec")
+	AddBoolFlag(propose, doctl.ArgAppProposeOnlyCost, "", false, "Only output the app cost and the upgrade and downgrade costs")
+	AddStringFlag(propose, doctl.ArgAppProposeSpecFormat, "", "", `Only output the normalized app spec returned by the API, in this format; either "json" or "yaml"`)
 
 	cmd.AddCommand(appsSpec())
 	cmd.AddCommand(appsTier())
+	cmd.AddCommand(appsEnv())
+	cmd.AddCommand(appsDomains())
+	cmd.AddCommand(appsAlert())
 
 	return cmd
 }
 
 // RunAppsCreate creates an app.
 func RunAppsCreate(c *CmdConfig) error {
-	specPath, err := c.Doit.GetString(c.NS, doctl.ArgAppSpec)
+	specPaths, err := c.Doit.GetStringSlice(c.NS, doctl.ArgAppSpec)
 	if err != nil {
 		return err
 	}
 
-	appSpec, err := readAppSpec(os.Stdin, specPath)
+	appSpec, err := readAppSpecFromArgs(c, os.Stdin, specPaths)
 	if err != nil {
 		return err
 	}
 
-	app, err := c.Apps().Create(&godo.AppCreateRequest{Spec: appSpec})
+	wait, err := c.Doit.GetBool(c.NS, doctl.ArgCommandWait)
 	if err != nil {
 		return err
 	}
-	notice("App created")
-
-	return c.Display(displayers.Apps{app})
-}
-
-// RunAppsGet gets an app.
-func RunAppsGet(c *CmdConfig) error {
-	if len(c.Args) < 1 {
-		return doctl.NewMissingArgsErr(c.NS)
-	}
-	id := c.Args[0]
 
-	app, err := c.Apps().Get(id)
+	timeout, err := c.Doit.GetDuration(c.NS, doctl.ArgTimeout)
 	if err != nil {
 		return err
 	}
 
-	return c.Display(displayers.Apps{app})
-}
-
-// RunAppsList lists all apps.
-func RunAppsList(c *CmdConfig) error {
-	apps, err := c.Apps().List()
+	pollInterval, err := c.Doit.GetDuration(c.NS, doctl.ArgPollInterval)
 	if err != nil {
 		return err
 	}
 
-	return c.Display(displayers.Apps(apps))
-}
-
-// RunAppsUpdate updates an app.
-func RunAppsUpdate(c *CmdConfig) error {
-	if len(c.Args) < 1 {
-		return doctl.NewMissingArgsErr(c.NS)
-	}
-	id := c.Args[0]
-
-	specPath, err := c.Doit.GetString(c.NS, doctl.ArgAppSpec)
+	maxFailures, err := c.Doit.GetInt(c.NS, doctl.ArgMaxAPIFailures)
 	if err != nil {
 		return err
 	}
 
-	appSpec, err := readAppSpec(os.Stdin, specPath)
+	validateRegion, err := c.Doit.GetBool(c.NS, doctl.ArgAppValidateRegion)
 	if err != nil {
 		return err
 	}
+	if validateRegion {
+		if err := checkAppSpecRegion(c.Apps(), appSpec); err != nil {
+			return err
+		}
+	}
 
-	app, err := c.Apps().Update(id, &godo.AppUpdateRequest{Spec: appSpec})
+	validateSizes, err := c.Doit.GetBool(c.NS, doctl.ArgAppValidateSizes)
 	if err != nil {
 		return err
 	}
-	notice("App updated")
-
-	return c.Display(displayers.Apps{app})
-}
-
-// RunAppsDelete deletes an app.
-func RunAppsDelete(c *CmdConfig) error {
-	if len(c.Args) < 1 {
-		return doctl.NewMissingArgsErr(c.NS)
+	if validateSizes {
+		if err := checkAppSpecInstanceSizes(c.Apps(), appSpec); err != nil {
+			return err
+		}
 	}
-	id := c.Args[0]
 
-	force, err := c.Doit.GetBool(c.NS, doctl.ArgForce)
+	upsert, err := c.Doit.GetBool(c.NS, doctl.ArgAppUpsert)
 	if err != nil {
 		return err
 	}
+	var existingID string
+	if upsert {
+		ids, err := findAppIDsByName(c.Apps(), appSpec.Name)
+		if err != nil {
+			return err
+		}
+		if len(ids) > 1 {
+			return fmt.Errorf("%d apps are named %q: %s", len(ids), appSpec.Name, strings.Join(ids, ", "))
+		}
+		if len(ids) == 1 {
+			existingID = ids[0]
+		}
+	}
 
-	if !force && AskForConfirmDelete("App", 1) != nil {
-		return fmt.Errorf("Operation aborted.")
+	var app *godo.App
+	if existingID != "" {
+		app, err = c.Apps().Update(existingID, &godo.AppUpdateRequest{Spec: appSpec})
+		if err != nil {
+			return err
+		}
+		notice("App updated")
+	} else {
+		app, err = c.Apps().Create(&godo.AppCreateRequest{Spec: appSpec})
+		if err != nil {
+			return err
+		}
+		notice("App created")
 	}
 
-	err = c.Apps().Delete(id)
-	if err != nil {
-		return err
+	if wait {
+		// An update leaves the previous deployment active until its own
+		// deployment replaces it, so don't mistake it for the new one.
+		var previousID string
+		if existingID != "" && app.ActiveDeployment != nil {
+			previousID = app.ActiveDeployment.ID
+		}
+
+		apps := c.Apps()
+		notice("App deployment is in progress, waiting for deployment to be running")
+		deployment, err := waitForAppNewDeploymentRunning(apps, app.ID, previousID, timeout, pollInterval, maxFailures)
+		if err != nil {
+			if deployment != nil {
+				return fmt.Errorf("app deployment %s couldn't enter `running` state: %w", deployment.ID, err)
+			}
+			return fmt.Errorf("app deployment couldn't enter `running` state: %w", err)
+		}
+
+		app, err = apps.Get(app.ID)
+		if err != nil {
+			return err
+		}
 	}
-	notice("App deleted")
 
-	return nil
+	return c.Display(displayers.Apps{app})
 }
 
-// RunAppsCreateDeployment creates a deployment for an app.
-func RunAppsCreateDeployment(c *CmdConfig) error {
+// RunAppsGet gets an app.
+func RunAppsGet(c *CmdConfig) error {
 	if len(c.Args) < 1 {
 		return doctl.NewMissingArgsErr(c.NS)
 	}
-	appID := c.Args[0]
-	forceRebuild, err := c.Doit.GetBool(c.NS, doctl.ArgAppForceRebuild)
+	id, err := resolveAppID(c.Apps(), c.Args[0])
 	if err != nil {
 		return err
 	}
@@ -311,493 +648,5543 @@ func RunAppsCreateDeployment(c *CmdConfig) error {
 		return err
 	}
 
-	deployment, err := c.Apps().CreateDeployment(appID, forceRebuild)
+	componentURLs, err := c.Doit.GetBool(c.NS, doctl.ArgAppComponentURLs)
 	if err != nil {
 		return err
 	}
 
+	var app *godo.App
 	if wait {
-		apps := c.Apps()
-		notice("App deplpyment is in progress, waiting for deployment to be running")
-		deployment, err = waitForAppDeploymentRunning(apps, appID, deployment.ID)
+		timeout, err := c.Doit.GetDuration(c.NS, doctl.ArgTimeout)
 		if err != nil {
-			warn("App deplpyment couldn't enter `running` state: %v", err)
-			return c.Display(displayers.Deployments{deployment})
+			return err
+		}
+
+		pollInterval, err := c.Doit.GetDuration(c.NS, doctl.ArgPollInterval)
+		if err != nil {
+			return err
+		}
+
+		maxFailures, err := c.Doit.GetInt(c.NS, doctl.ArgMaxAPIFailures)
+		if err != nil {
+			return err
+		}
+
+		app, err = waitForAppStable(c.Apps(), id, timeout, pollInterval, maxFailures)
+		if err != nil {
+			return err
+		}
+	} else {
+		app, err = c.Apps().Get(id)
+		if err != nil {
+			return err
 		}
 	}
 
-	notice("Deployment created")
+	if !componentURLs {
+		return c.Display(displayers.Apps{app})
+	}
 
-	return c.Display(displayers.Deployments{deployment})
+	urls, err := appComponentURLs(app)
+	if err != nil {
+		return err
+	}
+	return c.Display(urls)
+}
+
+// appComponentURLs returns the public URL of each route of each component in
+// the spec of app's active deployment, or of app if it has none, followed by
+// the internal hostname and ports of each service.
+func appComponentURLs(app *godo.App) (displayers.AppComponentURLs, error) {
+	spec := app.Spec
+	if app.ActiveDeployment != nil && app.ActiveDeployment.Spec != nil {
+		spec = app.ActiveDeployment.Spec
+	}
+	if spec == nil {
+		return displayers.AppComponentURLs{}, nil
+	}
+
+	var routes []displayers.AppComponentURL
+	for _, s := range spec.Services {
+		for _, r := range s.Routes {
+			routes = append(routes, displayers.AppComponentURL{Component: s.Name, Type: "public", URL: r.Path})
+		}
+	}
+	for _, s := range spec.StaticSites {
+		for _, r := range s.Routes {
+			routes = append(routes, displayers.AppComponentURL{Component: s.Name, Type: "public", URL: r.Path})
+		}
+	}
+
+	urls := displayers.AppComponentURLs{}
+	if len(routes) > 0 {
+		liveURL, err := appLiveURL(app)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range routes {
+			r.URL = strings.TrimSuffix(liveURL, "/") + r.URL
+			urls = append(urls, r)
+		}
+	}
+
+	for _, s := range spec.Services {
+		ports := s.InternalPorts
+		if s.HTTPPort != 0 {
+			ports = append([]int64{s.HTTPPort}, ports...)
+		}
+		for _, port := range ports {
+			urls = append(urls, displayers.AppComponentURL{
+				Component: s.Name,
+				Type:      "internal",
+				URL:       fmt.Sprintf("%s:%d", s.Name, port),
+			})
+		}
+	}
+	return urls, nil
 }
 
-// waitForAppDeploymentRunning waits for a app deployment to be running.
-func waitForAppDeploymentRunning(apps do.AppsService, appID string, deploymentID string) (*godo.Deployment, error) {
+// waitForAppStable waits for an app to have no in-progress deployment and an
+// active deployment in the active phase. The timeout applies to the whole
+// wait; a timeout of zero waits indefinitely.
+func waitForAppStable(apps do.AppsService, appID string, timeout, pollInterval time.Duration, maxFailures int) (*godo.App, error) {
+	if pollInterval <= 0 {
+		pollInterval = defaultAppPollInterval
+	}
+	if maxFailures <= 0 {
+		maxFailures = maxAPIFailures
+	}
+	deadline := appWaitDeadline(timeout)
+
+	progress := newWaitProgress()
+	defer progress.done()
+
 	failCount := 0
-	printNewLineSet := false
 	for i := 0; ; i++ {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return nil, fmt.Errorf("app did not become stable within %s", timeout)
+		}
+
 		if i != 0 {
-			fmt.Fprint(os.Stderr, ".")
-			if !printNewLineSet {
-				printNewLineSet = true
-				defer fmt.Fprintln(os.Stderr)
-			}
+			progress.tick()
 		}
 
-		deployment, err := apps.GetDeployment(appID, deploymentID)
-		if err == nil {
-			failCount = 0
-		} else {
+		app, err := apps.Get(appID)
+		if err != nil {
 			// Allow for transient API failures
 			failCount++
-			if failCount >= maxAPIFailures {
+			if failCount >= maxFailures {
 				return nil, err
 			}
-		}
-
-		if deployment == nil {
-			time.Sleep(1 * time.Second)
+			time.Sleep(appWaitRetryDelay(failCount))
 			continue
 		}
+		failCount = 0
 
-		switch deployment.Phase {
-		case godo.DeploymentPhase_PendingBuild:
-			fallthrough
-		case godo.DeploymentPhase_PendingDeploy:
-			fallthrough
-		case godo.DeploymentPhase_Building:
-			fallthrough
-		case godo.DeploymentPhase_Deploying:
-			time.Sleep(5 * time.Second)
-
-		case godo.DeploymentPhase_Active:
-			return deployment, nil
-
-		case godo.DeploymentPhase_Error:
-			fallthrough
-		case godo.DeploymentPhase_Canceled:
-			fallthrough
-		case godo.DeploymentPhase_Unknown:
-			return deployment, fmt.Errorf("phase: [%s]", deployment.Phase)
-
-		default:
-			return deployment, fmt.Errorf("Unknown phase: [%s]", deployment.Phase)
+		if app.InProgressDeployment == nil && app.ActiveDeployment != nil && app.ActiveDeployment.Phase == godo.DeploymentPhase_Active {
+			return app, nil
+		}
+		if app.InProgressDeployment != nil {
+			progress.update(phaseStatus(string(app.InProgressDeployment.Phase)))
+		} else {
+			progress.update("Waiting for an active deployment")
 		}
+		time.Sleep(pollInterval)
 	}
 }
 
-// RunAppsGetDeployment gets a deployment for an app.
-func RunAppsGetDeployment(c *CmdConfig) error {
-	if len(c.Args) < 2 {
-		return doctl.NewMissingArgsErr(c.NS)
+// RunAppsList lists all apps.
+func RunAppsList(c *CmdConfig) error {
+	filter, err := c.Doit.GetString(c.NS, doctl.ArgAppFilter)
+	if err != nil {
+		return err
 	}
-	appID := c.Args[0]
-	deploymentID := c.Args[1]
 
-	deployment, err := c.Apps().GetDeployment(appID, deploymentID)
+	region, err := c.Doit.GetString(c.NS, doctl.ArgRegionSlug)
 	if err != nil {
 		return err
 	}
 
-	return c.Display(displayers.Deployments{deployment})
-}
+	wide, err := c.Doit.GetBool(c.NS, doctl.ArgWide)
+	if err != nil {
+		return err
+	}
 
-// RunAppsListDeployments lists deployments for an app.
-func RunAppsListDeployments(c *CmdConfig) error {
+	sortField, err := c.Doit.GetString(c.NS, doctl.ArgSort)
+	if err != nil {
+		return err
+	}
+
+	sortDesc, err := c.Doit.GetBool(c.NS, doctl.ArgSortDesc)
+	if err != nil {
+		return err
+	}
+
+	nameMatch, err := appFilterMatcher(filter)
+	if err != nil {
+		return err
+	}
+
+	apps, err := c.Apps().List()
+	if err != nil {
+		return err
+	}
+
+	matched := make([]*godo.App, 0, len(apps))
+	for _, app := range apps {
+		if nameMatch != nil && (app.Spec == nil || !nameMatch(app.Spec.Name)) {
+			continue
+		}
+		if region != "" && appRegionSlug(app) != region {
+			continue
+		}
+		matched = append(matched, app)
+	}
+
+	if sortField != "" {
+		if err := displayers.SortApps(matched, sortField, sortDesc); err != nil {
+			return err
+		}
+	}
+
+	if wide {
+		return c.Display(displayers.AppsWide(matched))
+	}
+	return c.Display(displayers.Apps(matched))
+}
+
+// appFilterMatcher parses a name=<pattern> filter and returns a function that
+// matches app names against it. An empty filter returns a nil matcher.
+func appFilterMatcher(filter string) (func(string) bool, error) {
+	if filter == "" {
+		return nil, nil
+	}
+	kv := strings.SplitN(filter, "=", 2)
+	if len(kv) != 2 || kv[0] != "name" || kv[1] == "" {
+		return nil, fmt.Errorf("invalid filter %q, must be in the form name=<pattern>", filter)
+	}
+	return appNameMatcher(kv[1])
+}
+
+// appNameMatcher returns a function that matches app names against pattern.
+// Patterns containing glob wildcards are matched as globs; other patterns match
+// any name containing them.
+func appNameMatcher(pattern string) (func(string) bool, error) {
+	if !strings.ContainsAny(pattern, "*?[{") {
+		return func(name string) bool {
+			return strings.Contains(name, pattern)
+		}, nil
+	}
+
+	g, err := glob.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("Unknown glob %q", pattern)
+	}
+	return g.Match, nil
+}
+
+// appRegionSlug returns the slug of the region an app is deployed in.
+func appRegionSlug(app *godo.App) string {
+	if app.Region != nil {
+		return app.Region.Slug
+	}
+	if app.Spec != nil {
+		return app.Spec.Region
+	}
+	return ""
+}
+
+// checkAppSpecRegion warns if the region in spec is not a known app region or
+// is currently unavailable. A region may be given as a region slug or as one
+// of its data centers.
+func checkAppSpecRegion(apps do.AppsService, spec *godo.AppSpec) error {
+	if spec.Region == "" {
+		return nil
+	}
+
+	regions, err := apps.ListRegions()
+	if err != nil {
+		return fmt.Errorf("listing app regions: %w", err)
+	}
+
+	var slugs []string
+	for _, r := range regions {
+		slugs = append(slugs, r.Slug)
+		match := r.Slug == spec.Region
+		for _, dc := range r.DataCenters {
+			match = match || dc == spec.Region
+		}
+		if !match {
+			continue
+		}
+		if r.Disabled {
+			if r.Reason != "" {
+				warn("Region %s is currently unavailable: %s", spec.Region, r.Reason)
+			} else {
+				warn("Region %s is currently unavailable", spec.Region)
+			}
+		}
+		return nil
+	}
+
+	warn("Unknown region %s; available regions are: %s", spec.Region, strings.Join(slugs, ", "))
+	return nil
+}
+
+// checkAppSpecInstanceSizes returns an error listing the valid instance size
+// slugs if a component of spec uses an instance size that doesn't exist.
+func checkAppSpecInstanceSizes(apps do.AppsService, spec *godo.AppSpec) error {
+	type component struct{ name, size string }
+	var components []component
+	for _, s := range spec.Services {
+		components = append(components, component{s.Name, s.InstanceSizeSlug})
+	}
+	for _, w := range spec.Workers {
+		components = append(components, component{w.Name, w.InstanceSizeSlug})
+	}
+	for _, j := range spec.Jobs {
+		components = append(components, component{j.Name, j.InstanceSizeSlug})
+	}
+
+	sizes, err := apps.ListInstanceSizes()
+	if err != nil {
+		return fmt.Errorf("listing app instance sizes: %w", err)
+	}
+	known := make(map[string]bool, len(sizes))
+	slugs := make([]string, 0, len(sizes))
+	for _, size := range sizes {
+		known[size.Slug] = true
+		slugs = append(slugs, size.Slug)
+	}
+
+	var invalid []string
+	for _, comp := range components {
+		if comp.size != "" && !known[comp.size] {
+			invalid = append(invalid, fmt.Sprintf("component %q has unknown instance size %q", comp.name, comp.size))
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("%s; valid instance sizes are: %s", strings.Join(invalid, ", "), strings.Join(slugs, ", "))
+	}
+	return nil
+}
+
+// RunAppsUpdate updates an app.
+func RunAppsUpdate(c *CmdConfig) error {
+	byName, err := c.Doit.GetBool(c.NS, doctl.ArgAppByName)
+	if err != nil {
+		return err
+	}
+	if len(c.Args) < 1 && !byName {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	if len(c.Args) > 0 && byName {
+		return fmt.Errorf("an app id cannot be combined with --%s", doctl.ArgAppByName)
+	}
+
+	specPaths, err := c.Doit.GetStringSlice(c.NS, doctl.ArgAppSpec)
+	if err != nil {
+		return err
+	}
+
+	appSpec, err := readAppSpecFromArgs(c, os.Stdin, specPaths)
+	if err != nil {
+		return err
+	}
+
+	var id string
+	if byName {
+		id, err = findAppIDByName(c.Apps(), appSpec.Name)
+		if err != nil {
+			return err
+		}
+	} else {
+		id, err = resolveAppID(c.Apps(), c.Args[0])
+		if err != nil {
+			return err
+		}
+	}
+
+	validateRegion, err := c.Doit.GetBool(c.NS, doctl.ArgAppValidateRegion)
+	if err != nil {
+		return err
+	}
+	if validateRegion {
+		if err := checkAppSpecRegion(c.Apps(), appSpec); err != nil {
+			return err
+		}
+	}
+
+	validateSizes, err := c.Doit.GetBool(c.NS, doctl.ArgAppValidateSizes)
+	if err != nil {
+		return err
+	}
+	if validateSizes {
+		if err := checkAppSpecInstanceSizes(c.Apps(), appSpec); err != nil {
+			return err
+		}
+	}
+
+	force, err := c.Doit.GetBool(c.NS, doctl.ArgForce)
+	if err != nil {
+		return err
+	}
+	if !force {
+		// The current spec is fetched even when stdin isn't a terminal: the
+		// prompt then fails and aborts the update, which is safer than
+		// removing a database unconfirmed. Only --force skips the request.
+		current, err := c.Apps().Get(id)
+		if err != nil {
+			return err
+		}
+		removed := removedAppDatabases(current.Spec, appSpec)
+		if len(removed) > 0 && AskForConfirm(fmt.Sprintf("update app %s and remove its database component(s) %s? Their data may be lost.", id, strings.Join(removed, ", "))) != nil {
+			return fmt.Errorf("Operation aborted.")
+		}
+	}
+
+	app, err := c.Apps().Update(id, &godo.AppUpdateRequest{Spec: appSpec})
+	if err != nil {
+		return err
+	}
+	notice("App updated")
+
+	return c.Display(displayers.Apps{app})
+}
+
+// removedAppDatabases returns the names of the database components of from
+// that are missing from to. Databases are the only components of an app spec
+// that hold data, so they are the only removals that need confirming.
+func removedAppDatabases(from, to *godo.AppSpec) []string {
+	if from == nil {
+		return nil
+	}
+
+	kept := make(map[string]bool)
+	if to != nil {
+		for _, db := range to.Databases {
+			kept[db.Name] = true
+		}
+	}
+
+	var removed []string
+	for _, db := range from.Databases {
+		if !kept[db.Name] {
+			removed = append(removed, db.Name)
+		}
+	}
+	return removed
+}
+
+// resolveAppID returns idOrName if it looks like an app ID, and otherwise the
+// ID of the only app named idOrName.
+func resolveAppID(apps do.AppsService, idOrName string) (string, error) {
+	return newAppIDResolver(apps).resolve(idOrName)
+}
+
+// appIDResolver resolves app names to app IDs. Apps are listed at most once,
+// the first time a name needs resolving.
+type appIDResolver struct {
+	apps   do.AppsService
+	list   []*godo.App
+	listed bool
+}
+
+func newAppIDResolver(apps do.AppsService) *appIDResolver {
+	return &appIDResolver{apps: apps}
+}
+
+// resolve returns idOrName if it looks like an app ID, and otherwise the ID of
+// the only app named idOrName.
+func (r *appIDResolver) resolve(idOrName string) (string, error) {
+	if idOrName == "" || uuidPattern.MatchString(idOrName) {
+		return idOrName, nil
+	}
+
+	if !r.listed {
+		list, err := r.apps.List()
+		if err != nil {
+			return "", err
+		}
+		r.list, r.listed = list, true
+	}
+	return onlyAppID(idOrName, appIDsNamed(r.list, idOrName))
+}
+
+// findAppIDByName returns the ID of the only app whose spec is named name.
+func findAppIDByName(apps do.AppsService, name string) (string, error) {
+	ids, err := findAppIDsByName(apps, name)
+	if err != nil {
+		return "", err
+	}
+	return onlyAppID(name, ids)
+}
+
+// findAppIDsByName returns the IDs of all apps whose spec is named name.
+func findAppIDsByName(apps do.AppsService, name string) ([]string, error) {
+	if name == "" {
+		return nil, errors.New("the app spec has no name to find the app by")
+	}
+
+	list, err := apps.List()
+	if err != nil {
+		return nil, err
+	}
+	return appIDsNamed(list, name), nil
+}
+
+// appIDsNamed returns the IDs of the apps in list whose spec is named name.
+func appIDsNamed(list []*godo.App, name string) []string {
+	var ids []string
+	for _, app := range list {
+		if app.Spec != nil && app.Spec.Name == name {
+			ids = append(ids, app.ID)
+		}
+	}
+	return ids
+}
+
+// onlyAppID returns the only ID in ids, which are the IDs of the apps named
+// name, or an error if there is not exactly one.
+func onlyAppID(name string, ids []string) (string, error) {
+	switch len(ids) {
+	case 0:
+		return "", fmt.Errorf("no app named %q found", name)
+	case 1:
+		return ids[0], nil
+	default:
+		return "", fmt.Errorf("%d apps are named %q: %s", len(ids), name, strings.Join(ids, ", "))
+	}
+}
+
+// RunAppsDelete deletes an app.
+func RunAppsDelete(c *CmdConfig) error {
+	force, err := c.Doit.GetBool(c.NS, doctl.ArgForce)
+	if err != nil {
+		return err
+	}
+
+	all, err := c.Doit.GetBool(c.NS, doctl.ArgAppDeleteAll)
+	if err != nil {
+		return err
+	}
+	if all {
+		if len(c.Args) > 0 {
+			return fmt.Errorf("app ids cannot be combined with --%s", doctl.ArgAppDeleteAll)
+		}
+		return runAppsDeleteAll(c, force)
+	}
+
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+
+	resolver := newAppIDResolver(c.Apps())
+	ids := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		if ids[i], err = resolver.resolve(arg); err != nil {
+			return err
+		}
+	}
+
+	if !force {
+		if len(ids) == 1 {
+			id := ids[0]
+			app, err := c.Apps().Get(id)
+			if err != nil {
+				return err
+			}
+			var name string
+			if app.Spec != nil {
+				name = app.Spec.Name
+			}
+			err = AskForConfirmDeleteNamed("app", name, id)
+		} else {
+			err = AskForConfirmDelete("App", len(ids))
+		}
+		if err != nil {
+			return fmt.Errorf("Operation aborted.")
+		}
+	}
+
+	if len(ids) == 1 {
+		err = c.Apps().Delete(ids[0])
+		if err != nil {
+			return err
+		}
+		notice("App deleted")
+		return nil
+	}
+
+	concurrency, err := c.Doit.GetInt(c.NS, doctl.ArgConcurrency)
+	if err != nil {
+		return err
+	}
+	return deleteApps(c.Apps(), ids, ids, concurrency)
+}
+
+// deleteApps deletes the apps with the given ids, running up to concurrency
+// deletions at the same time. The result of each deletion is reported in the
+// order of ids, referring to each app by its entry in names, and the apps
+// that couldn't be deleted are returned in a single error.
+func deleteApps(apps do.AppsService, ids, names []string, concurrency int) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	errs := make([]error, len(ids))
+	done := make([]chan struct{}, len(ids))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	go func() {
+		sem := make(chan struct{}, concurrency)
+		for i := range ids {
+			sem <- struct{}{}
+			go func(i int) {
+				defer func() {
+					<-sem
+					close(done[i])
+				}()
+				errs[i] = apps.Delete(ids[i])
+			}(i)
+		}
+	}()
+
+	var failed []string
+	for i := range ids {
+		<-done[i]
+		if errs[i] != nil {
+			warn("Unable to delete app %s: %v", names[i], errs[i])
+			failed = append(failed, ids[i])
+			continue
+		}
+		notice("App %s deleted", names[i])
+	}
+
+	notice("Deleted %d of %d apps", len(ids)-len(failed), len(ids))
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed to delete %d app(s): %s", len(failed), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// runAppsDeleteAll deletes every app matching --filter. Deleting every app in
+// the account requires --force without a filter, and always requires a typed
+// confirmation, even when the filter happens to match every app.
+func runAppsDeleteAll(c *CmdConfig, force bool) error {
+	filter, err := c.Doit.GetString(c.NS, doctl.ArgAppFilter)
+	if err != nil {
+		return err
+	}
+	if filter == "" && !force {
+		return fmt.Errorf("--%s requires --%s, or --%s to delete every app in the account", doctl.ArgAppDeleteAll, doctl.ArgAppFilter, doctl.ArgForce)
+	}
+
+	nameMatch, err := appFilterMatcher(filter)
+	if err != nil {
+		return err
+	}
+
+	apps, err := c.Apps().List()
+	if err != nil {
+		return err
+	}
+
+	matched := make([]*godo.App, 0, len(apps))
+	for _, app := range apps {
+		if nameMatch != nil && (app.Spec == nil || !nameMatch(app.Spec.Name)) {
+			continue
+		}
+		matched = append(matched, app)
+	}
+	if len(matched) == 0 {
+		fmt.Fprintln(c.Out, "Nothing to delete: no apps match")
+		return nil
+	}
+
+	names := make([]string, 0, len(matched))
+	for _, app := range matched {
+		var name string
+		if app.Spec != nil {
+			name = app.Spec.Name
+		}
+		names = append(names, fmt.Sprintf("%s (%s)", name, app.ID))
+	}
+
+	// A filter matching every app, e.g. name=*, is as dangerous as no filter.
+	if len(matched) == len(apps) {
+		message := fmt.Sprintf("This will delete all %d apps in the account: %s.", len(matched), strings.Join(names, ", "))
+		if AskForTypedConfirm(message, "delete all apps") != nil {
+			return fmt.Errorf("Operation aborted.")
+		}
+	} else if !force {
+		resourceType := "app"
+		if len(matched) > 1 {
+			resourceType = "apps"
+		}
+		if AskForConfirm(fmt.Sprintf("delete %d %s? [%s]", len(matched), resourceType, strings.Join(names, ", "))) != nil {
+			return fmt.Errorf("Operation aborted.")
+		}
+	}
+
+	concurrency, err := c.Doit.GetInt(c.NS, doctl.ArgConcurrency)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(matched))
+	for _, app := range matched {
+		ids = append(ids, app.ID)
+	}
+	return deleteApps(c.Apps(), ids, names, concurrency)
+}
+
+// RunAppsCreateDeployment creates a deployment for an app.
+func RunAppsCreateDeployment(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+	forceRebuild, err := c.Doit.GetBool(c.NS, doctl.ArgAppForceRebuild)
+	if err != nil {
+		return err
+	}
+
+	wait, err := c.Doit.GetBool(c.NS, doctl.ArgCommandWait)
+	if err != nil {
+		return err
+	}
+
+	timeout, err := c.Doit.GetDuration(c.NS, doctl.ArgTimeout)
+	if err != nil {
+		return err
+	}
+
+	pollInterval, err := c.Doit.GetDuration(c.NS, doctl.ArgPollInterval)
+	if err != nil {
+		return err
+	}
+
+	maxFailures, err := c.Doit.GetInt(c.NS, doctl.ArgMaxAPIFailures)
+	if err != nil {
+		return err
+	}
+
+	counts, err := c.Doit.GetStringSlice(c.NS, doctl.ArgAppSetInstanceCount)
+	if err != nil {
+		return err
+	}
+	sizes, err := c.Doit.GetStringSlice(c.NS, doctl.ArgAppSetInstanceSize)
+	if err != nil {
+		return err
+	}
+	var deployment *godo.Deployment
+	if len(counts) > 0 || len(sizes) > 0 {
+		if forceRebuild {
+			return fmt.Errorf("--%s cannot be used with --%s or --%s", doctl.ArgAppForceRebuild, doctl.ArgAppSetInstanceCount, doctl.ArgAppSetInstanceSize)
+		}
+
+		app, err := c.Apps().Get(appID)
+		if err != nil {
+			return err
+		}
+		if err := setAppInstances(app.Spec, counts, sizes); err != nil {
+			return err
+		}
+		if len(sizes) > 0 {
+			if err := checkAppSpecInstanceSizes(c.Apps(), app.Spec); err != nil {
+				return err
+			}
+		}
+
+		var previousID string
+		if app.ActiveDeployment != nil {
+			previousID = app.ActiveDeployment.ID
+		}
+		updated, err := c.Apps().Update(appID, &godo.AppUpdateRequest{Spec: app.Spec})
+		if err != nil {
+			return err
+		}
+		notice("App spec updated")
+
+		// Updating the spec starts a deployment of its own.
+		deployment = appNewDeployment(updated, previousID)
+		if deployment == nil {
+			deployment, err = waitForAppNewDeployment(c.Apps(), appID, previousID, appWaitDeadline(timeout), timeout, pollInterval, maxFailures)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		deployment, err = c.Apps().CreateDeployment(appID, forceRebuild)
+		if err != nil {
+			return err
+		}
+	}
+
+	if wait {
+		apps := c.Apps()
+		notice("App deplpyment is in progress, waiting for deployment to be running")
+		deployment, err = waitForAppDeploymentRunning(apps, appID, deployment.ID, timeout, pollInterval, maxFailures)
+		if err != nil {
+			if deployment != nil {
+				if derr := c.Display(displayers.Deployments{deployment}); derr != nil {
+					return derr
+				}
+			}
+			return fmt.Errorf("app deployment couldn't enter `running` state: %w", err)
+		}
+	}
+
+	notice("Deployment created")
+
+	return c.Display(displayers.Deployments{deployment})
+}
+
+// setAppInstances sets the instance counts and sizes of the components of
+// spec, given as component=value pairs.
+func setAppInstances(spec *godo.AppSpec, counts, sizes []string) error {
+	for _, set := range counts {
+		name, value, err := parseAppComponentValue(doctl.ArgAppSetInstanceCount, set)
+		if err != nil {
+			return err
+		}
+		count, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || count < 1 {
+			return fmt.Errorf("invalid --%s %q, the instance count must be a positive number", doctl.ArgAppSetInstanceCount, set)
+		}
+
+		component, err := appSpecComponent(spec, name)
+		if err != nil {
+			return err
+		}
+		switch component := component.(type) {
+		case *godo.AppServiceSpec:
+			component.InstanceCount = count
+		case *godo.AppWorkerSpec:
+			component.InstanceCount = count
+		case *godo.AppJobSpec:
+			component.InstanceCount = count
+		default:
+			return fmt.Errorf("component %q has no instances to scale", name)
+		}
+	}
+
+	for _, set := range sizes {
+		name, size, err := parseAppComponentValue(doctl.ArgAppSetInstanceSize, set)
+		if err != nil {
+			return err
+		}
+
+		component, err := appSpecComponent(spec, name)
+		if err != nil {
+			return err
+		}
+		switch component := component.(type) {
+		case *godo.AppServiceSpec:
+			component.InstanceSizeSlug = size
+		case *godo.AppWorkerSpec:
+			component.InstanceSizeSlug = size
+		case *godo.AppJobSpec:
+			component.InstanceSizeSlug = size
+		default:
+			return fmt.Errorf("component %q has no instances to resize", name)
+		}
+	}
+	return nil
+}
+
+// parseAppComponentValue splits a component=value flag value.
+func parseAppComponentValue(flag, set string) (string, string, error) {
+	parts := strings.SplitN(set, "=", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid --%s %q, must be in the form component=value", flag, set)
+	}
+	return parts[0], parts[1], nil
+}
+
+// RunAppsRestart restarts an app's components.
+func RunAppsRestart(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	components, err := c.Doit.GetStringSlice(c.NS, doctl.ArgAppComponents)
+	if err != nil {
+		return err
+	}
+
+	wait, err := c.Doit.GetBool(c.NS, doctl.ArgCommandWait)
+	if err != nil {
+		return err
+	}
+
+	timeout, err := c.Doit.GetDuration(c.NS, doctl.ArgTimeout)
+	if err != nil {
+		return err
+	}
+
+	pollInterval, err := c.Doit.GetDuration(c.NS, doctl.ArgPollInterval)
+	if err != nil {
+		return err
+	}
+
+	maxFailures, err := c.Doit.GetInt(c.NS, doctl.ArgMaxAPIFailures)
+	if err != nil {
+		return err
+	}
+
+	apps := c.Apps()
+	deployment, err := apps.Restart(appID, components)
+	if err != nil {
+		// Fall back to a deployment without a rebuild when the API doesn't
+		// expose a dedicated restart.
+		errResp, ok := err.(*godo.ErrorResponse)
+		if !ok || errResp.Response == nil || errResp.Response.StatusCode != http.StatusNotFound {
+			return err
+		}
+		if len(components) > 0 {
+			warn("Restarting specific components is not supported; restarting all components instead")
+		}
+		deployment, err = apps.CreateDeployment(appID, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	if wait {
+		notice("App restart is in progress, waiting for deployment to be running")
+		deployment, err = waitForAppDeployment(apps, appID, deployment.ID, appWaitDeadline(timeout), timeout, pollInterval, maxFailures, appComponentChecklist)
+		if deployment != nil {
+			reportAppComponentStatuses(deployment)
+		}
+		if err != nil {
+			if deployment != nil {
+				if derr := c.Display(displayers.Deployments{deployment}); derr != nil {
+					return derr
+				}
+			}
+			return fmt.Errorf("app deployment couldn't enter `running` state: %w", err)
+		}
+	}
+
+	notice("App restarted")
+
+	return c.Display(displayers.Deployments{deployment})
+}
+
+// RunAppsRollback rolls an app back to the spec of a previous deployment.
+func RunAppsRollback(c *CmdConfig) error {
+	if len(c.Args) < 2 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+	deploymentID := c.Args[1]
+
+	force, err := c.Doit.GetBool(c.NS, doctl.ArgForce)
+	if err != nil {
+		return err
+	}
+
+	wait, err := c.Doit.GetBool(c.NS, doctl.ArgCommandWait)
+	if err != nil {
+		return err
+	}
+
+	timeout, err := c.Doit.GetDuration(c.NS, doctl.ArgTimeout)
+	if err != nil {
+		return err
+	}
+
+	pollInterval, err := c.Doit.GetDuration(c.NS, doctl.ArgPollInterval)
+	if err != nil {
+		return err
+	}
+
+	maxFailures, err := c.Doit.GetInt(c.NS, doctl.ArgMaxAPIFailures)
+	if err != nil {
+		return err
+	}
+
+	apps := c.Apps()
+	deployment, err := apps.GetDeployment(appID, deploymentID)
+	if err != nil {
+		return err
+	}
+	if deployment.Spec == nil {
+		return fmt.Errorf("deployment %s has no app spec", deploymentID)
+	}
+
+	app, err := apps.Get(appID)
+	if err != nil {
+		return err
+	}
+
+	for _, removed := range appSpecRemovals(app.Spec, deployment.Spec) {
+		warn("Rolling back will remove %s", removed)
+	}
+
+	var previousID string
+	if app.ActiveDeployment != nil {
+		previousID = app.ActiveDeployment.ID
+	}
+
+	if !force && AskForConfirm(fmt.Sprintf("roll back app %s to deployment %s?", appID, deploymentID)) != nil {
+		return fmt.Errorf("Operation aborted.")
+	}
+
+	app, err = apps.Update(appID, &godo.AppUpdateRequest{Spec: deployment.Spec})
+	if err != nil {
+		return err
+	}
+
+	if wait {
+		notice("App rollback is in progress, waiting for deployment to be running")
+		var rollbackDeployment *godo.Deployment
+		if app.InProgressDeployment != nil {
+			rollbackDeployment, err = waitForAppDeploymentRunning(apps, appID, app.InProgressDeployment.ID, timeout, pollInterval, maxFailures)
+		} else {
+			rollbackDeployment, err = waitForAppNewDeploymentRunning(apps, appID, previousID, timeout, pollInterval, maxFailures)
+		}
+		if err != nil {
+			if rollbackDeployment != nil {
+				return fmt.Errorf("app deployment %s couldn't enter `running` state: %w", rollbackDeployment.ID, err)
+			}
+			return fmt.Errorf("app deployment couldn't enter `running` state: %w", err)
+		}
+
+		app, err = apps.Get(appID)
+		if err != nil {
+			return err
+		}
+	}
+
+	notice("App rolled back to deployment %s", deploymentID)
+
+	return c.Display(displayers.Apps{app})
+}
+
+// appSpecRemovals describes the components, databases, and domains present in
+// current that are missing from target.
+func appSpecRemovals(current, target *godo.AppSpec) []string {
+	if current == nil || target == nil {
+		return nil
+	}
+
+	var removals []string
+	targetComponents := map[string]bool{}
+	for _, name := range appComponentNames(target) {
+		targetComponents[name] = true
+	}
+	for _, name := range appComponentNames(current) {
+		if !targetComponents[name] {
+			removals = append(removals, "component "+name)
+		}
+	}
+
+	targetDatabases := map[string]bool{}
+	for _, db := range target.Databases {
+		targetDatabases[db.Name] = true
+	}
+	for _, db := range current.Databases {
+		if !targetDatabases[db.Name] {
+			removals = append(removals, "database "+db.Name)
+		}
+	}
+
+	targetDomains := map[string]bool{}
+	for _, d := range target.Domains {
+		targetDomains[d.Domain] = true
+	}
+	for _, d := range current.Domains {
+		if !targetDomains[d.Domain] {
+			removals = append(removals, "domain "+d.Domain)
+		}
+	}
+
+	return removals
+}
+
+// waitForAppDeploymentRunning waits for a app deployment to be running. The
+// timeout applies to the whole wait; a timeout of zero waits indefinitely.
+// Up to maxFailures consecutive API errors are tolerated, backing off
+// exponentially between them.
+func waitForAppDeploymentRunning(apps do.AppsService, appID string, deploymentID string, timeout, pollInterval time.Duration, maxFailures int) (*godo.Deployment, error) {
+	return waitForAppDeploymentRunningUntil(apps, appID, deploymentID, appWaitDeadline(timeout), timeout, pollInterval, maxFailures)
+}
+
+// appWaitDeadline returns the deadline for a wait with the given timeout. A
+// timeout of zero results in a zero deadline, meaning no deadline.
+func appWaitDeadline(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
+
+// appWaitRetryDelay returns how long to wait before retrying after failCount
+// consecutive API failures, doubling with each failure.
+func appWaitRetryDelay(failCount int) time.Duration {
+	delay := appWaitRetryBackoff << (failCount - 1)
+	if delay <= 0 || delay > appWaitMaxRetryBackoff {
+		delay = appWaitMaxRetryBackoff
+	}
+	return delay
+}
+
+func waitForAppDeploymentRunningUntil(apps do.AppsService, appID string, deploymentID string, deadline time.Time, timeout, pollInterval time.Duration, maxFailures int) (*godo.Deployment, error) {
+	return waitForAppDeployment(apps, appID, deploymentID, deadline, timeout, pollInterval, maxFailures, func(d *godo.Deployment) string {
+		return phaseStatus(string(d.Phase))
+	})
+}
+
+// waitForAppDeployment polls a deployment until it is active or fails,
+// showing the status returned by status for each in-progress poll.
+func waitForAppDeployment(apps do.AppsService, appID string, deploymentID string, deadline time.Time, timeout, pollInterval time.Duration, maxFailures int, status func(*godo.Deployment) string) (*godo.Deployment, error) {
+	if pollInterval <= 0 {
+		pollInterval = defaultAppPollInterval
+	}
+	if maxFailures <= 0 {
+		maxFailures = maxAPIFailures
+	}
+
+	progress := newWaitProgress()
+	defer progress.done()
+
+	var lastDeployment *godo.Deployment
+	failCount := 0
+	for i := 0; ; i++ {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return lastDeployment, &exitCodeErr{
+				err:  fmt.Errorf("deployment did not reach active phase within %s", timeout),
+				code: exitCodeDeploymentTimeout,
+			}
+		}
+
+		if i != 0 {
+			progress.tick()
+		}
+
+		deployment, err := apps.GetDeployment(appID, deploymentID)
+		if err != nil {
+			// Allow for transient API failures
+			failCount++
+			if failCount >= maxFailures {
+				return nil, err
+			}
+			time.Sleep(appWaitRetryDelay(failCount))
+			continue
+		}
+		failCount = 0
+
+		if deployment == nil {
+			time.Sleep(1 * time.Second)
+			continue
+		}
+		lastDeployment = deployment
+
+		switch deployment.Phase {
+		case godo.DeploymentPhase_PendingBuild:
+			fallthrough
+		case godo.DeploymentPhase_PendingDeploy:
+			fallthrough
+		case godo.DeploymentPhase_Building:
+			fallthrough
+		case godo.DeploymentPhase_Deploying:
+			progress.update(status(deployment))
+			time.Sleep(pollInterval)
+
+		case godo.DeploymentPhase_Active:
+			return deployment, nil
+
+		case godo.DeploymentPhase_Error:
+			return deployment, &exitCodeErr{
+				err:  fmt.Errorf("phase: [%s]", deployment.Phase),
+				code: deploymentErrorExitCode(deployment),
+			}
+
+		case godo.DeploymentPhase_Canceled:
+			return deployment, &exitCodeErr{
+				err:  fmt.Errorf("phase: [%s]", deployment.Phase),
+				code: exitCodeDeploymentCanceled,
+			}
+
+		case godo.DeploymentPhase_Unknown:
+			return deployment, fmt.Errorf("phase: [%s]", deployment.Phase)
+
+		default:
+			return deployment, fmt.Errorf("Unknown phase: [%s]", deployment.Phase)
+		}
+	}
+}
+
+// appComponentStatus is the progress of a single component of a deployment.
+type appComponentStatus struct {
+	name   string
+	status godo.DeploymentProgressStepStatus
+}
+
+// appComponentStatuses summarizes the progress steps of a deployment by
+// component, in the order the components first appear in the steps. A
+// component that failed any step has failed, one that completed every step is
+// done, and one that started any step is running.
+func appComponentStatuses(progress *godo.DeploymentProgress) []appComponentStatus {
+	if progress == nil {
+		return nil
+	}
+
+	var names []string
+	steps := make(map[string][]godo.DeploymentProgressStepStatus)
+	var walk func(list []*godo.DeploymentProgressStep, component string)
+	walk = func(list []*godo.DeploymentProgressStep, component string) {
+		for _, step := range list {
+			name := component
+			if step.ComponentName != "" {
+				name = step.ComponentName
+			}
+			if name != "" {
+				if _, ok := steps[name]; !ok {
+					names = append(names, name)
+				}
+				steps[name] = append(steps[name], step.Status)
+			}
+			walk(step.Steps, name)
+		}
+	}
+	walk(progress.Steps, "")
+
+	statuses := make([]appComponentStatus, 0, len(names))
+	for _, name := range names {
+		var errored, started bool
+		done := true
+		for _, st := range steps[name] {
+			switch st {
+			case godo.DeploymentProgressStepStatus_Error:
+				errored = true
+			case godo.DeploymentProgressStepStatus_Success:
+				started = true
+			case godo.DeploymentProgressStepStatus_Running:
+				started = true
+				done = false
+			default:
+				done = false
+			}
+		}
+
+		status := godo.DeploymentProgressStepStatus_Pending
+		switch {
+		case errored:
+			status = godo.DeploymentProgressStepStatus_Error
+		case done:
+			status = godo.DeploymentProgressStepStatus_Success
+		case started:
+			status = godo.DeploymentProgressStepStatus_Running
+		}
+		statuses = append(statuses, appComponentStatus{name: name, status: status})
+	}
+	return statuses
+}
+
+// appComponentStatusText describes a component status for people.
+func appComponentStatusText(status godo.DeploymentProgressStepStatus) string {
+	switch status {
+	case godo.DeploymentProgressStepStatus_Success:
+		return "healthy"
+	case godo.DeploymentProgressStepStatus_Running:
+		return "restarting"
+	case godo.DeploymentProgressStepStatus_Error:
+		return "failed"
+	default:
+		return "pending"
+	}
+}
+
+// appComponentChecklist is a wait status listing the deployment's phase
+// followed by the status of each of its components, one per line.
+func appComponentChecklist(d *godo.Deployment) string {
+	lines := []string{phaseStatus(string(d.Phase))}
+	statuses := appComponentStatuses(d.Progress)
+
+	width := 0
+	for _, cs := range statuses {
+		if len(cs.name) > width {
+			width = len(cs.name)
+		}
+	}
+	for _, cs := range statuses {
+		mark := " "
+		switch cs.status {
+		case godo.DeploymentProgressStepStatus_Success:
+			mark = "x"
+		case godo.DeploymentProgressStepStatus_Error:
+			mark = "!"
+		}
+		lines = append(lines, fmt.Sprintf("  [%s] %-*s  %s", mark, width, cs.name, appComponentStatusText(cs.status)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reportAppComponentStatuses reports which components of a finished
+// deployment came back healthy and warns about those that did not.
+func reportAppComponentStatuses(d *godo.Deployment) {
+	statuses := appComponentStatuses(d.Progress)
+	if len(statuses) == 0 {
+		return
+	}
+
+	var healthy []string
+	for _, cs := range statuses {
+		if cs.status == godo.DeploymentProgressStepStatus_Success {
+			healthy = append(healthy, cs.name)
+			continue
+		}
+		warn("Component %s is %s", cs.name, appComponentStatusText(cs.status))
+	}
+	if len(healthy) > 0 {
+		notice("%d of %d components are healthy: %s", len(healthy), len(statuses), strings.Join(healthy, ", "))
+	}
+}
+
+// deploymentErrorExitCode returns the exit code for a deployment in the
+// ERROR phase, depending on whether it failed while building or deploying.
+func deploymentErrorExitCode(deployment *godo.Deployment) int {
+	if deployment.Progress != nil {
+		for _, step := range deployment.Progress.Steps {
+			if step.Name == "build" && step.Status == godo.DeploymentProgressStepStatus_Error {
+				return exitCodeDeploymentBuildFailed
+			}
+		}
+	}
+	return exitCodeDeploymentDeployFailed
+}
+
+// waitForAppNewDeploymentRunning waits for the deployment triggered by
+// creating or updating an app to be running, ignoring the deployment
+// previousID that was active before an update. The timeout applies to the
+// whole wait, including the time spent waiting for the deployment to be
+// created.
+func waitForAppNewDeploymentRunning(apps do.AppsService, appID, previousID string, timeout, pollInterval time.Duration, maxFailures int) (*godo.Deployment, error) {
+	deadline := appWaitDeadline(timeout)
+	deployment, err := waitForAppNewDeployment(apps, appID, previousID, deadline, timeout, pollInterval, maxFailures)
+	if err != nil {
+		return nil, err
+	}
+	return waitForAppDeploymentRunningUntil(apps, appID, deployment.ID, deadline, timeout, pollInterval, maxFailures)
+}
+
+// waitForAppNewDeployment polls an app until it has an in-progress or active
+// deployment other than previousID, such as the deployment triggered by
+// creating or updating the app, and returns it. An empty previousID accepts
+// any deployment.
+func waitForAppNewDeployment(apps do.AppsService, appID, previousID string, deadline time.Time, timeout, pollInterval time.Duration, maxFailures int) (*godo.Deployment, error) {
+	if pollInterval <= 0 {
+		pollInterval = defaultAppPollInterval
+	}
+	if maxFailures <= 0 {
+		maxFailures = maxAPIFailures
+	}
+
+	failCount := 0
+	for {
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return nil, &exitCodeErr{
+				err:  fmt.Errorf("deployment did not reach active phase within %s", timeout),
+				code: exitCodeDeploymentTimeout,
+			}
+		}
+
+		app, err := apps.Get(appID)
+		if err != nil {
+			// Allow for transient API failures
+			failCount++
+			if failCount >= maxFailures {
+				return nil, err
+			}
+			time.Sleep(appWaitRetryDelay(failCount))
+			continue
+		}
+		failCount = 0
+
+		if d := appNewDeployment(app, previousID); d != nil {
+			return d, nil
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// appNewDeployment returns the in-progress or else active deployment of app,
+// unless its ID is previousID.
+func appNewDeployment(app *godo.App, previousID string) *godo.Deployment {
+	switch {
+	case app.InProgressDeployment != nil && app.InProgressDeployment.ID != previousID:
+		return app.InProgressDeployment
+	case app.ActiveDeployment != nil && app.ActiveDeployment.ID != previousID:
+		return app.ActiveDeployment
+	}
+	return nil
+}
+
+// parseAppLogType parses a log type given on the command line.
+func parseAppLogType(s string) (godo.AppLogType, error) {
+	switch s {
+	case strings.ToLower(string(godo.AppLogTypeBuild)):
+		return godo.AppLogTypeBuild, nil
+	case strings.ToLower(string(godo.AppLogTypeDeploy)):
+		return godo.AppLogTypeDeploy, nil
+	case strings.ToLower(string(godo.AppLogTypeRun)):
+		return godo.AppLogTypeRun, nil
+	default:
+		return "", fmt.Errorf("Invalid log type %s", s)
+	}
+}
+
+// RunAppsGetDeployment gets a deployment for an app.
+func RunAppsGetDeployment(c *CmdConfig) error {
+	if len(c.Args) < 2 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+	deploymentID := c.Args[1]
+
+	wait, err := c.Doit.GetBool(c.NS, doctl.ArgCommandWait)
+	if err != nil {
+		return err
+	}
+
+	logTypeStr, err := c.Doit.GetString(c.NS, doctl.ArgAppDeploymentLogs)
+	if err != nil {
+		return err
+	}
+	var logType godo.AppLogType
+	if logTypeStr != "" {
+		if logType, err = parseAppLogType(logTypeStr); err != nil {
+			return err
+		}
+	}
+
+	watch, err := c.Doit.GetBool(c.NS, doctl.ArgAppDeploymentWatch)
+	if err != nil {
+		return err
+	}
+	if watch {
+		if wait {
+			return fmt.Errorf("--%s cannot be used with --%s", doctl.ArgAppDeploymentWatch, doctl.ArgCommandWait)
+		}
+		if Output != "text" {
+			return fmt.Errorf("--%s can only be used with text output", doctl.ArgAppDeploymentWatch)
+		}
+		pollInterval, err := c.Doit.GetDuration(c.NS, doctl.ArgPollInterval)
+		if err != nil {
+			return err
+		}
+		maxFailures, err := c.Doit.GetInt(c.NS, doctl.ArgMaxAPIFailures)
+		if err != nil {
+			return err
+		}
+
+		werr := watchAppDeployment(c, appID, deploymentID, pollInterval, maxFailures)
+		if logType != "" {
+			if lerr := printAppDeploymentLogs(c, appID, deploymentID, logType); lerr != nil {
+				return lerr
+			}
+		}
+		return werr
+	}
+
+	var deployment *godo.Deployment
+	if wait {
+		timeout, err := c.Doit.GetDuration(c.NS, doctl.ArgTimeout)
+		if err != nil {
+			return err
+		}
+		pollInterval, err := c.Doit.GetDuration(c.NS, doctl.ArgPollInterval)
+		if err != nil {
+			return err
+		}
+		maxFailures, err := c.Doit.GetInt(c.NS, doctl.ArgMaxAPIFailures)
+		if err != nil {
+			return err
+		}
+
+		notice("Waiting for deployment %s to be running", deploymentID)
+		deployment, err = waitForAppDeploymentRunning(c.Apps(), appID, deploymentID, timeout, pollInterval, maxFailures)
+		if err != nil {
+			if deployment != nil {
+				if derr := c.Display(displayers.Deployments{deployment}); derr != nil {
+					return derr
+				}
+				if logType != "" {
+					if lerr := printAppDeploymentLogs(c, appID, deploymentID, logType); lerr != nil {
+						return lerr
+					}
+				}
+			}
+			return fmt.Errorf("app deployment %s couldn't enter `running` state: %w", deploymentID, err)
+		}
+	} else {
+		deployment, err = c.Apps().GetDeployment(appID, deploymentID)
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := c.Display(displayers.Deployments{deployment}); err != nil {
+		return err
+	}
+
+	if Verbose && Output == "text" && deployment.Progress != nil {
+		writeDeploymentProgressSteps(c.Out, deployment.Progress.Steps, 1)
+	}
+
+	if logType != "" {
+		return printAppDeploymentLogs(c, appID, deploymentID, logType)
+	}
+	return nil
+}
+
+// watchAppDeployment redraws the status of a deployment every pollInterval
+// until it reaches a final phase. On a terminal the screen is cleared before
+// each redraw; otherwise the status is printed again on every poll. Up to
+// maxFailures consecutive API errors are tolerated. A failed or canceled
+// deployment results in the same exit codes as --wait.
+func watchAppDeployment(c *CmdConfig, appID, deploymentID string, pollInterval time.Duration, maxFailures int) error {
+	if pollInterval <= 0 {
+		pollInterval = defaultAppPollInterval
+	}
+	if maxFailures <= 0 {
+		maxFailures = maxAPIFailures
+	}
+
+	clear := stdoutIsTerminal()
+	start := time.Now()
+	failCount := 0
+	for i := 0; ; i++ {
+		if i != 0 {
+			time.Sleep(pollInterval)
+		}
+
+		deployment, err := c.Apps().GetDeployment(appID, deploymentID)
+		if err != nil {
+			// Allow for transient API failures
+			failCount++
+			if failCount >= maxFailures {
+				return err
+			}
+			time.Sleep(appWaitRetryDelay(failCount))
+			continue
+		}
+		failCount = 0
+
+		var view bytes.Buffer
+		fmt.Fprintf(&view, "Every %s: deployment %s    Phase: %s    Elapsed: %s\n\n",
+			pollInterval, deploymentID, phaseStatus(string(deployment.Phase)), time.Since(start).Round(time.Second))
+		vc := *c
+		vc.Out = &view
+		if err := vc.Display(displayers.Deployments{deployment}); err != nil {
+			return err
+		}
+		if Verbose && deployment.Progress != nil {
+			writeDeploymentProgressSteps(&view, deployment.Progress.Steps, 1)
+		}
+
+		if clear {
+			// Move the cursor home and clear the screen before redrawing.
+			fmt.Fprint(c.Out, "\033[H\033[2J")
+		} else if i != 0 {
+			fmt.Fprintln(c.Out)
+		}
+		if _, err := view.WriteTo(c.Out); err != nil {
+			return err
+		}
+
+		switch deployment.Phase {
+		case godo.DeploymentPhase_PendingBuild, godo.DeploymentPhase_Building,
+			godo.DeploymentPhase_PendingDeploy, godo.DeploymentPhase_Deploying:
+			continue
+		case godo.DeploymentPhase_Active, godo.DeploymentPhase_Superseded:
+			return nil
+		case godo.DeploymentPhase_Error:
+			return &exitCodeErr{
+				err:  fmt.Errorf("app deployment %s failed", deploymentID),
+				code: deploymentErrorExitCode(deployment),
+			}
+		case godo.DeploymentPhase_Canceled:
+			return &exitCodeErr{
+				err:  fmt.Errorf("app deployment %s was canceled", deploymentID),
+				code: exitCodeDeploymentCanceled,
+			}
+		default:
+			return fmt.Errorf("phase: [%s]", deployment.Phase)
+		}
+	}
+}
+
+// printAppDeploymentLogs writes the historic logs of the given type for a
+// deployment to c.Out, separated from the output before them by a blank line.
+func printAppDeploymentLogs(c *CmdConfig, appID, deploymentID string, logType godo.AppLogType) error {
+	logs, err := c.Apps().GetLogs(appID, deploymentID, "", logType, false)
+	if err != nil {
+		return err
+	}
+	if len(logs.HistoricURLs) == 0 {
+		warn("No %s logs found for deployment %s", strings.ToLower(string(logType)), deploymentID)
+		return nil
+	}
+
+	fmt.Fprintln(c.Out)
+	return copyAppLogs(c.Out, logs.HistoricURLs, appHistoricLogURLs(c.Apps(), appID, deploymentID, "", logType), appLogFilter{})
+}
+
+// writeDeploymentProgressSteps renders deployment progress steps as an
+// indented tree.
+func writeDeploymentProgressSteps(w io.Writer, steps []*godo.DeploymentProgressStep, depth int) {
+	indent := strings.Repeat("  ", depth)
+	for _, step := range steps {
+		name := step.Name
+		if step.MessageBase != "" {
+			name = strings.TrimSpace(step.MessageBase + " " + step.ComponentName)
+		}
+
+		line := fmt.Sprintf("%s%s [%s]", indent, name, step.Status)
+		if !step.StartedAt.IsZero() {
+			line += " started " + step.StartedAt.Format(time.RFC3339)
+		}
+		if !step.EndedAt.IsZero() {
+			line += " ended " + step.EndedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintln(w, line)
+
+		if step.Reason != nil && (step.Reason.Code != "" || step.Reason.Message != "") {
+			fmt.Fprintf(w, "%s  reason: %s\n", indent, strings.TrimSpace(step.Reason.Code+" "+step.Reason.Message))
+		}
+
+		writeDeploymentProgressSteps(w, step.Steps, depth+1)
+	}
+}
+
+// RunAppsListDeployments lists deployments for an app.
+func RunAppsListDeployments(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	page, err := c.Doit.GetInt(c.NS, doctl.ArgPage)
+	if err != nil {
+		return err
+	}
+	perPage, err := c.Doit.GetInt(c.NS, doctl.ArgPerPage)
+	if err != nil {
+		return err
+	}
+	limit, err := c.Doit.GetInt(c.NS, doctl.ArgLimit)
+	if err != nil {
+		return err
+	}
+	if page < 0 || perPage < 0 || limit < 0 {
+		return fmt.Errorf("--%s, --%s, and --%s must not be negative", doctl.ArgPage, doctl.ArgPerPage, doctl.ArgLimit)
+	}
+
+	activeOnly, err := c.Doit.GetBool(c.NS, doctl.ArgAppActiveOnly)
+	if err != nil {
+		return err
+	}
+	inProgress, err := c.Doit.GetBool(c.NS, doctl.ArgAppInProgress)
+	if err != nil {
+		return err
+	}
+
+	var deployments []*godo.Deployment
+	if activeOnly || inProgress {
+		if page > 0 || perPage > 0 {
+			return fmt.Errorf("--%s and --%s cannot be combined with --%s or --%s", doctl.ArgAppActiveOnly, doctl.ArgAppInProgress, doctl.ArgPage, doctl.ArgPerPage)
+		}
+
+		app, err := c.Apps().Get(appID)
+		if err != nil {
+			return err
+		}
+		if activeOnly && app.ActiveDeployment != nil {
+			deployments = append(deployments, app.ActiveDeployment)
+		}
+		if inProgress && app.InProgressDeployment != nil {
+			deployments = append(deployments, app.InProgressDeployment)
+		}
+	} else if page > 0 || perPage > 0 {
+		if page == 0 {
+			page = 1
+		}
+		deployments, err = c.Apps().ListDeploymentsPage(appID, page, perPage)
+	} else {
+		tmpl, err := c.Doit.GetString(c.NS, doctl.ArgFormatTemplate)
+		if err != nil {
+			return err
+		}
+		if Output == "json" && tmpl == "" {
+			return streamAppDeploymentsJSON(c, appID, limit)
+		}
+
+		deployments, err = c.Apps().ListDeployments(appID)
+	}
+	if err != nil {
+		return err
+	}
+
+	if limit > 0 && len(deployments) > limit {
+		deployments = deployments[:limit]
+	}
+
+	return c.Display(displayers.Deployments(deployments))
+}
+
+// errLimitReached stops iterating over paged results once enough have been
+// written.
+var errLimitReached = errors.New("limit reached")
+
+// streamAppDeploymentsJSON writes the app's deployments to c.Out as a JSON
+// array while they are paged in, writing at most limit deployments if limit is
+// positive.
+func streamAppDeploymentsJSON(c *CmdConfig, appID string, limit int) error {
+	out := &displayers.JSONArrayWriter{Out: c.Out}
+	var n int
+	err := c.Apps().ForEachDeployment(appID, func(d *godo.Deployment) error {
+		if limit > 0 && n == limit {
+			return errLimitReached
+		}
+		n++
+		return out.Write(d)
+	})
+	if err != nil && !errors.Is(err, errLimitReached) {
+		return err
+	}
+	return out.Close()
+}
+
+// RunAppsEvents shows a timeline of events from an app's deployments.
+func RunAppsEvents(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	var since time.Time
+	sinceValue, err := c.Doit.GetString(c.NS, doctl.ArgAppLogSince)
+	if err != nil {
+		return err
+	}
+	if sinceValue != "" {
+		if since, err = parseAppLogTime(doctl.ArgAppLogSince, sinceValue, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// Deployments are listed newest first. Stop at the first deployment that
+	// was last updated before since; it is kept so that whether the next one
+	// changed the spec can still be told.
+	var deployments []*godo.Deployment
+	err = c.Apps().ForEachDeployment(appID, func(d *godo.Deployment) error {
+		deployments = append(deployments, d)
+		if !since.IsZero() && d.UpdatedAt.Before(since) {
+			return errLimitReached
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errLimitReached) {
+		return err
+	}
+
+	events := displayers.AppEvents{}
+	for _, event := range appDeploymentEvents(deployments) {
+		if !event.Time.Before(since) {
+			events = append(events, event)
+		}
+	}
+	return c.Display(events)
+}
+
+// appDeploymentEvents returns the events of deployments, which are ordered
+// newest first, in chronological order.
+func appDeploymentEvents(deployments []*godo.Deployment) displayers.AppEvents {
+	var events displayers.AppEvents
+	for i, d := range deployments {
+		add := func(t time.Time, typ, detail string) {
+			if !t.IsZero() {
+				events = append(events, displayers.AppEvent{Time: t, DeploymentID: d.ID, Type: typ, Detail: detail})
+			}
+		}
+
+		add(d.CreatedAt, "created", d.Cause)
+		if i+1 < len(deployments) && !reflect.DeepEqual(d.Spec, deployments[i+1].Spec) {
+			add(d.CreatedAt, "spec updated", "")
+		}
+		if d.Progress != nil {
+			for _, step := range d.Progress.Steps {
+				add(step.StartedAt, step.Name+" started", "")
+				detail := string(step.Status)
+				if step.Reason != nil && step.Reason.Message != "" {
+					detail += ": " + step.Reason.Message
+				}
+				add(step.EndedAt, step.Name+" ended", detail)
+			}
+		}
+		add(d.PhaseLastUpdatedAt, "phase", string(d.Phase))
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events
+}
+
+// RunAppsCancelDeployment cancels an in-progress deployment for an app.
+func RunAppsCancelDeployment(c *CmdConfig) error {
+	if len(c.Args) < 2 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+	deploymentID := c.Args[1]
+
+	force, err := c.Doit.GetBool(c.NS, doctl.ArgForce)
+	if err != nil {
+		return err
+	}
+
+	deployment, err := c.Apps().GetDeployment(appID, deploymentID)
+	if err != nil {
+		return err
+	}
+
+	switch deployment.Phase {
+	case godo.DeploymentPhase_Active,
+		godo.DeploymentPhase_Superseded,
+		godo.DeploymentPhase_Error,
+		godo.DeploymentPhase_Canceled:
+		return fmt.Errorf("deployment %s cannot be canceled; it is already in the terminal phase [%s]", deploymentID, deployment.Phase)
+	}
+
+	if !force && AskForConfirm("cancel this deployment?") != nil {
+		return fmt.Errorf("Operation aborted.")
+	}
+
+	deployment, err = c.Apps().CancelDeployment(appID, deploymentID)
+	if err != nil {
+		return err
+	}
+	notice("Deployment canceled")
+
+	return c.Display(displayers.Deployments{deployment})
+}
+
+// RunAppsGetLogs gets app logs for a given component.
+func RunAppsGetLogs(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	var component string
+	if len(c.Args) >= 2 {
+		component = c.Args[1]
+	}
+	componentNames, err := c.Doit.GetStringSlice(c.NS, doctl.ArgAppLogComponents)
+	if err != nil {
+		return err
+	}
+	if component != "" && len(componentNames) > 0 {
+		return fmt.Errorf("--%s cannot be used with a component name argument", doctl.ArgAppLogComponents)
+	}
+
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	deploymentID, err := c.Doit.GetString(c.NS, doctl.ArgAppDeployment)
+	if err != nil {
+		return err
+	}
+	if deploymentID == "" {
+		app, err := c.Apps().Get(appID)
+		if err != nil {
+			return err
+		}
+		if app.ActiveDeployment != nil {
+			deploymentID = app.ActiveDeployment.ID
+		} else if app.InProgressDeployment != nil {
+			deploymentID = app.InProgressDeployment.ID
+		} else {
+			return fmt.Errorf("unable to retrieve logs; no deployment found for app %s", appID)
+		}
+	}
+
+	logTypeStr, err := c.Doit.GetString(c.NS, doctl.ArgAppLogType)
+	if err != nil {
+		return err
+	}
+	logType, err := parseAppLogType(logTypeStr)
+	if err != nil {
+		return err
+	}
+	logFollow, err := c.Doit.GetBool(c.NS, doctl.ArgAppLogFollow)
+	if err != nil {
+		return err
+	}
+	noReconnect, err := c.Doit.GetBool(c.NS, doctl.ArgAppLogNoReconnect)
+	if err != nil {
+		return err
+	}
+
+	tail, err := c.Doit.GetInt(c.NS, doctl.ArgAppLogTail)
+	if err != nil {
+		return err
+	}
+	if tail < 0 {
+		return fmt.Errorf("--%s must be 0 or greater", doctl.ArgAppLogTail)
+	}
+
+	filter := appLogFilter{tail: tail}
+	now := time.Now()
+	since, err := c.Doit.GetString(c.NS, doctl.ArgAppLogSince)
+	if err != nil {
+		return err
+	}
+	if since != "" {
+		if filter.since, err = parseAppLogTime(doctl.ArgAppLogSince, since, now); err != nil {
+			return err
+		}
+	}
+	until, err := c.Doit.GetString(c.NS, doctl.ArgAppLogUntil)
+	if err != nil {
+		return err
+	}
+	if until != "" {
+		if logFollow {
+			return fmt.Errorf("--%s cannot be used with --%s", doctl.ArgAppLogUntil, doctl.ArgAppLogFollow)
+		}
+		if filter.until, err = parseAppLogTime(doctl.ArgAppLogUntil, until, now); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range []struct {
+		name string
+		re   **regexp.Regexp
+	}{
+		{doctl.ArgAppLogGrep, &filter.include},
+		{doctl.ArgAppLogGrepInvert, &filter.exclude},
+	} {
+		pattern, err := c.Doit.GetString(c.NS, f.name)
+		if err != nil {
+			return err
+		}
+		if pattern == "" {
+			continue
+		}
+		if *f.re, err = regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid --%s pattern: %w", f.name, err)
+		}
+	}
+
+	format, err := c.Doit.GetString(c.NS, doctl.ArgFormat)
+	if err != nil {
+		return err
+	}
+	// --output json also turns on JSON logs, as it does for other commands.
+	jsonOutput := Output == "json"
+	switch format {
+	case "", "text":
+	case "json":
+		jsonOutput = true
+	default:
+		return fmt.Errorf("invalid log format %q, must be one of: text, json", format)
+	}
+
+	colorMode, err := c.Doit.GetString(c.NS, doctl.ArgAppLogColor)
+	if err != nil {
+		return err
+	}
+	var colorize bool
+	switch colorMode {
+	case "", "auto":
+		colorize = !color.NoColor
+	case "always":
+		colorize = true
+		for _, c := range appLogLevelColors {
+			c.color.EnableColor()
+		}
+	case "never":
+	default:
+		return fmt.Errorf("invalid --%s value %q, must be one of: auto, always, never", doctl.ArgAppLogColor, colorMode)
+	}
+
+	outputDir, err := c.Doit.GetString(c.NS, doctl.ArgAppLogOutputDir)
+	if err != nil {
+		return err
+	}
+	if outputDir != "" {
+		if jsonOutput {
+			return fmt.Errorf("JSON logs (--%s json or --output json) cannot be used with --%s", doctl.ArgFormat, doctl.ArgAppLogOutputDir)
+		}
+		if logFollow {
+			return fmt.Errorf("--%s cannot be used with --%s", doctl.ArgAppLogOutputDir, doctl.ArgAppLogFollow)
+		}
+	}
+
+	jsonRaw, err := c.Doit.GetBool(c.NS, doctl.ArgAppLogJSONRaw)
+	if err != nil {
+		return err
+	}
+	if jsonRaw {
+		switch {
+		case !logFollow:
+			return fmt.Errorf("--%s can only be used with --%s", doctl.ArgAppLogJSONRaw, doctl.ArgAppLogFollow)
+		case jsonOutput:
+			return fmt.Errorf("JSON logs (--%s json or --output json) cannot be used with --%s", doctl.ArgFormat, doctl.ArgAppLogJSONRaw)
+		case filter.grepped() || filter.backlog():
+			return fmt.Errorf("--%s cannot be used with --%s, --%s, --%s, or --%s", doctl.ArgAppLogJSONRaw, doctl.ArgAppLogGrep, doctl.ArgAppLogGrepInvert, doctl.ArgAppLogTail, doctl.ArgAppLogSince)
+		case len(componentNames) > 1:
+			return fmt.Errorf("--%s can only be used with a single component", doctl.ArgAppLogJSONRaw)
+		}
+		colorize = false
+	}
+
+	var components []string
+	if component != "" {
+		components = []string{component}
+	} else if len(componentNames) > 0 {
+		if components, err = validateAppLogComponents(c, appID, deploymentID, componentNames); err != nil {
+			return err
+		}
+	}
+
+	if outputDir != "" {
+		return writeAppLogsToDir(c, appID, deploymentID, components, logType, filter, outputDir)
+	}
+
+	if colorize && !jsonOutput {
+		// Every log line is written to c.Out, so coloring it colors them all.
+		w := &lineWriter{
+			mu:     new(sync.Mutex),
+			out:    c.Out,
+			format: colorAppLogLevel,
+		}
+		defer w.Flush()
+		c.Out = w
+	}
+
+	if jsonRaw {
+		if len(components) == 1 {
+			component = components[0]
+		}
+		logs, err := c.Apps().GetLogs(appID, deploymentID, component, logType, true)
+		if err != nil {
+			return err
+		}
+		if logs.LiveURL == "" {
+			return errors.New("unable to follow logs; no live logs available")
+		}
+		return streamAppLogs(c, appID, deploymentID, component, logType, logs.LiveURL, c.Out, !noReconnect, true)
+	}
+
+	if logFollow && len(components) != 1 {
+		return followAllAppComponentLogs(c, appID, deploymentID, components, logType, filter, !noReconnect, jsonOutput)
+	}
+	if len(components) > 1 {
+		return copyAppComponentLogs(c, appID, deploymentID, components, logType, filter, jsonOutput)
+	}
+	if len(components) == 1 {
+		component = components[0]
+	}
+
+	logs, err := c.Apps().GetLogs(appID, deploymentID, component, logType, logFollow)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = c.Out
+	if jsonOutput {
+		w := &lineWriter{
+			mu:     new(sync.Mutex),
+			out:    c.Out,
+			format: appLogJSONLines(component, logType),
+		}
+		defer w.Flush()
+		out = w
+	}
+
+	if logs.LiveURL != "" {
+		if filter.backlog() {
+			historic, err := c.Apps().GetLogs(appID, deploymentID, component, logType, false)
+			if err != nil {
+				return err
+			}
+			if err := copyAppLogs(out, historic.HistoricURLs, appHistoricLogURLs(c.Apps(), appID, deploymentID, component, logType), filter); err != nil {
+				return err
+			}
+		}
+
+		live := filter.grepWriter(out)
+		err := streamAppLogs(c, appID, deploymentID, component, logType, logs.LiveURL, live, !noReconnect, false)
+		live.Flush()
+		if err != nil {
+			return err
+		}
+	} else if len(logs.HistoricURLs) > 0 {
+		return copyAppLogs(out, logs.HistoricURLs, appHistoricLogURLs(c.Apps(), appID, deploymentID, component, logType), filter)
+	} else {
+		warn("No logs found for app component")
+	}
+
+	return nil
+}
+
+// appLogsListener returns a listener that streams the live logs at liveURL to
+// out. If raw is true, each message is written verbatim, followed by a
+// newline if it lacks one, rather than only its log data.
+func appLogsListener(c *CmdConfig, liveURL string, out io.Writer, raw bool) (listen.ListenerService, error) {
+	url, err := url.Parse(liveURL)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaFunc := func(message []byte) (io.Reader, error) {
+		if raw {
+			if !bytes.HasSuffix(message, []byte("\n")) {
+				message = append(message, '\n')
+			}
+			return bytes.NewReader(message), nil
+		}
+
+		data := struct {
+			Data string `json:"data"`
+		}{}
+		err := json.Unmarshal(message, &data)
+		if err != nil {
+			return nil, err
+		}
+		r := strings.NewReader(data.Data)
+
+		return r, nil
+	}
+
+	token := url.Query().Get("token")
+	switch url.Scheme {
+	case "http":
+		url.Scheme = "ws"
+	default:
+		url.Scheme = "wss"
+	}
+
+	return c.Doit.Listen(url, token, schemaFunc, out, nil), nil
+}
+
+// appConsoleStdin is read for the input of interactive app consoles.
+var appConsoleStdin = os.Stdin
+
+// appConsoleMessage is a message sent to an app console: either input for its
+// shell or the size of the terminal it's displayed in.
+type appConsoleMessage struct {
+	Op     string `json:"op"`
+	Data   string `json:"data,omitempty"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+}
+
+func (m appConsoleMessage) bytes() []byte {
+	b, _ := json.Marshal(m)
+	return b
+}
+
+// RunAppsConsole opens a console on an instance of an app component, or runs
+// a single command in it with --command.
+func RunAppsConsole(c *CmdConfig) error {
+	if len(c.Args) < 2 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	component := c.Args[1]
+
+	command, err := c.Doit.GetString(c.NS, doctl.ArgAppConsoleCommand)
+	if err != nil {
+		return err
+	}
+
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	exec, err := c.Apps().GetExec(appID, component)
+	if err != nil {
+		return err
+	}
+
+	url, err := url.Parse(exec.URL)
+	if err != nil {
+		return err
+	}
+	token := url.Query().Get("token")
+
+	schemaFunc := func(message []byte) (io.Reader, error) {
+		data := struct {
+			Data string `json:"data"`
+		}{}
+		err := json.Unmarshal(message, &data)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(data.Data), nil
+	}
+
+	inputCh := make(chan []byte)
+	listener := c.Doit.Listen(url, token, schemaFunc, c.Out, inputCh)
+
+	if command != "" {
+		go func() {
+			inputCh <- appConsoleMessage{Op: "stdin", Data: command + "\nexit\n"}.bytes()
+		}()
+		return listener.Start()
+	}
+
+	var resize []byte
+	stdinFd := int(appConsoleStdin.Fd())
+	if terminal.IsTerminal(stdinFd) {
+		state, err := terminal.MakeRaw(stdinFd)
+		if err != nil {
+			return err
+		}
+		defer terminal.Restore(stdinFd, state)
+
+		if width, height, err := terminal.GetSize(stdinFd); err == nil {
+			resize = appConsoleMessage{Op: "resize", Width: width, Height: height}.bytes()
+		}
+	}
+
+	go func() {
+		if resize != nil {
+			inputCh <- resize
+		}
+		buf := make([]byte, 1024)
+		for {
+			n, err := appConsoleStdin.Read(buf)
+			if n > 0 {
+				inputCh <- appConsoleMessage{Op: "stdin", Data: string(buf[:n])}.bytes()
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	return listener.Start()
+}
+
+// validateAppLogComponents checks that every name in names is a component of
+// the deployment and returns the names with duplicates removed.
+func validateAppLogComponents(c *CmdConfig, appID, deploymentID string, names []string) ([]string, error) {
+	deployment, err := c.Apps().GetDeployment(appID, deploymentID)
+	if err != nil {
+		return nil, err
+	}
+	valid := appComponentNames(deployment.Spec)
+
+	var components []string
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		found := false
+		for _, v := range valid {
+			if v == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("component %q not found in deployment %s; valid components are: %s", name, deploymentID, strings.Join(valid, ", "))
+		}
+		components = append(components, name)
+	}
+	return components, nil
+}
+
+// appLogPrefixWidth returns the width that component name prefixes are padded
+// to so that log lines of different components line up.
+func appLogPrefixWidth(components []string) int {
+	width := 0
+	for _, name := range components {
+		if len(name) > width {
+			width = len(name)
+		}
+	}
+	return width
+}
+
+// copyAppComponentLogs writes the historic logs of each of components to
+// c.Out, prefixing each line with the component's name or encoding it as JSON
+// if jsonOutput is true. The logs of the components are merged by the
+// timestamps their lines start with.
+func copyAppComponentLogs(c *CmdConfig, appID, deploymentID string, components []string, logType godo.AppLogType, filter appLogFilter, jsonOutput bool) error {
+	width := appLogPrefixWidth(components)
+	mu := new(sync.Mutex)
+	var logLines [][]appComponentLogLine
+	for _, name := range components {
+		logs, err := c.Apps().GetLogs(appID, deploymentID, name, logType, false)
+		if err != nil {
+			return err
+		}
+		if len(logs.HistoricURLs) == 0 {
+			warn("No logs found for app component %s", name)
+			continue
+		}
+
+		format := prefixLines(fmt.Sprintf("%-*s | ", width, name))
+		if jsonOutput {
+			format = appLogJSONLines(name, logType)
+		}
+		var lines []appComponentLogLine
+		w := &lineWriter{
+			mu:  mu,
+			out: ioutil.Discard,
+			format: func(line []byte) []byte {
+				// Lines without a timestamp, such as those of a stack trace,
+				// stay with the line before them.
+				ts, ok := appLogLineTime(line)
+				if !ok && len(lines) > 0 {
+					ts = lines[len(lines)-1].time
+				}
+				lines = append(lines, appComponentLogLine{time: ts, line: format(line)})
+				return nil
+			},
+		}
+		err = copyAppLogs(w, logs.HistoricURLs, appHistoricLogURLs(c.Apps(), appID, deploymentID, name, logType), filter)
+		w.Flush()
+		if err != nil {
+			return err
+		}
+		logLines = append(logLines, lines)
+	}
+
+	for {
+		next := -1
+		for i, lines := range logLines {
+			if len(lines) > 0 && (next < 0 || lines[0].time.Before(logLines[next][0].time)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			return nil
+		}
+		if _, err := c.Out.Write(logLines[next][0].line); err != nil {
+			return err
+		}
+		logLines[next] = logLines[next][1:]
+	}
+}
+
+// appComponentLogLine is a formatted log line of a component and the time it
+// was logged at.
+type appComponentLogLine struct {
+	time time.Time
+	line []byte
+}
+
+// followAllAppComponentLogs concurrently follows the live logs of every
+// component in a deployment, prefixing each line with the component's name or
+// encoding it as JSON if jsonOutput is true. A stream that fails is reported
+// without stopping the others.
+func followAllAppComponentLogs(c *CmdConfig, appID, deploymentID string, components []string, logType godo.AppLogType, filter appLogFilter, reconnect, jsonOutput bool) error {
+	if len(components) == 0 {
+		deployment, err := c.Apps().GetDeployment(appID, deploymentID)
+		if err != nil {
+			return err
+		}
+		components = appComponentNames(deployment.Spec)
+	}
+	if len(components) == 0 {
+		return fmt.Errorf("unable to follow logs; no components found in deployment %s", deploymentID)
+	}
+
+	width := appLogPrefixWidth(components)
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		failures int
+	)
+	for _, name := range components {
+		w := &lineWriter{
+			mu:     &mu,
+			out:    c.Out,
+			format: prefixLines(fmt.Sprintf("%-*s | ", width, name)),
+		}
+		if jsonOutput {
+			w.format = appLogJSONLines(name, logType)
+		}
+
+		wg.Add(1)
+		go func(name string, w *lineWriter) {
+			defer wg.Done()
+
+			err := followAppComponentLogs(c, appID, deploymentID, name, logType, filter, reconnect, w)
+			w.Flush()
+			if err != nil {
+				mu.Lock()
+				failures++
+				mu.Unlock()
+				warn("Unable to follow logs for component %s: %v", name, err)
+			}
+		}(name, w)
+	}
+	wg.Wait()
+
+	if failures == len(components) {
+		return fmt.Errorf("unable to follow logs for any component of app %s", appID)
+	}
+	return nil
+}
+
+// followAppComponentLogs streams the live logs of a single component to out,
+// first writing the historic logs selected by filter, if any.
+func followAppComponentLogs(c *CmdConfig, appID, deploymentID, component string, logType godo.AppLogType, filter appLogFilter, reconnect bool, out io.Writer) error {
+	logs, err := c.Apps().GetLogs(appID, deploymentID, component, logType, true)
+	if err != nil {
+		return err
+	}
+	if logs.LiveURL == "" {
+		return errors.New("no live logs available")
+	}
+
+	if filter.backlog() {
+		historic, err := c.Apps().GetLogs(appID, deploymentID, component, logType, false)
+		if err != nil {
+			return err
+		}
+		if err := copyAppLogs(out, historic.HistoricURLs, appHistoricLogURLs(c.Apps(), appID, deploymentID, component, logType), filter); err != nil {
+			return err
+		}
+	}
+
+	live := filter.grepWriter(out)
+	defer live.Flush()
+	return streamAppLogs(c, appID, deploymentID, component, logType, logs.LiveURL, live, reconnect, false)
+}
+
+var (
+	// appLogsReconnectMinBackoff and appLogsReconnectMaxBackoff bound the
+	// delay between attempts to reconnect to a dropped live log stream.
+	appLogsReconnectMinBackoff = time.Second
+	appLogsReconnectMaxBackoff = 30 * time.Second
+)
+
+// streamAppLogs streams the live logs at liveURL to out, as raw messages if
+// raw is true. If reconnect is true and the stream drops, it reconnects with
+// exponential backoff, resolving a fresh live URL each time since the URL's
+// token expires. It returns once the user interrupts it or the component no
+// longer has live logs.
+func streamAppLogs(c *CmdConfig, appID, deploymentID, component string, logType godo.AppLogType, liveURL string, out io.Writer, reconnect, raw bool) error {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	backoff := appLogsReconnectMinBackoff
+	for {
+		started := time.Now()
+		listener, err := appLogsListener(c, liveURL, out, raw)
+		if err != nil {
+			return err
+		}
+		err = listener.Start()
+		if !reconnect {
+			return err
+		}
+
+		select {
+		case <-interrupt:
+			return nil
+		default:
+		}
+
+		// A stream that stayed up for a while was healthy, so start backing
+		// off from scratch.
+		if time.Since(started) > appLogsReconnectMaxBackoff {
+			backoff = appLogsReconnectMinBackoff
+		}
+
+		name := component
+		if name == "" {
+			name = "app"
+		}
+		if err != nil {
+			notice("Log stream for %s dropped (%v); reconnecting in %s. Some log lines may be missing.", name, err, backoff)
+		} else {
+			notice("Log stream for %s dropped; reconnecting in %s. Some log lines may be missing.", name, backoff)
+		}
+
+		select {
+		case <-interrupt:
+			return nil
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > appLogsReconnectMaxBackoff {
+			backoff = appLogsReconnectMaxBackoff
+		}
+
+		logs, err := c.Apps().GetLogs(appID, deploymentID, component, logType, true)
+		if err != nil {
+			return err
+		}
+		if logs.LiveURL == "" {
+			return nil
+		}
+		liveURL = logs.LiveURL
+	}
+}
+
+// lineWriter writes each complete line written to it to out, transformed by
+// format. Writes to out are serialized with mu so that multiple lineWriters
+// can share the same out without interleaving lines.
+type lineWriter struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	format func(line []byte) []byte
+	buf    []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush writes any buffered partial line to out.
+func (w *lineWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	line := append(w.buf, '\n')
+	w.buf = nil
+	return w.writeLine(line)
+}
+
+func (w *lineWriter) writeLine(line []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	_, err := w.out.Write(w.format(line))
+	return err
+}
+
+// prefixLines returns a lineWriter format that prefixes lines with prefix.
+func prefixLines(prefix string) func([]byte) []byte {
+	return func(line []byte) []byte {
+		return append([]byte(prefix), line...)
+	}
+}
+
+type appLogLine struct {
+	Component string `json:"component,omitempty"`
+	Type      string `json:"type"`
+	Time      string `json:"time,omitempty"`
+	Message   string `json:"message"`
+}
+
+// appLogJSONLines returns a lineWriter format that encodes log lines as JSON
+// objects. A line's component and time are parsed from its leading fields
+// when present, falling back to component.
+func appLogJSONLines(component string, logType godo.AppLogType) func([]byte) []byte {
+	return func(line []byte) []byte {
+		entry := appLogLine{
+			Component: component,
+			Type:      strings.ToLower(string(logType)),
+			Message:   strings.TrimRight(string(line), "\r\n"),
+		}
+
+		fields := strings.SplitN(entry.Message, " ", 3)
+		if _, err := time.Parse(time.RFC3339Nano, fields[0]); err == nil {
+			entry.Time = fields[0]
+			entry.Message = strings.TrimPrefix(entry.Message, fields[0]+" ")
+		} else if len(fields) > 1 {
+			if _, err := time.Parse(time.RFC3339Nano, fields[1]); err == nil {
+				entry.Component = fields[0]
+				entry.Time = fields[1]
+				entry.Message = strings.TrimPrefix(entry.Message, fields[0]+" "+fields[1]+" ")
+				if len(fields) == 2 {
+					entry.Message = ""
+				}
+			}
+		}
+
+		out, err := json.Marshal(entry)
+		if err != nil {
+			return line
+		}
+		return append(out, '\n')
+	}
+}
+
+// writeAppLogsToDir writes the logs of each of components to a separate file
+// in dir. If components is empty, the logs of every component in the
+// deployment's spec are written.
+func writeAppLogsToDir(c *CmdConfig, appID, deploymentID string, components []string, logType godo.AppLogType, filter appLogFilter, dir string) error {
+	if len(components) == 0 {
+		deployment, err := c.Apps().GetDeployment(appID, deploymentID)
+		if err != nil {
+			return err
+		}
+		components = appComponentNames(deployment.Spec)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, name := range components {
+		logs, err := c.Apps().GetLogs(appID, deploymentID, name, logType, false)
+		if err != nil {
+			return err
+		}
+		if len(logs.HistoricURLs) == 0 {
+			warn("No logs found for app component %s", name)
+			continue
+		}
+
+		path := filepath.Join(dir, fmt.Sprintf("%s-%s.log", name, strings.ToLower(string(logType))))
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		err = copyAppLogs(f, logs.HistoricURLs, appHistoricLogURLs(c.Apps(), appID, deploymentID, name, logType), filter)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+
+		notice("Logs written to %s", path)
+	}
+
+	return nil
+}
+
+var (
+	// stdoutIsTerminal reports whether stdout is a terminal. In test, you can
+	// replace this to force either kind of log output.
+	stdoutIsTerminal = func() bool {
+		return terminal.IsTerminal(int(os.Stdout.Fd()))
+	}
+
+	appLogLevelColors = []struct {
+		level *regexp.Regexp
+		color *color.Color
+	}{
+		{regexp.MustCompile(`(?i)\b(error|err|fatal|panic|critical)\b`), color.New(color.FgRed)},
+		{regexp.MustCompile(`(?i)\b(warn|warning)\b`), color.New(color.FgYellow)},
+		{regexp.MustCompile(`(?i)\binfo\b`), color.New(color.FgCyan)},
+	}
+)
+
+// colorAppLogLevel is a lineWriter format that colors a log line by the most
+// severe log level keyword found in it. Lines without a level are left as is.
+func colorAppLogLevel(line []byte) []byte {
+	for _, c := range appLogLevelColors {
+		if c.level.Match(line) {
+			text := strings.TrimRight(string(line), "\r\n")
+			return []byte(c.color.Sprint(text) + string(line[len(text):]))
+		}
+	}
+	return line
+}
+
+// appLogFilter selects the historic log lines that are displayed.
+type appLogFilter struct {
+	// tail limits output to the last tail lines when greater than 0.
+	tail int
+	// since and until drop lines timestamped outside of the window. A zero
+	// value leaves that end of the window open.
+	since, until time.Time
+	// include and exclude, when set, drop lines that don't match and that
+	// match them, respectively.
+	include, exclude *regexp.Regexp
+}
+
+// grepped reports whether lines are filtered by a pattern.
+func (f appLogFilter) grepped() bool {
+	return f.include != nil || f.exclude != nil
+}
+
+// selects reports whether line passes the filter's patterns.
+func (f appLogFilter) selects(line []byte) bool {
+	return (f.include == nil || f.include.Match(line)) && (f.exclude == nil || !f.exclude.Match(line))
+}
+
+// grepWriter returns a lineWriter that writes the lines written to it to out
+// if they pass the filter's patterns. It must be flushed once done.
+func (f appLogFilter) grepWriter(out io.Writer) *lineWriter {
+	return &lineWriter{
+		mu:  new(sync.Mutex),
+		out: out,
+		format: func(line []byte) []byte {
+			if !f.selects(line) {
+				return nil
+			}
+			return line
+		},
+	}
+}
+
+// backlog reports whether historic logs should be shown before following
+// live logs.
+func (f appLogFilter) backlog() bool {
+	return f.tail > 0 || !f.since.IsZero()
+}
+
+func (f appLogFilter) windowed() bool {
+	return !f.since.IsZero() || !f.until.IsZero()
+}
+
+// appLogURLStatusError is returned when downloading historic logs fails with
+// a non-200 response.
+type appLogURLStatusError struct {
+	status string
+	code   int
+}
+
+func (e *appLogURLStatusError) Error() string {
+	return fmt.Sprintf("unable to download logs: server responded with %s", e.status)
+}
+
+// appHistoricLogURLs returns a function that fetches fresh historic log URLs
+// of a component, for retrying downloads once the signed URLs have expired.
+func appHistoricLogURLs(apps do.AppsService, appID, deploymentID, component string, logType godo.AppLogType) func() ([]string, error) {
+	return func() ([]string, error) {
+		logs, err := apps.GetLogs(appID, deploymentID, component, logType, false)
+		if err != nil {
+			return nil, err
+		}
+		return logs.HistoricURLs, nil
+	}
+}
+
+// copyAppLogs downloads each of the given historic log URLs in order and
+// writes the lines selected by filter to w. Historic log URLs are signed and
+// expire, so if a download is rejected with a 4xx response and refresh isn't
+// nil, fresh URLs are fetched with refresh and the download is retried once.
+func copyAppLogs(w io.Writer, urls []string, refresh func() ([]string, error), filter appLogFilter) error {
+	out := w
+	var buf bytes.Buffer
+	if filter.tail > 0 || filter.windowed() || filter.grepped() {
+		out = &buf
+	}
+
+	client := &http.Client{
+		Transport: doctl.HTTPTransport(),
+		Timeout:   viper.GetDuration(doctl.ArgRequestTimeout),
+	}
+	retried := false
+	for i := 0; i < len(urls); i++ {
+		err := copyAppLogURL(client, out, urls[i])
+		var serr *appLogURLStatusError
+		if errors.As(err, &serr) && serr.code >= 400 && serr.code < 500 && refresh != nil && !retried {
+			retried = true
+			fresh, rerr := refresh()
+			if rerr != nil {
+				return fmt.Errorf("%v; refreshing log URLs: %w", err, rerr)
+			}
+			if i >= len(fresh) {
+				return err
+			}
+			urls = fresh
+			i--
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if out == w {
+		return nil
+	}
+
+	data := buf.Bytes()
+	if filter.windowed() {
+		data = windowLines(data, filter.since, filter.until)
+	}
+	if filter.grepped() {
+		data = grepLines(data, filter)
+	}
+	if filter.tail > 0 {
+		data = tailLines(data, filter.tail)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// copyAppLogURL downloads the historic logs at u to w. Compressed responses
+// are requested to save bandwidth, and logs are decompressed if they are
+// gzipped, either in transit or as stored.
+func copyAppLogURL(client *http.Client, w io.Writer, u string) error {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	// Setting the header stops the transport from decompressing responses
+	// itself, so logs stored gzipped are handled the same way.
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &appLogURLStatusError{status: resp.Status, code: resp.StatusCode}
+	}
+
+	body := bufio.NewReader(resp.Body)
+	var r io.Reader = body
+	if magic, _ := body.Peek(2); bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+// windowLines returns the lines of data timestamped within [since, until].
+// The API doesn't support filtering logs by time, so each line's timestamp is
+// parsed from its first or second field, the latter being the case when lines
+// are prefixed with a component name. Lines without a timestamp, such as the
+// continuation of a multi-line message, follow the line before them.
+func windowLines(data []byte, since, until time.Time) []byte {
+	var out []byte
+	keep := true
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i+1]
+		}
+		data = data[len(line):]
+
+		if ts, ok := appLogLineTime(line); ok {
+			keep = (since.IsZero() || !ts.Before(since)) && (until.IsZero() || !ts.After(until))
+		}
+		if keep {
+			out = append(out, line...)
+		}
+	}
+	return out
+}
+
+// grepLines returns the lines of data that pass filter's patterns.
+func grepLines(data []byte, filter appLogFilter) []byte {
+	var out []byte
+	for len(data) > 0 {
+		line := data
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			line = data[:i+1]
+		}
+		data = data[len(line):]
+
+		if filter.selects(line) {
+			out = append(out, line...)
+		}
+	}
+	return out
+}
+
+func appLogLineTime(line []byte) (time.Time, bool) {
+	fields := strings.Fields(string(line))
+	for i := 0; i < len(fields) && i < 2; i++ {
+		if ts, err := time.Parse(time.RFC3339Nano, fields[i]); err == nil {
+			return ts, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseAppLogTime parses the value of the flag named name as either an
+// RFC3339 timestamp or a duration relative to now.
+func parseAppLogTime(name, value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("invalid --%s value %q: expected an RFC3339 timestamp like 2021-03-01T15:04:05Z or a duration like 1h30m", name, value)
+	}
+	return now.Add(-d), nil
+}
+
+// tailLines returns the last n lines of data.
+func tailLines(data []byte, n int) []byte {
+	end := len(data)
+	if end > 0 && data[end-1] == '\n' {
+		end--
+	}
+	for i := end - 1; i >= 0; i-- {
+		if data[i] == '\n' {
+			n--
+			if n == 0 {
+				return data[i+1:]
+			}
+		}
+	}
+	return data
+}
+
+// appSpecComponent returns the definition of the service, static site, worker,
+// or job named name in spec.
+func appSpecComponent(spec *godo.AppSpec, name string) (interface{}, error) {
+	if spec == nil {
+		return nil, errors.New("app has no spec")
+	}
+
+	for _, s := range spec.Services {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	for _, s := range spec.StaticSites {
+		if s.Name == name {
+			return s, nil
+		}
+	}
+	for _, w := range spec.Workers {
+		if w.Name == name {
+			return w, nil
+		}
+	}
+	for _, j := range spec.Jobs {
+		if j.Name == name {
+			return j, nil
+		}
+	}
+	return nil, fmt.Errorf("component %q not found in app spec; available components are: %s", name, strings.Join(appComponentNames(spec), ", "))
+}
+
+// appComponentNames returns the names of all components defined in spec.
+func appComponentNames(spec *godo.AppSpec) []string {
+	if spec == nil {
+		return nil
+	}
+
+	var names []string
+	for _, s := range spec.Services {
+		names = append(names, s.Name)
+	}
+	for _, s := range spec.StaticSites {
+		names = append(names, s.Name)
+	}
+	for _, w := range spec.Workers {
+		names = append(names, w.Name)
+	}
+	for _, j := range spec.Jobs {
+		names = append(names, j.Name)
+	}
+	return names
+}
+
+// RunAppsPropose proposes an app spec
+func RunAppsPropose(c *CmdConfig) error {
+	appID, err := c.Doit.GetString(c.NS, doctl.ArgApp)
+	if err != nil {
+		return err
+	}
+
+	specPath, err := c.Doit.GetString(c.NS, doctl.ArgAppSpec)
+	if err != nil {
+		return err
+	}
+
+	showDiff, err := c.Doit.GetBool(c.NS, doctl.ArgAppProposeDiff)
+	if err != nil {
+		return err
+	}
+
+	specFormat, err := c.Doit.GetString(c.NS, doctl.ArgAppProposeSpecFormat)
+	if err != nil {
+		return err
+	}
+	if specFormat != "" && specFormat != "json" && specFormat != "yaml" {
+		return fmt.Errorf("invalid --%s %q, must be one of: json, yaml", doctl.ArgAppProposeSpecFormat, specFormat)
+	}
+
+	byName, err := c.Doit.GetBool(c.NS, doctl.ArgAppByName)
+	if err != nil {
+		return err
+	}
+
+	onlyCost, err := c.Doit.GetBool(c.NS, doctl.ArgAppProposeOnlyCost)
+	if err != nil {
+		return err
+	}
+
+	if onlyCost && showDiff {
+		return fmt.Errorf("--%s cannot be combined with --%s", doctl.ArgAppProposeOnlyCost, doctl.ArgAppProposeDiff)
+	}
+	if specFormat != "" && (onlyCost || showDiff) {
+		return fmt.Errorf("--%s cannot be combined with --%s or --%s", doctl.ArgAppProposeSpecFormat, doctl.ArgAppProposeOnlyCost, doctl.ArgAppProposeDiff)
+	}
+
+	if showDiff && appID == "" && !byName {
+		return fmt.Errorf("--%s requires --%s or --%s", doctl.ArgAppProposeDiff, doctl.ArgApp, doctl.ArgAppByName)
+	}
+
+	byt, err := readAppSpecBytes(os.Stdin, specPath)
+	if err != nil {
+		return err
+	}
+	if docs := appSpecDocuments(appSpecDisplayName(specPath), byt); len(docs) > 1 {
+		if appID != "" || showDiff || specFormat != "" {
+			return fmt.Errorf("--%s, --%s, and --%s cannot be used with an app spec containing several YAML documents", doctl.ArgApp, doctl.ArgAppProposeDiff, doctl.ArgAppProposeSpecFormat)
+		}
+		if Output == "json" {
+			return errors.New("JSON output cannot be used with an app spec containing several YAML documents")
+		}
+		return proposeAppSpecDocuments(c, docs, byName)
+	}
+
+	// The spec has already been read, so it is parsed from byt rather than
+	// read again from a stream or URL.
+	appSpec, err := readAppSpecFromArgs(c, bytes.NewReader(byt), []string{"-"})
+	if err != nil {
+		return err
+	}
+
+	if appID == "" && byName {
+		appID, err = findAppIDByName(c.Apps(), appSpec.Name)
+	} else {
+		appID, err = resolveAppID(c.Apps(), appID)
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := c.Apps().Propose(&godo.AppProposeRequest{
+		Spec:  appSpec,
+		AppID: appID,
+	})
+
+	if err != nil {
+		// most likely an invalid app spec. The error message would start with "error validating app spec"
+		return err
+	}
+
+	if onlyCost {
+		return c.Display(displayers.AppProposeCost{Res: res})
+	}
+
+	if showDiff {
+		app, err := c.Apps().Get(appID)
+		if err != nil {
+			return err
+		}
+
+		fromName, toName := "app "+appID, "proposed"
+		if Output == "json" {
+			diff, err := newAppSpecDiff(fromName, app.Spec, toName, res.Spec)
+			if err != nil {
+				return err
+			}
+			e := json.NewEncoder(c.Out)
+			e.SetIndent("", "  ")
+			return e.Encode(struct {
+				Spec *godo.AppSpec `json:"spec"`
+				Diff *appSpecDiff  `json:"diff"`
+			}{res.Spec, diff})
+		}
+
+		if err := c.Display(displayers.AppProposeResponse{Res: res}); err != nil {
+			return err
+		}
+		fmt.Fprintln(c.Out)
+		return writeAppSpecUnifiedDiff(c.Out, fromName, app.Spec, toName, res.Spec)
+	}
+
+	switch specFormat {
+	case "json":
+		e := json.NewEncoder(c.Out)
+		e.SetIndent("", "  ")
+		return e.Encode(res.Spec)
+	case "yaml":
+		ymlSpec, err := yaml.Marshal(res.Spec)
+		if err != nil {
+			return fmt.Errorf("marshaling the spec as yaml: %v", err)
+		}
+		_, err = c.Out.Write(ymlSpec)
+		return err
+	}
+
+	return c.Display(displayers.AppProposeResponse{Res: res})
+}
+
+// proposeAppSpecDocuments proposes each of the app specs in docs, printing a
+// pass/fail line with the cost of each. With byName, each spec is proposed as
+// an update to the app of the same name.
+func proposeAppSpecDocuments(c *CmdConfig, docs []appSpecDocument, byName bool) error {
+	sets, err := c.Doit.GetStringArray(c.NS, doctl.ArgAppSet)
+	if err != nil {
+		return err
+	}
+	if len(sets) > 0 {
+		return fmt.Errorf("--%s cannot be used with an app spec containing several YAML documents", doctl.ArgAppSet)
+	}
+
+	return reportAppSpecDocuments(c, docs, func(doc appSpecDocument) (string, error) {
+		appSpec, err := parseAppSpec(doc.spec)
+		if err != nil {
+			return "", fmt.Errorf("parsing app spec: %w", err)
+		}
+
+		var appID string
+		if byName {
+			if appID, err = findAppIDByName(c.Apps(), appSpec.Name); err != nil {
+				return "", err
+			}
+		}
+
+		res, err := c.Apps().Propose(&godo.AppProposeRequest{
+			Spec:  appSpec,
+			AppID: appID,
+		})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s  $%.2f/month", appSpec.Name, res.AppCost), nil
+	})
+}
+
+func readAppSpec(stdin io.Reader, path string) (*godo.AppSpec, error) {
+	byt, err := readAppSpecBytes(stdin, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSingleAppSpecDocument(path, byt); err != nil {
+		return nil, err
+	}
+
+	s, err := parseAppSpec(byt)
+	if err != nil {
+		return nil, fmt.Errorf("parsing app spec: %w", err)
+	}
+
+	return s, nil
+}
+
+// addAppSpecSetFlags adds the flags used to override values in an app spec.
+func addAppSpecSetFlags(cmd *Command) {
+	AddStringArrayFlag(cmd, doctl.ArgAppSet, "", nil, "Override a value in the app spec, e.g. services.0.instance_count=3. Values are parsed as YAML. Repeat to set multiple values.")
+	AddBoolFlag(cmd, doctl.ArgAppSetCreate, "", false, "Allow --"+doctl.ArgAppSet+" to create values that don't exist in the app spec")
+}
+
+// readAppSpecFromArgs reads and merges the app specs at paths, applying any
+// overrides passed with --set. A path of - is read from stdin.
+func readAppSpecFromArgs(c *CmdConfig, stdin io.Reader, paths []string) (*godo.AppSpec, error) {
+	sets, err := c.Doit.GetStringArray(c.NS, doctl.ArgAppSet)
+	if err != nil {
+		return nil, err
+	}
+	if len(sets) == 0 {
+		return readAppSpecs(stdin, paths)
+	}
+
+	createMissing, err := c.Doit.GetBool(c.NS, doctl.ArgAppSetCreate)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := readAppSpecMap(stdin, paths)
+	if err != nil {
+		return nil, err
+	}
+	for _, set := range sets {
+		kv := strings.SplitN(set, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid --%s %q, must be in the form key.path=value", doctl.ArgAppSet, set)
+		}
+		if err := setAppSpecPath(m, kv[0], kv[1], createMissing); err != nil {
+			return nil, fmt.Errorf("invalid --%s %q: %w", doctl.ArgAppSet, set, err)
+		}
+	}
+
+	return appSpecFromMap(m)
+}
+
+// readAppSpecs reads the app specs at paths and deep-merges them in order.
+// Maps are merged key by key with later specs taking precedence, while arrays
+// and scalar values are replaced wholesale.
+func readAppSpecs(stdin io.Reader, paths []string) (*godo.AppSpec, error) {
+	if len(paths) == 1 {
+		return readAppSpec(stdin, paths[0])
+	}
+
+	m, err := readAppSpecMap(stdin, paths)
+	if err != nil {
+		return nil, err
+	}
+	return appSpecFromMap(m)
+}
+
+// readAppSpecMap reads the app specs at paths into a generic map, deep-merging
+// them in order.
+func readAppSpecMap(stdin io.Reader, paths []string) (map[string]interface{}, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("an app spec is required")
+	}
+
+	merged := map[string]interface{}{}
+	for _, path := range paths {
+		byt, err := readAppSpecBytes(stdin, path)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkSingleAppSpecDocument(path, byt); err != nil {
+			return nil, err
+		}
+
+		jsonSpec, err := yaml.YAMLToJSON(byt)
+		if err != nil {
+			return nil, fmt.Errorf("parsing app spec %s: %w", path, err)
+		}
+		var m map[string]interface{}
+		if err := json.Unmarshal(jsonSpec, &m); err != nil {
+			return nil, fmt.Errorf("parsing app spec %s: %w", path, err)
+		}
+		mergeAppSpecMaps(merged, m)
+	}
+	return merged, nil
+}
+
+// appSpecFromMap converts a generic map into an app spec.
+func appSpecFromMap(m map[string]interface{}) (*godo.AppSpec, error) {
+	byt, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("merging app specs: %w", err)
+	}
+
+	s, err := parseAppSpec(byt)
+	if err != nil {
+		return nil, fmt.Errorf("parsing app spec: %w", err)
+	}
+
+	return s, nil
+}
+
+// setAppSpecPath sets the value at the dotted path in spec, e.g.
+// services.0.instance_count. value is parsed as YAML. Unless createMissing is
+// true, the path must already exist in spec.
+func setAppSpecPath(spec map[string]interface{}, path, value string, createMissing bool) error {
+	jsonValue, err := yaml.YAMLToJSON([]byte(value))
+	if err != nil {
+		return err
+	}
+	var v interface{}
+	if err := json.Unmarshal(jsonValue, &v); err != nil {
+		return err
+	}
+
+	var node interface{} = spec
+	parts := strings.Split(path, ".")
+	for i, part := range parts {
+		last := i == len(parts)-1
+
+		switch n := node.(type) {
+		case map[string]interface{}:
+			next, ok := n[part]
+			if !ok && !createMissing {
+				return fmt.Errorf("path %q does not exist in the app spec", strings.Join(parts[:i+1], "."))
+			}
+			if last {
+				n[part] = v
+				return nil
+			}
+			if !ok {
+				next = map[string]interface{}{}
+				n[part] = next
+			}
+			node = next
+		case []interface{}:
+			idx, err := strconv.Atoi(part)
+			if err != nil || idx < 0 || idx >= len(n) {
+				return fmt.Errorf("path %q does not exist in the app spec", strings.Join(parts[:i+1], "."))
+			}
+			if last {
+				n[idx] = v
+				return nil
+			}
+			node = n[idx]
+		default:
+			return fmt.Errorf("path %q does not resolve to an object or list", strings.Join(parts[:i], "."))
+		}
+	}
+	return nil
+}
+
+// mergeAppSpecMaps recursively merges src into dst. Nested maps are merged;
+// all other values in src replace those in dst.
+func mergeAppSpecMaps(dst, src map[string]interface{}) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		dstMap, dstIsMap := dst[k].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergeAppSpecMaps(dstMap, srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// expandHomeDir replaces a leading ~/ in path with the user's home
+// directory, as a shell would if the path weren't quoted.
+func expandHomeDir(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
+
+// readAppSpecBytes reads the contents of the app spec at path, which may be
+// "-" for stdin, an http(s) URL, or a file path. Comments in JSON specs are
+// removed.
+func readAppSpecBytes(stdin io.Reader, path string) ([]byte, error) {
+	var spec io.Reader
+	if path == "-" {
+		spec = stdin
+	} else if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		byt, err := fetchAppSpec(path)
+		if err != nil {
+			return nil, fmt.Errorf("fetching app spec: %w", err)
+		}
+		return stripJSONComments(byt), nil
+	} else {
+		filePath, err := expandHomeDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("opening app spec: %w", err)
+		}
+		specFile, err := os.Open(filePath) // guardrails-disable-line
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, fmt.Errorf("opening app spec: %s does not exist", filePath)
+			}
+			return nil, fmt.Errorf("opening app spec: %w", err)
+		}
+		defer specFile.Close()
+		spec = specFile
+	}
+
+	byt, err := ioutil.ReadAll(spec)
+	if err != nil {
+		return nil, fmt.Errorf("reading app spec: %w", err)
+	}
+	return stripJSONComments(byt), nil
+}
+
+// stripJSONComments replaces the // line and /* */ block comments in a JSON
+// document with spaces, keeping line and column numbers intact. Input that
+// isn't a JSON object, such as YAML, is returned unchanged.
+func stripJSONComments(b []byte) []byte {
+	out := make([]byte, len(b))
+	copy(out, b)
+
+	var isJSON, inString bool
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch {
+		case inString:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case c == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				// Leave unterminated comments for the parser to report.
+				return b
+			}
+			end += i + 4
+			for ; i < end; i++ {
+				if out[i] != '\n' {
+					out[i] = ' '
+				}
+			}
+			i--
+		case !isJSON:
+			switch c {
+			case ' ', '\t', '\r', '\n':
+			case '{':
+				isJSON = true
+			default:
+				return b
+			}
+		case c == '"':
+			inString = true
+		}
+	}
+
+	if !isJSON {
+		return b
+	}
+	return out
+}
+
+// fetchAppSpec downloads an app spec from url.
+func fetchAppSpec(url string) ([]byte, error) {
+	timeout := appSpecFetchTimeout
+	if t := viper.GetDuration(doctl.ArgRequestTimeout); t > 0 {
+		timeout = t
+	}
+	client := &http.Client{Transport: doctl.HTTPTransport(), Timeout: timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mediaType, _, err := mime.ParseMediaType(ct)
+		if err != nil || !(strings.HasPrefix(mediaType, "text/") || strings.Contains(mediaType, "json") || strings.Contains(mediaType, "yaml")) {
+			return nil, fmt.Errorf("unsupported content type %q; expected YAML or JSON", ct)
+		}
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+// appSpecDocumentSeparator matches the line separating two YAML documents.
+var appSpecDocumentSeparator = regexp.MustCompile(`^---[ \t\r]*(#.*)?$`)
+
+// splitAppSpecDocuments splits spec into its YAML documents, leaving out
+// documents that are empty or only contain comments.
+func splitAppSpecDocuments(spec []byte) [][]byte {
+	var (
+		docs  [][]byte
+		doc   []string
+		empty = true
+	)
+	flush := func() {
+		if !empty {
+			docs = append(docs, []byte(strings.Join(doc, "\n")))
+		}
+		doc, empty = nil, true
+	}
+	for _, line := range strings.Split(string(spec), "\n") {
+		if appSpecDocumentSeparator.MatchString(line) {
+			flush()
+			continue
+		}
+		doc = append(doc, line)
+		if trimmed := strings.TrimSpace(line); trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			empty = false
+		}
+	}
+	flush()
+	return docs
+}
+
+// checkSingleAppSpecDocument returns an error if the app spec read from path
+// holds more than one YAML document.
+func checkSingleAppSpecDocument(path string, spec []byte) error {
+	if n := len(splitAppSpecDocuments(spec)); n > 1 {
+		return fmt.Errorf("app spec %s contains %d YAML documents, but only a single app spec is allowed here", appSpecDisplayName(path), n)
+	}
+	return nil
+}
+
+// appSpecDocument is a single YAML document read from an app spec file.
+type appSpecDocument struct {
+	name string
+	spec []byte
+	err  error
+}
+
+// readAppSpecDocuments reads the app spec at path and splits it into its
+// YAML documents.
+func readAppSpecDocuments(stdin io.Reader, path string) ([]appSpecDocument, error) {
+	byt, err := readAppSpecBytes(stdin, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return appSpecDocuments(appSpecDisplayName(path), byt), nil
+}
+
+// appSpecDocuments splits the app spec read from the file name into its YAML
+// documents, naming each after the file and, if there are several, its
+// position.
+func appSpecDocuments(name string, spec []byte) []appSpecDocument {
+	specs := splitAppSpecDocuments(spec)
+	if len(specs) <= 1 {
+		return []appSpecDocument{{name: name, spec: spec}}
+	}
+
+	docs := make([]appSpecDocument, len(specs))
+	for i, s := range specs {
+		docs[i] = appSpecDocument{name: fmt.Sprintf("%s (document %d)", name, i+1), spec: s}
+	}
+	return docs
+}
+
+// appSpecDisplayName returns the name used for the app spec at path in
+// output.
+func appSpecDisplayName(path string) string {
+	if path == "-" {
+		return "<stdin>"
+	}
+	return path
+}
+
+// parseAppSpec parses a YAML or JSON app spec. Errors match ErrAppSpecSyntax
+// or ErrAppSpecSchema, depending on whether the spec couldn't be parsed or
+// didn't match godo.AppSpec.
+func parseAppSpec(spec []byte) (*godo.AppSpec, error) {
+	jsonSpec, err := yaml.YAMLToJSON(spec)
+	if err != nil {
+		return nil, &appSpecError{kind: ErrAppSpecSyntax, err: err}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(jsonSpec))
+	dec.DisallowUnknownFields()
+
+	var appSpec godo.AppSpec
+	if err := dec.Decode(&appSpec); err != nil {
+		return nil, &appSpecError{kind: ErrAppSpecSchema, err: err}
+	}
+
+	return &appSpec, nil
+}
+
+var (
+	// ErrAppSpecSyntax is matched by errors for app specs that aren't valid
+	// YAML or JSON.
+	ErrAppSpecSyntax = errors.New("app spec syntax error")
+	// ErrAppSpecSchema is matched by errors for app specs that are valid YAML
+	// or JSON but don't describe a valid app spec, e.g. because of unknown
+	// fields or values of the wrong type.
+	ErrAppSpecSchema = errors.New("app spec schema error")
+)
+
+// appSpecError is an app spec parsing error of a specific kind, either
+// ErrAppSpecSyntax or ErrAppSpecSchema. Use errors.Is to check its kind.
+type appSpecError struct {
+	kind error
+	err  error
+}
+
+func (e *appSpecError) Error() string {
+	return e.err.Error()
+}
+
+func (e *appSpecError) Unwrap() error {
+	return e.err
+}
+
+func (e *appSpecError) Is(target error) bool {
+	return target == e.kind
+}
+
+// findUnknownAppSpecField returns the first key in the YAML or JSON app spec
+// that doesn't correspond to a field of godo.AppSpec, along with its line
+// number.
+func findUnknownAppSpecField(spec []byte) (key string, line int, ok bool) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(spec, &doc); err != nil {
+		return "", 0, false
+	}
+
+	node := findUnknownField(&doc, reflect.TypeOf(godo.AppSpec{}))
+	if node == nil {
+		return "", 0, false
+	}
+	return node.Value, node.Line, true
+}
+
+// findUnknownField walks node alongside t, matching mapping keys against the
+// json names of struct fields, and returns the first key node without a
+// matching field.
+func findUnknownField(node *yamlv3.Node, t reflect.Type) *yamlv3.Node {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		for _, n := range node.Content {
+			if unknown := findUnknownField(n, t); unknown != nil {
+				return unknown
+			}
+		}
+	case yamlv3.SequenceNode:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+		for _, n := range node.Content {
+			if unknown := findUnknownField(n, t.Elem()); unknown != nil {
+				return unknown
+			}
+		}
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+
+			var fieldType reflect.Type
+			switch t.Kind() {
+			case reflect.Map:
+				fieldType = t.Elem()
+			case reflect.Struct:
+				field, ok := jsonField(t, k.Value)
+				if !ok {
+					return k
+				}
+				fieldType = field.Type
+			default:
+				return nil
+			}
+
+			if unknown := findUnknownField(v, fieldType); unknown != nil {
+				return unknown
+			}
+		}
+	}
+	return nil
+}
+
+// jsonField returns the field of struct type t with the given json name. Like
+// encoding/json, names are matched case-insensitively.
+func jsonField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("json"), ",")[0]
+		if tag == "-" {
+			continue
+		}
+		if tag == "" {
+			tag = f.Name
+		}
+		if strings.EqualFold(tag, name) {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func appsSpec() *Command {
+	cmd := &Command{
+		Command: &cobra.Command{
+			Use:   "spec",
+			Short: "Display commands for working with app specs",
+			Long:  "The subcommands of `doctl app spec` manage your app specs.",
+		},
+	}
+
+	getCmd := CmdBuilder(cmd, RunAppsSpecGet, "get <app id>", "Retrieve an application's spec", `Use this command to retrieve the latest spec of an app.
+
+Optionally, pass a deployment ID to get the spec of that specific deployment. Pass `+"`"+`--deployment latest`+"`"+` to get the spec of the app's newest deployment, or `+"`"+`--deployment latest-active`+"`"+` to get the spec of its active deployment, i.e. the spec that is currently running.
+
+Use --`+doctl.ArgAppComponent+` to output only the definition of a single service, static site, worker, or job.
+
+Use --`+doctl.ArgAppSpecRedactSecrets+` to replace the values of secret environment variables with `+"`"+appSecretRedacted+"`"+`, e.g. before sharing the spec.`, Writer)
+	AddStringFlag(getCmd, doctl.ArgAppDeployment, "", "", `optional: a deployment ID, "latest", or "latest-active"`)
+	AddStringFlag(getCmd, doctl.ArgAppComponent, "", "", "optional: the name of a component to output instead of the whole spec")
+	AddStringFlag(getCmd, doctl.ArgFormat, "", "yaml", `the format to output the spec in; either "yaml" or "json"`)
+	AddStringFlag(getCmd, doctl.ArgAppSpecOutputFile, "", "", "optional: a file to write the spec to instead of stdout")
+	AddBoolFlag(getCmd, doctl.ArgForce, doctl.ArgShortForce, false, "Overwrite the file passed with --"+doctl.ArgAppSpecOutputFile+" if it already exists")
+	AddBoolFlag(getCmd, doctl.ArgAppSpecRedactSecrets, "", false, "Replace the values of secret environment variables with "+appSecretRedacted)
+
+	validateCmd := CmdBuilder(cmd, RunAppsSpecValidate, "validate <spec file>...", "Validate an application spec", `Use this command to check whether a given app spec (YAML or JSON) is valid.
+
+You may pass - as the filename to read from stdin. A spec file may contain several app specs as YAML documents separated by ---. When several spec files or documents are passed, or when every *.yaml, *.yml, and *.json spec in a directory is validated with --`+doctl.ArgAppSpecDir+`, a pass/fail line is printed for each spec and the command fails if any spec is invalid.
+
+With --schema-only, the spec is checked offline against a copy of the app spec schema bundled with doctl, and every violation found is reported. Without it, the spec is validated by the App Platform API.
+
+With --`+doctl.ArgAppSpecStrictEnv+`, the environment variables of the spec are also checked for mistakes that pass validation but break the app at build or run time. These checks are heuristic, so problems are reported as warnings and don't fail validation.`, Writer)
+	AddBoolFlag(validateCmd, doctl.ArgSchemaOnly, "", false, "Only validate the spec schema and not the correctness of the spec.")
+	AddStringFlag(validateCmd, doctl.ArgAppSpecDir, "", "", "Validate every app spec in the given directory")
+	AddBoolFlag(validateCmd, doctl.ArgAppSpecStrictEnv, "", false, "Warn about environment variables that reference undefined variables or components, secrets without a value, and run-time variables that look like build-time ones")
+
+	formatCmd := CmdBuilder(cmd, RunAppsSpecFormat, "format <spec file>", "Format an application spec", `Use this command to rewrite a local app spec (YAML or JSON) as canonical YAML, with its keys sorted, so that specs are formatted consistently in version control.
+
+The formatted spec is written to stdout, or back to the spec file with --`+doctl.ArgAppSpecWrite+`. A JSON spec file is written back as JSON, with its keys sorted the same way. You may pass - as the filename to read from stdin, in which case the spec is always written to stdout. The spec is not sent to the App Platform API.`, Writer)
+	AddBoolFlag(formatCmd, doctl.ArgAppSpecWrite, "", false, "Write the formatted spec back to the spec file instead of stdout")
+
+	diffCmd := CmdBuilder(cmd, RunAppsSpecDiff, "diff <app id>", "Compare app specs", `Use this command to show the differences between two app specs as a unified diff of their YAML.
+
+Pass two deployment IDs with --`+doctl.ArgAppDeployment+` to compare the specs of those deployments, or pass a local spec file with --`+doctl.ArgAppSpec+` to compare it against the spec of the active deployment (or of a single deployment passed with --`+doctl.ArgAppDeployment+`).`, Writer)
+	AddStringSliceFlag(diffCmd, doctl.ArgAppDeployment, "", nil, "a deployment ID; pass twice to compare two deployments")
+	AddStringFlag(diffCmd, doctl.ArgAppSpec, "", "", `Path or URL to an app spec in JSON or YAML format to compare against. Set to "-" to read from stdin.`)
+	AddStringFlag(diffCmd, doctl.ArgFormat, "", "text", `the format to output the diff in; either "text" or "json"`)
+
+	return cmd
+}
+
+// appSpecDeployment returns the deployment of an app identified by
+// deploymentID, which is either a deployment ID, "latest" for the newest
+// deployment, or "latest-active" for the active deployment.
+func appSpecDeployment(apps do.AppsService, appID, deploymentID string) (*godo.Deployment, error) {
+	switch deploymentID {
+	case "latest":
+		var latest *godo.Deployment
+		err := apps.ForEachDeployment(appID, func(d *godo.Deployment) error {
+			latest = d
+			return errLimitReached
+		})
+		if err != nil && !errors.Is(err, errLimitReached) {
+			return nil, err
+		}
+		if latest == nil {
+			return nil, fmt.Errorf("app %s has no deployments", appID)
+		}
+		return latest, nil
+	case "latest-active":
+		app, err := apps.Get(appID)
+		if err != nil {
+			return nil, err
+		}
+		if app.ActiveDeployment == nil {
+			return nil, fmt.Errorf("app %s has no active deployment", appID)
+		}
+		if app.ActiveDeployment.Spec != nil {
+			return app.ActiveDeployment, nil
+		}
+		return apps.GetDeployment(appID, app.ActiveDeployment.ID)
+	default:
+		return apps.GetDeployment(appID, deploymentID)
+	}
+}
+
+// RunAppsSpecGet gets the spec for an app
+func RunAppsSpecGet(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+	deploymentID, err := c.Doit.GetString(c.NS, doctl.ArgAppDeployment)
+	if err != nil {
+		return err
+	}
+
+	format, err := c.Doit.GetString(c.NS, doctl.ArgFormat)
+	if err != nil {
+		return err
+	}
+	if format != "json" && format != "yaml" {
+		return fmt.Errorf("invalid spec format %q, must be one of: json, yaml", format)
+	}
+
+	outputFile, err := c.Doit.GetString(c.NS, doctl.ArgAppSpecOutputFile)
+	if err != nil {
+		return err
+	}
+
+	force, err := c.Doit.GetBool(c.NS, doctl.ArgForce)
+	if err != nil {
+		return err
+	}
+
+	component, err := c.Doit.GetString(c.NS, doctl.ArgAppComponent)
+	if err != nil {
+		return err
+	}
+
+	var spec *godo.AppSpec
+	if deploymentID == "" {
+		app, err := c.Apps().Get(appID)
+		if err != nil {
+			return err
+		}
+		spec = app.Spec
+	} else {
+		deployment, err := appSpecDeployment(c.Apps(), appID, deploymentID)
+		if err != nil {
+			return err
+		}
+		spec = deployment.Spec
+	}
+
+	redact, err := c.Doit.GetBool(c.NS, doctl.ArgAppSpecRedactSecrets)
+	if err != nil {
+		return err
+	}
+	if redact {
+		redactAppSpecSecrets(spec)
+	}
+
+	var v interface{} = spec
+	if component != "" {
+		if v, err = appSpecComponent(spec, component); err != nil {
+			return err
+		}
+	}
+
+	var out []byte
+	switch format {
+	case "json":
+		out, err = json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshaling the spec as json: %v", err)
+		}
+		out = append(out, '\n')
+	case "yaml":
+		out, err = yaml.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("marshaling the spec as yaml: %v", err)
+		}
+	}
+
+	if outputFile == "" {
+		_, err = c.Out.Write(out)
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(outputFile, flags, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("%s already exists; use --%s to overwrite it", outputFile, doctl.ArgForce)
+		}
+		return err
+	}
+	_, err = f.Write(out)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	notice("App spec written to %s", outputFile)
+	return nil
+}
+
+// appSecretRedacted replaces the values of secrets redacted from app specs.
+const appSecretRedacted = "REDACTED"
+
+// redactAppSpecSecrets replaces the value of every secret env var defined in
+// spec, at the app level or by a component, with appSecretRedacted.
+func redactAppSpecSecrets(spec *godo.AppSpec) {
+	if spec == nil {
+		return
+	}
+
+	envs := []*[]*godo.AppVariableDefinition{&spec.Envs}
+	for _, s := range spec.Services {
+		envs = append(envs, &s.Envs)
+	}
+	for _, s := range spec.StaticSites {
+		envs = append(envs, &s.Envs)
+	}
+	for _, w := range spec.Workers {
+		envs = append(envs, &w.Envs)
+	}
+	for _, j := range spec.Jobs {
+		envs = append(envs, &j.Envs)
+	}
+
+	for _, defs := range envs {
+		for i, env := range *defs {
+			if env.Type == godo.AppVariableType_Secret && env.Value != "" {
+				redacted := *env
+				redacted.Value = appSecretRedacted
+				(*defs)[i] = &redacted
+			}
+		}
+	}
+}
+
+// appSpecDiff is a structured representation of the differences between two
+// app specs.
+type appSpecDiff struct {
+	From    string              `json:"from"`
+	To      string              `json:"to"`
+	Changes []appSpecDiffChange `json:"changes"`
+}
+
+// appSpecDiffChange is a single change between two app specs. Line numbers
+// are 1-based and refer to the canonical YAML of each spec.
+type appSpecDiffChange struct {
+	Op       string   `json:"op"`
+	FromLine int      `json:"from_line"`
+	ToLine   int      `json:"to_line"`
+	Removed  []string `json:"removed,omitempty"`
+	Added    []string `json:"added,omitempty"`
+}
+
+// RunAppsSpecDiff compares two app specs.
+func RunAppsSpecDiff(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	deploymentIDs, err := c.Doit.GetStringSlice(c.NS, doctl.ArgAppDeployment)
+	if err != nil {
+		return err
+	}
+
+	specPath, err := c.Doit.GetString(c.NS, doctl.ArgAppSpec)
+	if err != nil {
+		return err
+	}
+
+	format, err := c.Doit.GetString(c.NS, doctl.ArgFormat)
+	if err != nil {
+		return err
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("invalid diff format %q, must be one of: text, json", format)
+	}
+
+	var (
+		fromName, toName string
+		fromSpec, toSpec *godo.AppSpec
+	)
+	switch {
+	case specPath != "" && len(deploymentIDs) <= 1:
+		if len(deploymentIDs) == 1 {
+			deployment, err := c.Apps().GetDeployment(appID, deploymentIDs[0])
+			if err != nil {
+				return err
+			}
+			fromName, fromSpec = "deployment "+deployment.ID, deployment.Spec
+		} else {
+			app, err := c.Apps().Get(appID)
+			if err != nil {
+				return err
+			}
+			if app.ActiveDeployment == nil {
+				return fmt.Errorf("app %s has no active deployment", appID)
+			}
+			fromName, fromSpec = "deployment "+app.ActiveDeployment.ID, app.ActiveDeployment.Spec
+		}
+
+		toName = specPath
+		toSpec, err = readAppSpec(os.Stdin, specPath)
+		if err != nil {
+			return err
+		}
+	case specPath == "" && len(deploymentIDs) == 2:
+		from, err := c.Apps().GetDeployment(appID, deploymentIDs[0])
+		if err != nil {
+			return err
+		}
+		to, err := c.Apps().GetDeployment(appID, deploymentIDs[1])
+		if err != nil {
+			return err
+		}
+		fromName, fromSpec = "deployment "+from.ID, from.Spec
+		toName, toSpec = "deployment "+to.ID, to.Spec
+	default:
+		return fmt.Errorf("either two --%s IDs or --%s must be provided", doctl.ArgAppDeployment, doctl.ArgAppSpec)
+	}
+
+	if format == "json" {
+		diff, err := newAppSpecDiff(fromName, fromSpec, toName, toSpec)
+		if err != nil {
+			return err
+		}
+		e := json.NewEncoder(c.Out)
+		e.SetIndent("", "  ")
+		return e.Encode(diff)
+	}
+
+	return writeAppSpecUnifiedDiff(c.Out, fromName, fromSpec, toName, toSpec)
+}
+
+// appSpecYAMLLines returns the lines of the canonical YAML of spec.
+func appSpecYAMLLines(spec *godo.AppSpec) ([]string, error) {
+	byt, err := yaml.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling the spec as yaml: %v", err)
+	}
+	return splitLines(string(byt)), nil
+}
+
+// newAppSpecDiff builds a structured diff between the YAML of two app specs.
+func newAppSpecDiff(fromName string, fromSpec *godo.AppSpec, toName string, toSpec *godo.AppSpec) (*appSpecDiff, error) {
+	fromLines, err := appSpecYAMLLines(fromSpec)
+	if err != nil {
+		return nil, err
+	}
+	toLines, err := appSpecYAMLLines(toSpec)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &appSpecDiff{
+		From:    fromName,
+		To:      toName,
+		Changes: []appSpecDiffChange{},
+	}
+	for _, op := range difflib.NewMatcher(fromLines, toLines).GetOpCodes() {
+		change := appSpecDiffChange{
+			FromLine: op.I1 + 1,
+			ToLine:   op.J1 + 1,
+			Removed:  trimLines(fromLines[op.I1:op.I2]),
+			Added:    trimLines(toLines[op.J1:op.J2]),
+		}
+		switch op.Tag {
+		case 'r':
+			change.Op = "replace"
+		case 'd':
+			change.Op = "delete"
+		case 'i':
+			change.Op = "insert"
+		default:
+			continue
+		}
+		diff.Changes = append(diff.Changes, change)
+	}
+	return diff, nil
+}
+
+// writeAppSpecUnifiedDiff writes a unified diff between the YAML of two app
+// specs to out.
+func writeAppSpecUnifiedDiff(out io.Writer, fromName string, fromSpec *godo.AppSpec, toName string, toSpec *godo.AppSpec) error {
+	fromLines, err := appSpecYAMLLines(fromSpec)
+	if err != nil {
+		return err
+	}
+	toLines, err := appSpecYAMLLines(toSpec)
+	if err != nil {
+		return err
+	}
+
+	return difflib.WriteUnifiedDiff(out, difflib.UnifiedDiff{
+		A:        fromLines,
+		B:        toLines,
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+}
+
+// splitLines splits s into lines, keeping their trailing newlines.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// trimLines returns lines with their trailing newlines removed.
+func trimLines(lines []string) []string {
+	if len(lines) == 0 {
+		return nil
+	}
+	trimmed := make([]string, 0, len(lines))
+	for _, l := range lines {
+		trimmed = append(trimmed, strings.TrimSuffix(l, "\n"))
+	}
+	return trimmed
+}
+
+// RunAppsSpecValidate validates an app spec file
+func RunAppsSpecValidate(c *CmdConfig) error {
+	schemaOnly, err := c.Doit.GetBool(c.NS, doctl.ArgSchemaOnly)
+	if err != nil {
+		return err
+	}
+
+	dir, err := c.Doit.GetString(c.NS, doctl.ArgAppSpecDir)
+	if err != nil {
+		return err
+	}
+	if dir != "" {
+		if len(c.Args) > 0 {
+			return fmt.Errorf("a spec file cannot be combined with --%s", doctl.ArgAppSpecDir)
+		}
+		return validateAppSpecDir(c, dir, schemaOnly)
+	}
+
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	if len(c.Args) > 1 {
+		stdin := 0
+		for _, path := range c.Args {
+			if path == "-" {
+				stdin++
+			}
+		}
+		if stdin > 1 {
+			return errors.New("- can only be passed once")
+		}
+		return validateAppSpecFiles(c, c.Args, schemaOnly)
+	}
+
+	docs, err := readAppSpecDocuments(os.Stdin, c.Args[0])
+	if err != nil {
+		return err
+	}
+	if len(docs) > 1 {
+		return validateAppSpecDocuments(c, docs, schemaOnly)
+	}
+
+	spec, err := validateAppSpec(c, docs[0].name, docs[0].spec, schemaOnly)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrAppSpecSyntax):
+			notice("The app spec is not valid YAML or JSON; check its indentation, quoting, and brackets")
+		case errors.Is(err, ErrAppSpecSchema):
+			notice("The app spec has invalid fields; see https://www.digitalocean.com/docs/app-platform/concepts/app-spec for the supported fields")
+		}
+		return err
+	}
+
+	ymlSpec, err := yaml.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("marshaling the spec as yaml: %v", err)
+	}
+	_, err = c.Out.Write(ymlSpec)
+	return err
+}
+
+// validateAppSpec validates the app spec in byt, read from the file name,
+// and returns it. With schemaOnly the spec is only checked against the
+// embedded schema; otherwise it is validated by the Propose API and the
+// normalized spec is returned.
+func validateAppSpec(c *CmdConfig, name string, byt []byte, schemaOnly bool) (*godo.AppSpec, error) {
+	if schemaOnly {
+		if err := validateAppSpecSchema(name, byt); err != nil {
+			return nil, err
+		}
+	}
+
+	appSpec, err := parseAppSpec(byt)
+	if err != nil {
+		if key, line, ok := findUnknownAppSpecField(byt); ok {
+			return nil, &appSpecError{kind: ErrAppSpecSchema, err: fmt.Errorf("%s:%d: unknown field %q", name, line, key)}
+		}
+		return nil, fmt.Errorf("parsing app spec: %w", err)
+	}
+
+	strictEnv, err := c.Doit.GetBool(c.NS, doctl.ArgAppSpecStrictEnv)
+	if err != nil {
+		return nil, err
+	}
+	if strictEnv {
+		for _, problem := range lintAppSpecEnvs(appSpec) {
+			warn("%s: %s", name, problem)
+		}
+	}
+
+	if schemaOnly {
+		return appSpec, nil
+	}
+
+	res, err := c.Apps().Propose(&godo.AppProposeRequest{
+		Spec: appSpec,
+	})
+	if err != nil {
+		// most likely an invalid app spec. The error message would start with "error validating app spec"
+		return nil, err
+	}
+	return res.Spec, nil
+}
+
+var (
+	// appEnvReferencePattern matches a reference to another variable in the
+	// value of an app environment variable, e.g. ${db.DATABASE_URL}.
+	appEnvReferencePattern = regexp.MustCompile(`\$\{([^}]+)\}`)
+	// appBuildTimeEnvPattern matches the names of environment variables that
+	// are usually only read while an app is built.
+	appBuildTimeEnvPattern = regexp.MustCompile(`^(BP_|BUILD_|NPM_CONFIG_|YARN_|PIP_|COMPOSER_)|^(CGO_ENABLED|GOFLAGS|NODE_OPTIONS)$`)
+	// appBuiltinEnvs are the app-wide variables provided by App Platform.
+	appBuiltinEnvs = map[string]bool{"APP_DOMAIN": true, "APP_ID": true, "APP_URL": true}
+)
+
+// lintAppSpecEnvs returns problems with the environment variables of spec
+// that validation doesn't catch: references to undefined variables or
+// components, secrets without a value, and run-time variables that look like
+// build-time ones.
+func lintAppSpecEnvs(spec *godo.AppSpec) []string {
+	envs := make(map[string]bool)
+	for _, env := range spec.Envs {
+		envs[env.Key] = true
+	}
+	components := map[string]bool{"_self": true}
+	for _, name := range appComponentNames(spec) {
+		components[name] = true
+	}
+	for _, db := range spec.Databases {
+		components[db.Name] = true
+	}
+
+	var problems []string
+	for _, component := range append([]string{""}, appComponentNames(spec)...) {
+		where := "app env"
+		if component != "" {
+			where = fmt.Sprintf("component %s env", component)
+		}
+
+		defs, err := appEnvs(spec, component)
+		if err != nil {
+			continue
+		}
+		for _, env := range *defs {
+			if env.Type == godo.AppVariableType_Secret && env.Value == "" {
+				problems = append(problems, fmt.Sprintf("%s %s is a secret with no value", where, env.Key))
+			}
+			if env.Scope == godo.AppVariableScope_RunTime && appBuildTimeEnvPattern.MatchString(env.Key) {
+				problems = append(problems, fmt.Sprintf("%s %s looks like a build-time variable but is only available at run time", where, env.Key))
+			}
+
+			for _, m := range appEnvReferencePattern.FindAllStringSubmatch(env.Value, -1) {
+				ref := m[1]
+				if i := strings.Index(ref, "."); i >= 0 {
+					if !components[ref[:i]] {
+						problems = append(problems, fmt.Sprintf("%s %s references ${%s}, but there is no component named %s", where, env.Key, ref, ref[:i]))
+					}
+				} else if !envs[ref] && !appBuiltinEnvs[ref] {
+					problems = append(problems, fmt.Sprintf("%s %s references ${%s}, which is not an app-level variable", where, env.Key, ref))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// validateAppSpecDir validates every YAML and JSON app spec in dir, printing
+// whether each one passed.
+func validateAppSpecDir(c *CmdConfig, dir string, schemaOnly bool) error {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("reading app spec directory: %w", err)
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no app specs found in %s", dir)
+	}
+
+	return validateAppSpecFiles(c, paths, schemaOnly)
+}
+
+// validateAppSpecFiles validates each of the app specs at paths, printing a
+// pass/fail line for each, and fails if any spec is invalid. A path of - reads
+// a spec from stdin.
+func validateAppSpecFiles(c *CmdConfig, paths []string, schemaOnly bool) error {
+	var docs []appSpecDocument
+	for _, path := range paths {
+		d, err := readAppSpecDocuments(os.Stdin, path)
+		if err != nil {
+			docs = append(docs, appSpecDocument{name: appSpecDisplayName(path), err: err})
+			continue
+		}
+		docs = append(docs, d...)
+	}
+	return validateAppSpecDocuments(c, docs, schemaOnly)
+}
+
+// validateAppSpecDocuments validates each of docs, printing a pass/fail line
+// for each, and fails if any spec is invalid.
+func validateAppSpecDocuments(c *CmdConfig, docs []appSpecDocument, schemaOnly bool) error {
+	return reportAppSpecDocuments(c, docs, func(doc appSpecDocument) (string, error) {
+		_, err := validateAppSpec(c, doc.name, doc.spec, schemaOnly)
+		return "", err
+	})
+}
+
+// reportAppSpecDocuments calls check for each of docs that could be read,
+// printing a pass/fail line for each along with the detail returned by check
+// or the error, and fails if any document failed.
+func reportAppSpecDocuments(c *CmdConfig, docs []appSpecDocument, check func(appSpecDocument) (string, error)) error {
+	failed := 0
+	for _, doc := range docs {
+		err := doc.err
+		var detail string
+		if err == nil {
+			detail, err = check(doc)
+		}
+		if err != nil {
+			failed++
+			fmt.Fprintf(c.Out, "FAIL  %s\n", doc.name)
+			for _, line := range strings.Split(err.Error(), "\n") {
+				fmt.Fprintf(c.Out, "      %s\n", line)
+			}
+			continue
+		}
+		if detail != "" {
+			fmt.Fprintf(c.Out, "PASS  %s  %s\n", doc.name, detail)
+		} else {
+			fmt.Fprintf(c.Out, "PASS  %s\n", doc.name)
+		}
+	}
+
+	fmt.Fprintf(c.Out, "\n%d of %d app specs are valid\n", len(docs)-failed, len(docs))
+	if failed > 0 {
+		return fmt.Errorf("%d app spec(s) failed validation", failed)
+	}
+	return nil
+}
+
+// RunAppsSpecFormat formats a local app spec file as canonical YAML, or as
+// canonical JSON when a JSON spec is written back with --write.
+func RunAppsSpecFormat(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	specPath := c.Args[0]
+
+	write, err := c.Doit.GetBool(c.NS, doctl.ArgAppSpecWrite)
+	if err != nil {
+		return err
+	}
+	if write && (specPath == "-" || strings.HasPrefix(specPath, "http://") || strings.HasPrefix(specPath, "https://")) {
+		return fmt.Errorf("--%s can only be used with a local spec file", doctl.ArgAppSpecWrite)
+	}
+
+	byt, err := readAppSpecBytes(os.Stdin, specPath)
+	if err != nil {
+		return err
+	}
+	if err := checkSingleAppSpecDocument(specPath, byt); err != nil {
+		return err
+	}
+	spec, err := parseAppSpec(byt)
+	if err != nil {
+		return fmt.Errorf("parsing app spec: %w", err)
+	}
+
+	out, err := yaml.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("marshaling the spec as yaml: %v", err)
+	}
+
+	if !write {
+		_, err = c.Out.Write(out)
+		return err
+	}
+
+	// A JSON spec is written back as JSON, so that --write doesn't leave YAML
+	// in a file that tools expect to be JSON. Its keys are sorted like those
+	// of the YAML output.
+	if isJSONAppSpec(specPath, byt) {
+		j, err := yaml.YAMLToJSON(out)
+		if err != nil {
+			return fmt.Errorf("marshaling the spec as json: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, j, "", "  "); err != nil {
+			return fmt.Errorf("marshaling the spec as json: %v", err)
+		}
+		buf.WriteByte('\n')
+		out = buf.Bytes()
+	}
+
+	filePath, err := expandHomeDir(specPath)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filePath, out, info.Mode().Perm()); err != nil {
+		return err
+	}
+
+	notice("App spec written to %s", filePath)
+	return nil
+}
+
+// isJSONAppSpec reports whether the app spec read from path is JSON, either
+// because of its extension or because it is a JSON object.
+func isJSONAppSpec(path string, spec []byte) bool {
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return true
+	}
+	return bytes.HasPrefix(bytes.TrimSpace(spec), []byte("{"))
+}
+
+// RunAppsListBandwidth lists the bandwidth used by apps over a period of
+// days.
+func RunAppsListBandwidth(c *CmdConfig) error {
+	since, until, err := appBandwidthPeriod(c, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+
+	csvOut, err := c.Doit.GetBool(c.NS, doctl.ArgAppBandwidthCSV)
+	if err != nil {
+		return err
+	}
+	if csvOut && Output != "text" {
+		return fmt.Errorf("--%s cannot be combined with --%s %s", doctl.ArgAppBandwidthCSV, doctl.ArgOutput, Output)
+	}
+
+	list, err := c.Apps().List()
+	if err != nil {
+		return err
+	}
+	apps, err := selectApps(list, c.Args)
+	if err != nil {
+		return err
+	}
+
+	usage := make(displayers.AppBandwidthUsages, len(apps))
+	ids := make([]string, len(apps))
+	for i, app := range apps {
+		usage[i] = displayers.AppBandwidthUsage{AppID: app.ID}
+		if app.Spec != nil {
+			usage[i].Name = app.Spec.Name
+		}
+		ids[i] = app.ID
+	}
+
+	if len(ids) > 0 {
+		used, err := sumAppBandwidthUsage(c.Apps(), ids, since, until)
+		if err != nil {
+			return err
+		}
+		for i := range usage {
+			usage[i].BandwidthBytes = used[usage[i].AppID]
+		}
+	}
+
+	if csvOut {
+		return writeAppBandwidthCSV(c.Out, usage)
+	}
+	return c.Display(usage)
+}
+
+// sumAppBandwidthUsage returns the bytes used by each of the apps from the
+// start of since to the end of until, by app ID. The API reports usage one
+// day at a time, so each day is requested for all the apps at once.
+func sumAppBandwidthUsage(apps do.AppsService, ids []string, since, until time.Time) (map[string]uint64, error) {
+	used := make(map[string]uint64, len(ids))
+	for day := since; !day.After(until); day = day.AddDate(0, 0, 1) {
+		daily, err := apps.ListBandwidthUsage(ids, day)
+		if err != nil {
+			return nil, fmt.Errorf("listing bandwidth usage for %s: %w", day.Format(appBandwidthDateLayout), err)
+		}
+		for _, u := range daily {
+			used[u.AppID] += u.BandwidthBytes
+		}
+	}
+	return used, nil
+}
+
+// RunAppsGetMetrics gets the CPU and memory usage of each of an app's
+// components over a period, and the bandwidth used by the app.
+func RunAppsGetMetrics(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	period, err := c.Doit.GetDuration(c.NS, doctl.ArgAppMetricsPeriod)
+	if err != nil {
+		return err
+	}
+	if period <= 0 {
+		return fmt.Errorf("--%s must be positive", doctl.ArgAppMetricsPeriod)
+	}
+
+	end := time.Now().UTC()
+	start := end.Add(-period)
+
+	cpu, err := c.Apps().GetMetric(appID, do.AppMetricCPUPercentage, start, end)
+	if err != nil {
+		return fmt.Errorf("getting CPU usage: %w", err)
+	}
+	memory, err := c.Apps().GetMetric(appID, do.AppMetricMemoryPercentage, start, end)
+	if err != nil {
+		return fmt.Errorf("getting memory usage: %w", err)
+	}
+	metrics := appComponentMetrics(cpu, memory)
+
+	since := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	until := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	used, err := sumAppBandwidthUsage(c.Apps(), []string{appID}, since, until)
+	if err != nil {
+		return err
+	}
+	bandwidth := displayers.AppBandwidthUsage{AppID: appID, BandwidthBytes: used[appID]}
+
+	if Output == "json" {
+		e := json.NewEncoder(c.Out)
+		e.SetIndent("", "  ")
+		return e.Encode(struct {
+			Components     displayers.AppMetrics `json:"components"`
+			Since          string                `json:"bandwidth_since"`
+			Until          string                `json:"bandwidth_until"`
+			BandwidthBytes uint64                `json:"bandwidth_bytes"`
+		}{metrics, since.Format(appBandwidthDateLayout), until.Format(appBandwidthDateLayout), bandwidth.BandwidthBytes})
+	}
+
+	if err := c.Display(metrics); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(c.Out, "\nBandwidth used from %s to %s: %s GiB\n",
+		since.Format(appBandwidthDateLayout), until.Format(appBandwidthDateLayout), bandwidth.GiB())
+	return err
+}
+
+// appComponentMetrics returns the average and maximum of the CPU and memory
+// samples of each component, sorted by component name. The samples of all
+// the instances of a component are combined.
+func appComponentMetrics(cpu, memory []*do.AppMetricSample) displayers.AppMetrics {
+	cpuStats, memoryStats := appMetricStats(cpu), appMetricStats(memory)
+
+	var components []string
+	for component := range cpuStats {
+		components = append(components, component)
+	}
+	for component := range memoryStats {
+		if _, ok := cpuStats[component]; !ok {
+			components = append(components, component)
+		}
+	}
+	sort.Strings(components)
+
+	metrics := make(displayers.AppMetrics, len(components))
+	for i, component := range components {
+		metrics[i] = displayers.AppComponentMetrics{
+			Component:     component,
+			CPUAverage:    cpuStats[component].average,
+			CPUMax:        cpuStats[component].max,
+			MemoryAverage: memoryStats[component].average,
+			MemoryMax:     memoryStats[component].max,
+		}
+	}
+	return metrics
+}
+
+type metricStats struct {
+	average, max float64
+}
+
+// appMetricStats returns the average and maximum of the samples of each
+// component.
+func appMetricStats(samples []*do.AppMetricSample) map[string]metricStats {
+	sums := make(map[string]float64)
+	counts := make(map[string]int)
+	stats := make(map[string]metricStats)
+	for _, sample := range samples {
+		sums[sample.Component] += sample.Value
+		counts[sample.Component]++
+		st := stats[sample.Component]
+		if sample.Value > st.max {
+			st.max = sample.Value
+		}
+		stats[sample.Component] = st
+	}
+	for component, st := range stats {
+		st.average = sums[component] / float64(counts[component])
+		stats[component] = st
+	}
+	return stats
+}
+
+// appBandwidthDateLayout is the layout of the days passed to list-bandwidth.
+const appBandwidthDateLayout = "2006-01-02"
+
+// appBandwidthPeriod returns the first and last day of the period set by the
+// --since and --until flags, defaulting to the month of now up to now.
+func appBandwidthPeriod(c *CmdConfig, now time.Time) (time.Time, time.Time, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	since, err := appBandwidthDay(c, doctl.ArgAppBandwidthSince, today.AddDate(0, 0, 1-today.Day()))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	until, err := appBandwidthDay(c, doctl.ArgAppBandwidthUntil, today)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	if until.Before(since) {
+		return time.Time{}, time.Time{}, fmt.Errorf("--%s must not be before --%s", doctl.ArgAppBandwidthUntil, doctl.ArgAppBandwidthSince)
+	}
+	return since, until, nil
+}
+
+// appBandwidthDay returns the day passed with the named flag, or def if it
+// wasn't passed.
+func appBandwidthDay(c *CmdConfig, flag string, def time.Time) (time.Time, error) {
+	value, err := c.Doit.GetString(c.NS, flag)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if value == "" {
+		return def, nil
+	}
+
+	day, err := time.Parse(appBandwidthDateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --%s %q, must be a date such as 2021-03-01", flag, value)
+	}
+	return day, nil
+}
+
+// selectApps returns the apps of list identified by idsOrNames, in order, or
+// all of list if idsOrNames is empty.
+func selectApps(list []*godo.App, idsOrNames []string) ([]*godo.App, error) {
+	if len(idsOrNames) == 0 {
+		return list, nil
+	}
+
+	byID := make(map[string]*godo.App, len(list))
+	for _, app := range list {
+		byID[app.ID] = app
+	}
+
+	apps := make([]*godo.App, 0, len(idsOrNames))
+	for _, idOrName := range idsOrNames {
+		id := idOrName
+		if !uuidPattern.MatchString(idOrName) {
+			var err error
+			if id, err = onlyAppID(idOrName, appIDsNamed(list, idOrName)); err != nil {
+				return nil, err
+			}
+		}
+		app, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("app %s not found", id)
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+// writeAppBandwidthCSV writes usage to w as CSV, with a header row.
+func writeAppBandwidthCSV(w io.Writer, usage displayers.AppBandwidthUsages) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"App ID", "Name", "GiB Used"}); err != nil {
+		return err
+	}
+	for _, u := range usage {
+		if err := cw.Write([]string{u.AppID, u.Name, u.GiB()}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// RunAppsOpen opens an app's live URL in the browser.
+func RunAppsOpen(c *CmdConfig) error {
 	if len(c.Args) < 1 {
 		return doctl.NewMissingArgsErr(c.NS)
 	}
-	appID := c.Args[0]
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
 
-	deployments, err := c.Apps().ListDeployments(appID)
+	printURL, err := c.Doit.GetBool(c.NS, doctl.ArgAppOpenPrint)
 	if err != nil {
 		return err
 	}
 
-	return c.Display(displayers.Deployments(deployments))
+	app, err := c.Apps().Get(appID)
+	if err != nil {
+		return err
+	}
+
+	liveURL, err := appLiveURL(app)
+	if err != nil {
+		return err
+	}
+
+	if printURL {
+		_, err := fmt.Fprintln(c.Out, liveURL)
+		return err
+	}
+
+	notice("Opening %s", liveURL)
+	return openBrowser(liveURL)
 }
 
-// RunAppsGetLogs gets app logs for a given component.
-func RunAppsGetLogs(c *CmdConfig) error {
+// appLiveURL returns the URL at which app is live.
+func appLiveURL(app *godo.App) (string, error) {
+	liveURL := app.LiveURL
+	if liveURL == "" {
+		liveURL = app.DefaultIngress
+	}
+	if liveURL == "" {
+		return "", fmt.Errorf("app %s has no live URL yet; it may still be deploying", app.ID)
+	}
+	return liveURL, nil
+}
+
+// RunAppsListRegions lists all app platform regions.
+func RunAppsListRegions(c *CmdConfig) error {
+	regions, err := c.Apps().ListRegions()
+	if err != nil {
+		return err
+	}
+
+	return c.Display(displayers.AppRegions(regions))
+}
+
+func appsTier() *Command {
+	cmd := &Command{
+		Command: &cobra.Command{
+			Use:   "tier",
+			Short: "Display commands for working with app tiers",
+			Long:  "The subcommands of `doctl app tier` retrieve information about app tiers.",
+		},
+	}
+
+	CmdBuilder(cmd, RunAppsTierList, "list", "List all app tiers", `Use this command to list all the available app tiers.`, Writer, displayerType(&displayers.AppTiers{}))
+	CmdBuilder(cmd, RunAppsTierGet, "get <tier slug>...", "Retrieve app tiers", `Use this command to retrieve information about one or more app tiers.
+
+Tiers that are not found are reported after the others are displayed.`, Writer, displayerType(&displayers.AppTiers{}))
+	CmdBuilder(cmd, RunAppsTierCompare, "compare <tier slug> <tier slug>", "Compare two app tiers", `Use this command to compare two app tiers side by side, including the difference in their monthly prices.
+
+A tier's monthly price is the price of its least expensive instance size.`, Writer, displayerType(&displayers.AppTierComparison{}))
+
+	cmd.AddCommand(appsTierInstanceSize())
+
+	return cmd
+}
+
+// RunAppsTierList lists all app tiers.
+func RunAppsTierList(c *CmdConfig) error {
+	tiers, err := c.Apps().ListTiers()
+	if err != nil {
+		return err
+	}
+
+	prices, err := appTierMonthlyPrices(c.Apps())
+	if err != nil {
+		warn("Could not retrieve app tier prices: %v", err)
+	}
+
+	return c.Display(displayers.AppTiers{Tiers: tiers, MonthlyPrices: prices})
+}
+
+// RunAppsTierGet gets one or more app tiers.
+func RunAppsTierGet(c *CmdConfig) error {
 	if len(c.Args) < 1 {
 		return doctl.NewMissingArgsErr(c.NS)
 	}
-	appID := c.Args[0]
-	var component string
-	if len(c.Args) >= 2 {
-		component = c.Args[1]
+
+	var (
+		tiers    []*godo.AppTier
+		notFound []string
+	)
+	for _, slug := range c.Args {
+		tier, err := c.Apps().GetTier(slug)
+		if err != nil {
+			errResp, ok := err.(*godo.ErrorResponse)
+			if !ok || errResp.Response == nil || errResp.Response.StatusCode != http.StatusNotFound {
+				return err
+			}
+			notFound = append(notFound, slug)
+			continue
+		}
+		tiers = append(tiers, tier)
 	}
 
-	deploymentID, err := c.Doit.GetString(c.NS, doctl.ArgAppDeployment)
+	if len(tiers) > 0 {
+		prices, err := appTierMonthlyPrices(c.Apps())
+		if err != nil {
+			warn("Could not retrieve app tier prices: %v", err)
+		}
+
+		if err := c.Display(displayers.AppTiers{Tiers: tiers, MonthlyPrices: prices}); err != nil {
+			return err
+		}
+	}
+
+	if len(notFound) > 0 {
+		return fmt.Errorf("app tier(s) not found: %s", strings.Join(notFound, ", "))
+	}
+	return nil
+}
+
+// RunAppsTierCompare compares two app tiers.
+func RunAppsTierCompare(c *CmdConfig) error {
+	if len(c.Args) < 2 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+
+	from, err := c.Apps().GetTier(c.Args[0])
 	if err != nil {
 		return err
 	}
-	if deploymentID == "" {
-		app, err := c.Apps().Get(appID)
+	to, err := c.Apps().GetTier(c.Args[1])
+	if err != nil {
+		return err
+	}
+
+	prices, err := appTierMonthlyPrices(c.Apps())
+	if err != nil {
+		return err
+	}
+
+	return c.Display(displayers.AppTierComparison{From: from, To: to, MonthlyPrices: prices})
+}
+
+// appTierMonthlyPrices returns the starting monthly price of each tier in
+// cents, keyed by tier slug. The tiers API doesn't report prices, so a tier's
+// price is that of its least expensive instance size.
+func appTierMonthlyPrices(apps do.AppsService) (map[string]int64, error) {
+	sizes, err := apps.ListInstanceSizes()
+	if err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]int64)
+	for _, size := range sizes {
+		usd, err := strconv.ParseFloat(size.USDPerMonth, 64)
 		if err != nil {
-			return err
+			continue
 		}
-		if app.ActiveDeployment != nil {
-			deploymentID = app.ActiveDeployment.ID
-		} else if app.InProgressDeployment != nil {
-			deploymentID = app.InProgressDeployment.ID
-		} else {
-			return fmt.Errorf("unable to retrieve logs; no deployment found for app %s", appID)
+		cents := int64(math.Round(usd * 100))
+		if price, ok := prices[size.TierSlug]; !ok || cents < price {
+			prices[size.TierSlug] = cents
+		}
+	}
+	return prices, nil
+}
+
+func appsTierInstanceSize() *Command {
+	cmd := &Command{
+		Command: &cobra.Command{
+			Use:   "instance-size",
+			Short: "Display commands for working with app instance sizes",
+			Long:  "The subcommands of `doctl app tier instance-size` retrieve information about app instance sizes.",
+		},
+	}
+
+	list := CmdBuilder(cmd, RunAppsTierInstanceSizeList, "list", "List all app instance sizes", `Use this command to list all the available app instance sizes.`, Writer, displayerType(&displayers.AppInstanceSizes{}))
+	AddStringFlag(list, doctl.ArgAppTier, "", "", "Only list instance sizes belonging to the tier with this slug")
+	AddStringFlag(list, doctl.ArgAppCPUType, "", "", "Only list instance sizes with this CPU type (shared or dedicated)")
+	get := CmdBuilder(cmd, RunAppsTierInstanceSizeGet, "get <instance size slug>...", "Retrieve app instance sizes", `Use this command to retrieve information about one or more app instance sizes.
+
+Unknown instance sizes are reported after the others are displayed. With --`+doctl.ArgAppTierPriceTotals+`, a final row shows the combined price of the listed sizes, which is useful for estimating the cost of an app with several components. Repeat a slug to count it more than once.`, Writer, displayerType(&displayers.AppInstanceSizes{}))
+	AddBoolFlag(get, doctl.ArgAppTierPriceTotals, "", false, "Add a row with the combined price of the instance sizes")
+
+	return cmd
+}
+
+// RunAppsTierInstanceSizeList lists all app tiers.
+func RunAppsTierInstanceSizeList(c *CmdConfig) error {
+	tier, err := c.Doit.GetString(c.NS, doctl.ArgAppTier)
+	if err != nil {
+		return err
+	}
+	cpuType, err := c.Doit.GetString(c.NS, doctl.ArgAppCPUType)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(cpuType) {
+	case "", "shared", "dedicated":
+	default:
+		return fmt.Errorf("invalid CPU type %q; expected shared or dedicated", cpuType)
+	}
+
+	instanceSizes, err := c.Apps().ListInstanceSizes()
+	if err != nil {
+		return err
+	}
+
+	if tier != "" || cpuType != "" {
+		filtered := make([]*godo.AppInstanceSize, 0, len(instanceSizes))
+		for _, size := range instanceSizes {
+			if tier != "" && size.TierSlug != tier {
+				continue
+			}
+			if cpuType != "" && !strings.EqualFold(string(size.CPUType), cpuType) {
+				continue
+			}
+			filtered = append(filtered, size)
 		}
+		instanceSizes = filtered
 	}
 
-	logTypeStr, err := c.Doit.GetString(c.NS, doctl.ArgAppLogType)
+	return c.Display(displayers.AppInstanceSizes(instanceSizes))
+}
+
+// RunAppsTierInstanceSizeGet gets an app tier.
+func RunAppsTierInstanceSizeGet(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+
+	totals, err := c.Doit.GetBool(c.NS, doctl.ArgAppTierPriceTotals)
 	if err != nil {
 		return err
 	}
-	var logType godo.AppLogType
-	switch logTypeStr {
-	case strings.ToLower(string(godo.AppLogTypeBuild)):
-		logType = godo.AppLogTypeBuild
-	case strings.ToLower(string(godo.AppLogTypeDeploy)):
-		logType = godo.AppLogTypeDeploy
-	case strings.ToLower(string(godo.AppLogTypeRun)):
-		logType = godo.AppLogTypeRun
+
+	var (
+		sizes    displayers.AppInstanceSizes
+		notFound []string
+	)
+	for _, slug := range c.Args {
+		size, err := c.Apps().GetInstanceSize(slug)
+		if err != nil {
+			errResp, ok := err.(*godo.ErrorResponse)
+			if !ok || errResp.Response == nil || errResp.Response.StatusCode != http.StatusNotFound {
+				return err
+			}
+			notFound = append(notFound, slug)
+			continue
+		}
+		sizes = append(sizes, size)
+	}
+
+	if len(sizes) > 0 {
+		var displayer displayers.Displayable = sizes
+		if totals {
+			displayer = displayers.AppInstanceSizeTotals{Sizes: sizes}
+		}
+		if err := c.Display(displayer); err != nil {
+			return err
+		}
+	}
+
+	if len(notFound) > 0 {
+		return fmt.Errorf("app instance size(s) not found: %s", strings.Join(notFound, ", "))
+	}
+	return nil
+}
+
+func appsEnv() *Command {
+	cmd := &Command{
+		Command: &cobra.Command{
+			Use:   "env",
+			Short: "Display commands for working with app environment variables",
+			Long:  "The subcommands of `doctl app env` manage the environment variables of an app or of one of its components.",
+		},
+	}
+
+	listCmd := CmdBuilder(cmd, RunAppsEnvList, "list <app id>", "List environment variables", `Use this command to list the environment variables of an app.
+
+By default the app-level environment variables are listed; use --`+doctl.ArgAppComponent+` to list those of a specific component. The values of secrets are masked unless --`+doctl.ArgAppEnvShowSecret+` is passed.`, Writer, aliasOpt("ls"), displayerType(&displayers.AppEnvs{}))
+	AddStringFlag(listCmd, doctl.ArgAppComponent, "", "", "The name of the component to list environment variables for. Defaults to the app-level environment variables.")
+	AddBoolFlag(listCmd, doctl.ArgAppEnvShowSecret, "", false, "Show the values of secret environment variables")
+
+	setCmd := CmdBuilder(cmd, RunAppsEnvSet, "set <app id> <key>=<value>...", "Set environment variables", `Use this command to set one or more environment variables of an app and submit the updated app spec.
+
+Existing variables keep their scope and type; only their value is changed. New variables are created with the scope passed with --`+doctl.ArgAppEnvScope+`.`, Writer, displayerType(&displayers.AppEnvs{}))
+	AddStringFlag(setCmd, doctl.ArgAppComponent, "", "", "The name of the component to set environment variables on. Defaults to the app-level environment variables.")
+	AddStringFlag(setCmd, doctl.ArgAppEnvScope, "", string(godo.AppVariableScope_RunAndBuildTime), "The scope of new environment variables: RUN_TIME, BUILD_TIME, or RUN_AND_BUILD_TIME")
+	AddBoolFlag(setCmd, doctl.ArgAppEnvSecret, "", false, "Store the environment variables as encrypted secrets")
+
+	unsetCmd := CmdBuilder(cmd, RunAppsEnvUnset, "unset <app id> <key>...", "Unset environment variables", `Use this command to remove one or more environment variables from an app and submit the updated app spec.`, Writer, displayerType(&displayers.AppEnvs{}))
+	AddStringFlag(unsetCmd, doctl.ArgAppComponent, "", "", "The name of the component to remove environment variables from. Defaults to the app-level environment variables.")
+
+	return cmd
+}
+
+// RunAppsEnvList lists the environment variables of an app or component.
+func RunAppsEnvList(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	component, err := c.Doit.GetString(c.NS, doctl.ArgAppComponent)
+	if err != nil {
+		return err
+	}
+
+	showSecret, err := c.Doit.GetBool(c.NS, doctl.ArgAppEnvShowSecret)
+	if err != nil {
+		return err
+	}
+
+	app, err := c.Apps().Get(appID)
+	if err != nil {
+		return err
+	}
+
+	envs, err := appEnvs(app.Spec, component)
+	if err != nil {
+		return err
+	}
+
+	return c.Display(displayers.AppEnvs(maskAppEnvs(*envs, showSecret)))
+}
+
+// RunAppsEnvSet sets environment variables on an app or component.
+func RunAppsEnvSet(c *CmdConfig) error {
+	if len(c.Args) < 2 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+
+	component, err := c.Doit.GetString(c.NS, doctl.ArgAppComponent)
+	if err != nil {
+		return err
+	}
+
+	scopeStr, err := c.Doit.GetString(c.NS, doctl.ArgAppEnvScope)
+	if err != nil {
+		return err
+	}
+	scope := godo.AppVariableScope(strings.ToUpper(scopeStr))
+	switch scope {
+	case godo.AppVariableScope_RunTime, godo.AppVariableScope_BuildTime, godo.AppVariableScope_RunAndBuildTime:
+	case "":
+		scope = godo.AppVariableScope_RunAndBuildTime
 	default:
-		return fmt.Errorf("Invalid log type %s", logTypeStr)
+		return fmt.Errorf("invalid scope %q, must be one of: RUN_TIME, BUILD_TIME, RUN_AND_BUILD_TIME", scopeStr)
 	}
-	logFollow, err := c.Doit.GetBool(c.NS, doctl.ArgAppLogFollow)
+
+	secret, err := c.Doit.GetBool(c.NS, doctl.ArgAppEnvSecret)
+	if err != nil {
+		return err
+	}
+	varType := godo.AppVariableType_General
+	if secret {
+		varType = godo.AppVariableType_Secret
+	}
+
+	vars := make([][2]string, 0, len(c.Args)-1)
+	for _, arg := range c.Args[1:] {
+		kv := strings.SplitN(arg, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return fmt.Errorf("invalid environment variable %q, must be in the form KEY=VALUE", arg)
+		}
+		vars = append(vars, [2]string{kv[0], kv[1]})
+	}
+
+	app, err := c.Apps().Get(appID)
 	if err != nil {
 		return err
 	}
 
-	logs, err := c.Apps().GetLogs(appID, deploymentID, component, logType, logFollow)
+	envs, err := appEnvs(app.Spec, component)
 	if err != nil {
 		return err
 	}
 
-	if logs.LiveURL != "" {
-		url, err := url.Parse(logs.LiveURL)
-		if err != nil {
-			return err
-		}
-
-		schemaFunc := func(message []byte) (io.Reader, error) {
-			data := struct {
-				Data string `json:"data"`
-			}{}
-			err = json.Unmarshal(message, &data)
-			if err != nil {
-				return nil, err
+	for _, kv := range vars {
+		if env := findAppEnv(*envs, kv[0]); env != nil {
+			env.Value = kv[1]
+			if secret {
+				env.Type = godo.AppVariableType_Secret
 			}
-			r := strings.NewReader(data.Data)
-
-			return r, nil
-		}
-
-		token := url.Query().Get("token")
-		switch url.Scheme {
-		case "http":
-			url.Scheme = "ws"
-		default:
-			url.Scheme = "wss"
-		}
-
-		listener := c.Doit.Listen(url, token, schemaFunc, c.Out)
-		err = listener.Start()
-		if err != nil {
-			return err
-		}
-	} else if len(logs.HistoricURLs) > 0 {
-		resp, err := http.Get(logs.HistoricURLs[0])
-		if err != nil {
-			return err
+			continue
 		}
-		defer resp.Body.Close()
-
-		io.Copy(c.Out, resp.Body)
-	} else {
-		warn("No logs found for app component")
+		*envs = append(*envs, &godo.AppVariableDefinition{
+			Key:   kv[0],
+			Value: kv[1],
+			Scope: scope,
+			Type:  varType,
+		})
 	}
 
-	return nil
+	return updateAppEnvs(c, appID, app.Spec, component)
 }
 
-// RunAppsPropose proposes an app spec
-func RunAppsPropose(c *CmdConfig) error {
-	appID, err := c.Doit.GetString(c.NS, doctl.ArgApp)
+// RunAppsEnvUnset removes environment variables from an app or component.
+func RunAppsEnvUnset(c *CmdConfig) error {
+	if len(c.Args) < 2 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
 	if err != nil {
 		return err
 	}
+	keys := c.Args[1:]
 
-	specPath, err := c.Doit.GetString(c.NS, doctl.ArgAppSpec)
+	component, err := c.Doit.GetString(c.NS, doctl.ArgAppComponent)
 	if err != nil {
 		return err
 	}
 
-	appSpec, err := readAppSpec(os.Stdin, specPath)
+	app, err := c.Apps().Get(appID)
 	if err != nil {
 		return err
 	}
 
-	res, err := c.Apps().Propose(&godo.AppProposeRequest{
-		Spec:  appSpec,
-		AppID: appID,
-	})
-
+	envs, err := appEnvs(app.Spec, component)
 	if err != nil {
-		// most likely an invalid app spec. The error message would start with "error validating app spec"
 		return err
 	}
 
-	return c.Display(displayers.AppProposeResponse{Res: res})
-}
+	for _, key := range keys {
+		if findAppEnv(*envs, key) == nil {
+			return fmt.Errorf("environment variable %s not found", key)
+		}
+	}
 
-func readAppSpec(stdin io.Reader, path string) (*godo.AppSpec, error) {
-	var spec io.Reader
-	if path == "-" {
-		spec = stdin
-	} else {
-		specFile, err := os.Open(path) // guardrails-disable-line
-		if err != nil {
-			if os.IsNotExist(err) {
-				return nil, fmt.Errorf("opening app spec: %s does not exist", path)
+	remaining := make([]*godo.AppVariableDefinition, 0, len(*envs))
+	for _, env := range *envs {
+		unset := false
+		for _, key := range keys {
+			if env.Key == key {
+				unset = true
+				break
 			}
-			return nil, fmt.Errorf("opening app spec: %w", err)
 		}
-		defer specFile.Close()
-		spec = specFile
+		if !unset {
+			remaining = append(remaining, env)
+		}
 	}
+	*envs = remaining
 
-	byt, err := ioutil.ReadAll(spec)
-	if err != nil {
-		return nil, fmt.Errorf("reading app spec: %w", err)
-	}
+	return updateAppEnvs(c, appID, app.Spec, component)
+}
 
-	s, err := parseAppSpec(byt)
+// updateAppEnvs submits spec as the app's new spec and displays the resulting
+// environment variables of component.
+func updateAppEnvs(c *CmdConfig, appID string, spec *godo.AppSpec, component string) error {
+	app, err := c.Apps().Update(appID, &godo.AppUpdateRequest{Spec: spec})
 	if err != nil {
-		return nil, fmt.Errorf("parsing app spec: %w", err)
+		return err
 	}
 
-	return s, nil
-}
-
-func parseAppSpec(spec []byte) (*godo.AppSpec, error) {
-	jsonSpec, err := yaml.YAMLToJSON(spec)
+	envs, err := appEnvs(app.Spec, component)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	dec := json.NewDecoder(bytes.NewReader(jsonSpec))
-	dec.DisallowUnknownFields()
-
-	var appSpec godo.AppSpec
-	if err := dec.Decode(&appSpec); err != nil {
-		return nil, err
-	}
+	notice("Environment variables updated")
 
-	return &appSpec, nil
+	return c.Display(displayers.AppEnvs(maskAppEnvs(*envs, false)))
 }
 
-func appsSpec() *Command {
+func appsDomains() *Command {
 	cmd := &Command{
 		Command: &cobra.Command{
-			Use:   "spec",
-			Short: "Display commands for working with app specs",
-			Long:  "The subcommands of `doctl app spec` manage your app specs.",
+			Use:     "domains",
+			Aliases: []string{"domain"},
+			Short:   "Display commands for working with app domains",
+			Long:    "The subcommands of `doctl app domains` manage the custom domains of an app.",
 		},
 	}
 
-	getCmd := CmdBuilder(cmd, RunAppsSpecGet, "get <app id>", "Retrieve an application's spec", `Use this command to retrieve the latest spec of an app.
+	CmdBuilder(cmd, RunAppsDomainsList, "list <app id>", "List app domains", `Use this command to list the custom domains of an app along with the status of their certificates.`, Writer, aliasOpt("ls"), displayerType(&displayers.AppDomains{}))
 
-Optionally, pass a deployment ID to get the spec of that specific deployment.`, Writer)
-	AddStringFlag(getCmd, doctl.ArgAppDeployment, "", "", "optional: a deployment ID")
-	AddStringFlag(getCmd, doctl.ArgFormat, "", "yaml", `the format to output the spec in; either "yaml" or "json"`)
+	addCmd := CmdBuilder(cmd, RunAppsDomainsAdd, "add <app id>", "Add a domain to an app", `Use this command to add a custom domain to an app and submit the updated app spec.
 
-	validateCmd := CmdBuilder(cmd, RunAppsSpecValidate, "validate <spec file>", "Validate an application spec", `Use this command to check whether a given app spec (YAML or JSON) is valid.
+An app can only have one PRIMARY domain; other domains are ALIAS domains.`, Writer, displayerType(&displayers.AppDomains{}))
+	AddStringFlag(addCmd, doctl.ArgAppDomain, "", "", "The domain name to add, e.g. www.example.com", requiredOpt())
+	AddStringFlag(addCmd, doctl.ArgAppDomainType, "", "", "The type of the domain: PRIMARY or ALIAS")
+	AddBoolFlag(addCmd, doctl.ArgAppDomainWildcard, "", false, "Whether the domain is a wildcard domain, e.g. *.example.com")
+	AddStringFlag(addCmd, doctl.ArgAppDomainZone, "", "", "The DigitalOcean DNS zone of the domain, e.g. example.com, to let App Platform manage its records")
 
-You may pass - as the filename to read from stdin.`, Writer)
-	AddBoolFlag(validateCmd, doctl.ArgSchemaOnly, "", false, "Only validate the spec schema and not the correctness of the spec.")
+	CmdBuilder(cmd, RunAppsDomainsRemove, "remove <app id> <domain>...", "Remove domains from an app", `Use this command to remove one or more custom domains from an app and submit the updated app spec.`, Writer, aliasOpt("rm"), displayerType(&displayers.AppDomains{}))
 
 	return cmd
 }
 
-// RunAppsSpecGet gets the spec for an app
-func RunAppsSpecGet(c *CmdConfig) error {
+// RunAppsDomainsList lists the domains of an app.
+func RunAppsDomainsList(c *CmdConfig) error {
 	if len(c.Args) < 1 {
 		return doctl.NewMissingArgsErr(c.NS)
 	}
 
-	appID := c.Args[0]
-	deploymentID, err := c.Doit.GetString(c.NS, doctl.ArgAppDeployment)
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
 	if err != nil {
 		return err
 	}
 
-	format, err := c.Doit.GetString(c.NS, doctl.ArgFormat)
+	app, err := c.Apps().Get(appID)
 	if err != nil {
 		return err
 	}
 
-	var spec *godo.AppSpec
-	if deploymentID == "" {
-		app, err := c.Apps().Get(appID)
-		if err != nil {
-			return err
-		}
-		spec = app.Spec
-	} else {
-		deployment, err := c.Apps().GetDeployment(appID, deploymentID)
-		if err != nil {
-			return err
-		}
-		spec = deployment.Spec
-	}
-
-	switch format {
-	case "json":
-		e := json.NewEncoder(c.Out)
-		e.SetIndent("", "  ")
-		return e.Encode(spec)
-	case "yaml":
-		yaml, err := yaml.Marshal(spec)
-		if err != nil {
-			return fmt.Errorf("marshaling the spec as yaml: %v", err)
-		}
-		_, err = c.Out.Write(yaml)
-		return err
-	default:
-		return fmt.Errorf("invalid spec format %q, must be one of: json, yaml", format)
-	}
+	return c.Display(appDomains(app))
 }
 
-// RunAppsSpecValidate validates an app spec file
-func RunAppsSpecValidate(c *CmdConfig) error {
+// RunAppsDomainsAdd adds a domain to an app.
+func RunAppsDomainsAdd(c *CmdConfig) error {
 	if len(c.Args) < 1 {
 		return doctl.NewMissingArgsErr(c.NS)
 	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
 
-	specPath := c.Args[0]
-	appSpec, err := readAppSpec(os.Stdin, specPath)
+	name, err := c.Doit.GetString(c.NS, doctl.ArgAppDomain)
 	if err != nil {
 		return err
 	}
+	if name == "" {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
 
-	schemaOnly, err := c.Doit.GetBool(c.NS, doctl.ArgSchemaOnly)
+	typeStr, err := c.Doit.GetString(c.NS, doctl.ArgAppDomainType)
 	if err != nil {
 		return err
 	}
+	domainType := godo.AppDomainSpecType(strings.ToUpper(typeStr))
+	switch domainType {
+	case "", godo.AppDomainSpecType_Primary, godo.AppDomainSpecType_Alias:
+	default:
+		return fmt.Errorf("invalid domain type %q, must be one of: PRIMARY, ALIAS", typeStr)
+	}
 
-	if schemaOnly {
-		ymlSpec, err := yaml.Marshal(appSpec)
-		if err != nil {
-			return fmt.Errorf("marshaling the spec as yaml: %v", err)
-		}
-		_, err = c.Out.Write(ymlSpec)
+	wildcard, err := c.Doit.GetBool(c.NS, doctl.ArgAppDomainWildcard)
+	if err != nil {
 		return err
 	}
 
-	res, err := c.Apps().Propose(&godo.AppProposeRequest{
-		Spec: appSpec,
-	})
+	zone, err := c.Doit.GetString(c.NS, doctl.ArgAppDomainZone)
 	if err != nil {
-		// most likely an invalid app spec. The error message would start with "error validating app spec"
 		return err
 	}
 
-	ymlSpec, err := yaml.Marshal(res.Spec)
+	app, err := c.Apps().Get(appID)
 	if err != nil {
-		return fmt.Errorf("marshaling the spec as yaml: %v", err)
+		return err
 	}
-	_, err = c.Out.Write(ymlSpec)
-	return err
+	if app.Spec == nil {
+		return errors.New("app has no spec")
+	}
+
+	if findAppDomain(app.Spec.Domains, name) != nil {
+		return fmt.Errorf("domain %s already exists", name)
+	}
+	for _, d := range app.Spec.Domains {
+		if domainType == godo.AppDomainSpecType_Primary && d.Type == godo.AppDomainSpecType_Primary {
+			return fmt.Errorf("app already has a primary domain %s", d.Domain)
+		}
+	}
+
+	app.Spec.Domains = append(app.Spec.Domains, &godo.AppDomainSpec{
+		Domain:   name,
+		Type:     domainType,
+		Wildcard: wildcard,
+		Zone:     zone,
+	})
+
+	return updateAppDomains(c, appID, app.Spec)
 }
 
-// RunAppsListRegions lists all app platform regions.
-func RunAppsListRegions(c *CmdConfig) error {
-	regions, err := c.Apps().ListRegions()
+// RunAppsDomainsRemove removes domains from an app.
+func RunAppsDomainsRemove(c *CmdConfig) error {
+	if len(c.Args) < 2 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
 	if err != nil {
 		return err
 	}
+	names := c.Args[1:]
 
-	return c.Display(displayers.AppRegions(regions))
-}
+	app, err := c.Apps().Get(appID)
+	if err != nil {
+		return err
+	}
+	if app.Spec == nil {
+		return errors.New("app has no spec")
+	}
 
-func appsTier() *Command {
-	cmd := &Command{
-		Command: &cobra.Command{
-			Use:   "tier",
-			Short: "Display commands for working with app tiers",
-			Long:  "The subcommands of `doctl app tier` retrieve information about app tiers.",
-		},
+	remove := make(map[string]bool, len(names))
+	for _, name := range names {
+		if findAppDomain(app.Spec.Domains, name) == nil {
+			return fmt.Errorf("domain %s not found", name)
+		}
+		remove[strings.ToLower(name)] = true
 	}
 
-	CmdBuilder(cmd, RunAppsTierList, "list", "List all app tiers", `Use this command to list all the available app tiers.`, Writer)
-	CmdBuilder(cmd, RunAppsTierGet, "get <tier slug>", "Retrieve an app tier", `Use this command to retrieve information about a specific app tier.`, Writer)
+	remaining := make([]*godo.AppDomainSpec, 0, len(app.Spec.Domains))
+	for _, d := range app.Spec.Domains {
+		if !remove[strings.ToLower(d.Domain)] {
+			remaining = append(remaining, d)
+		}
+	}
+	app.Spec.Domains = remaining
 
-	cmd.AddCommand(appsTierInstanceSize())
+	return updateAppDomains(c, appID, app.Spec)
+}
 
-	return cmd
+// findAppDomain returns the domain in domains named name, or nil.
+func findAppDomain(domains []*godo.AppDomainSpec, name string) *godo.AppDomainSpec {
+	for _, d := range domains {
+		if strings.EqualFold(d.Domain, name) {
+			return d
+		}
+	}
+	return nil
 }
 
-// RunAppsTierList lists all app tiers.
-func RunAppsTierList(c *CmdConfig) error {
-	tiers, err := c.Apps().ListTiers()
+// updateAppDomains submits spec as the app's new spec and displays the
+// resulting domains.
+func updateAppDomains(c *CmdConfig, appID string, spec *godo.AppSpec) error {
+	app, err := c.Apps().Update(appID, &godo.AppUpdateRequest{Spec: spec})
 	if err != nil {
 		return err
 	}
 
-	return c.Display(displayers.AppTiers(tiers))
+	notice("Domains updated")
+
+	return c.Display(appDomains(app))
 }
 
-// RunAppsTierGet gets an app tier.
-func RunAppsTierGet(c *CmdConfig) error {
-	if len(c.Args) < 1 {
-		return doctl.NewMissingArgsErr(c.NS)
+// appDomains returns the domains in the spec of app, along with their status
+// where the app reports it.
+func appDomains(app *godo.App) displayers.AppDomains {
+	if app.Spec == nil {
+		return displayers.AppDomains{}
+	}
+
+	domains := make(displayers.AppDomains, 0, len(app.Spec.Domains))
+	for _, spec := range app.Spec.Domains {
+		domain := &godo.AppDomain{Spec: spec}
+		for _, d := range app.Domains {
+			if d.Spec != nil && strings.EqualFold(d.Spec.Domain, spec.Domain) {
+				domain = d
+				break
+			}
+		}
+		domains = append(domains, domain)
 	}
+	return domains
+}
 
-	slug := c.Args[0]
+// appEnvs returns a pointer to the environment variables of component in
+// spec, or to the app-level environment variables if component is empty.
+func appEnvs(spec *godo.AppSpec, component string) (*[]*godo.AppVariableDefinition, error) {
+	if spec == nil {
+		return nil, errors.New("app has no spec")
+	}
+	if component == "" {
+		return &spec.Envs, nil
+	}
 
-	tier, err := c.Apps().GetTier(slug)
-	if err != nil {
-		return err
+	for _, s := range spec.Services {
+		if s.Name == component {
+			return &s.Envs, nil
+		}
+	}
+	for _, s := range spec.StaticSites {
+		if s.Name == component {
+			return &s.Envs, nil
+		}
+	}
+	for _, w := range spec.Workers {
+		if w.Name == component {
+			return &w.Envs, nil
+		}
+	}
+	for _, j := range spec.Jobs {
+		if j.Name == component {
+			return &j.Envs, nil
+		}
+	}
+	return nil, fmt.Errorf("component %s not found in app spec", component)
+}
+
+func findAppEnv(envs []*godo.AppVariableDefinition, key string) *godo.AppVariableDefinition {
+	for _, env := range envs {
+		if env.Key == key {
+			return env
+		}
 	}
+	return nil
+}
 
-	return c.Display(displayers.AppTiers([]*godo.AppTier{tier}))
+// maskAppEnvs returns a copy of envs with the values of secrets masked, unless
+// showSecret is true.
+func maskAppEnvs(envs []*godo.AppVariableDefinition, showSecret bool) []*godo.AppVariableDefinition {
+	masked := make([]*godo.AppVariableDefinition, 0, len(envs))
+	for _, env := range envs {
+		if env.Type == godo.AppVariableType_Secret && !showSecret {
+			m := *env
+			m.Value = "********"
+			env = &m
+		}
+		masked = append(masked, env)
+	}
+	return masked
 }
 
-func appsTierInstanceSize() *Command {
+func appsAlert() *Command {
 	cmd := &Command{
 		Command: &cobra.Command{
-			Use:   "instance-size",
-			Short: "Display commands for working with app instance sizes",
-			Long:  "The subcommands of `doctl app tier instance-size` retrieve information about app instance sizes.",
+			Use:     "alert",
+			Aliases: []string{"alerts"},
+			Short:   "Display commands for working with app alerts",
+			Long:    "The subcommands of `doctl app alert` list the alerts of an app and manage where they are sent.",
 		},
 	}
 
-	CmdBuilder(cmd, RunAppsTierInstanceSizeList, "list", "List all app instance sizes", `Use this command to list all the available app instance sizes.`, Writer)
-	CmdBuilder(cmd, RunAppsTierInstanceSizeGet, "get <instance size slug>", "Retrieve an app instance size", `Use this command to retrieve information about a specific app instance size.`, Writer)
+	CmdBuilder(cmd, RunAppsAlertList, "list <app id>", "List app alerts", `Use this command to list the alerts of an app and its components, along with the email addresses and Slack webhooks they are sent to.
+
+Alerts are added to an app through the alerts section of its app spec.`, Writer, aliasOpt("ls"), displayerType(&displayers.AppAlerts{}))
+
+	updateCmd := CmdBuilder(cmd, RunAppsAlertUpdate, "update <app id> <alert id>", "Update the destinations of an app alert", `Use this command to set the email addresses and Slack webhooks an app alert is sent to. Use `+"`"+`doctl apps alert list`+"`"+` to find the ID of the alert.
+
+Each flag replaces the alert's current destinations of that kind; destinations of a kind whose flag isn't passed are kept. Pass an empty value, e.g. --`+doctl.ArgAppAlertEmails+` "", to remove all destinations of a kind.`, Writer, displayerType(&displayers.AppAlerts{}))
+	AddStringSliceFlag(updateCmd, doctl.ArgAppAlertEmails, "", nil, "The email addresses to send the alert to. Repeat or comma-separate to set multiple addresses.")
+	AddStringSliceFlag(updateCmd, doctl.ArgAppAlertSlackWebhooks, "", nil, "The Slack incoming webhook URLs to send the alert to. Repeat or comma-separate to set multiple webhooks.")
 
 	return cmd
 }
 
-// RunAppsTierInstanceSizeList lists all app tiers.
-func RunAppsTierInstanceSizeList(c *CmdConfig) error {
-	instanceSizes, err := c.Apps().ListInstanceSizes()
+// RunAppsAlertList lists the alerts of an app.
+func RunAppsAlertList(c *CmdConfig) error {
+	if len(c.Args) < 1 {
+		return doctl.NewMissingArgsErr(c.NS)
+	}
+
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
 	if err != nil {
 		return err
 	}
 
-	return c.Display(displayers.AppInstanceSizes(instanceSizes))
+	alerts, err := c.Apps().ListAlerts(appID)
+	if err != nil {
+		return err
+	}
+	return c.Display(displayers.AppAlerts(alerts))
 }
 
-// RunAppsTierInstanceSizeGet gets an app tier.
-func RunAppsTierInstanceSizeGet(c *CmdConfig) error {
-	if len(c.Args) < 1 {
+// RunAppsAlertUpdate replaces the email or Slack destinations of an app
+// alert, keeping those of the kind that isn't given.
+func RunAppsAlertUpdate(c *CmdConfig) error {
+	if len(c.Args) < 2 {
 		return doctl.NewMissingArgsErr(c.NS)
 	}
 
-	slug := c.Args[0]
+	appID, err := resolveAppID(c.Apps(), c.Args[0])
+	if err != nil {
+		return err
+	}
+	alertID := c.Args[1]
+
+	setEmails, setWebhooks := c.Doit.IsSet(doctl.ArgAppAlertEmails), c.Doit.IsSet(doctl.ArgAppAlertSlackWebhooks)
+	if !setEmails && !setWebhooks {
+		return fmt.Errorf("pass --%s or --%s to update the alert", doctl.ArgAppAlertEmails, doctl.ArgAppAlertSlackWebhooks)
+	}
+
+	alerts, err := c.Apps().ListAlerts(appID)
+	if err != nil {
+		return err
+	}
+	var alert *do.AppAlert
+	for _, a := range alerts {
+		if a.ID == alertID {
+			alert = a
+			break
+		}
+	}
+	if alert == nil {
+		return fmt.Errorf("app %s has no alert %s", appID, alertID)
+	}
+
+	destinations := &do.AppAlertDestinations{
+		Emails:        alert.Emails,
+		SlackWebhooks: alert.SlackWebhooks,
+	}
+	if setEmails {
+		emails, err := c.Doit.GetStringSlice(c.NS, doctl.ArgAppAlertEmails)
+		if err != nil {
+			return err
+		}
+		destinations.Emails = nonEmptyStrings(emails)
+	}
+	if setWebhooks {
+		urls, err := c.Doit.GetStringSlice(c.NS, doctl.ArgAppAlertSlackWebhooks)
+		if err != nil {
+			return err
+		}
+		destinations.SlackWebhooks = nil
+		for _, u := range nonEmptyStrings(urls) {
+			if !strings.HasPrefix(u, "https://") {
+				return fmt.Errorf("invalid Slack webhook URL %q, must start with https://", u)
+			}
+			destinations.SlackWebhooks = append(destinations.SlackWebhooks, &do.AppAlertSlackWebhook{URL: u})
+		}
+	}
 
-	instanceSize, err := c.Apps().GetInstanceSize(slug)
+	updated, err := c.Apps().UpdateAlertDestinations(appID, alertID, destinations)
 	if err != nil {
 		return err
 	}
+	return c.Display(displayers.AppAlerts{updated})
+}
 
-	return c.Display(displayers.AppInstanceSizes([]*godo.AppInstanceSize{instanceSize}))
+// nonEmptyStrings returns the non-blank values of ss, trimmed of spaces.
+func nonEmptyStrings(ss []string) []string {
+	out := make([]string, 0, len(ss))
+	for _, s := range ss {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
 }
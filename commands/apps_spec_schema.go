@@ -0,0 +1,202 @@
+/*
+Copyright 2018 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	_ "embed" // for the embedded app spec schema
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+	yamlv3 "gopkg.in/yaml.v3"
+	"sigs.k8s.io/yaml"
+)
+
+// appSpecSchema is a JSON schema describing the app spec. It is used to
+// validate specs offline; the Propose API remains the authoritative check.
+//
+//go:embed apps_spec.schema.json
+var appSpecSchema string
+
+// appSpecSchemaError is a single schema violation found in an app spec.
+type appSpecSchemaError struct {
+	line    int
+	path    string
+	message string
+}
+
+// validateAppSpecSchema validates spec against the embedded app spec schema
+// and returns every violation found, ordered by line. name is used to prefix
+// each violation in the returned error.
+func validateAppSpecSchema(name string, spec []byte) error {
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("apps_spec.schema.json", strings.NewReader(appSpecSchema)); err != nil {
+		return fmt.Errorf("loading app spec schema: %w", err)
+	}
+	schema, err := compiler.Compile("apps_spec.schema.json")
+	if err != nil {
+		return fmt.Errorf("loading app spec schema: %w", err)
+	}
+
+	jsonSpec, err := yaml.YAMLToJSON(spec)
+	if err != nil {
+		return fmt.Errorf("parsing app spec: %w", &appSpecError{kind: ErrAppSpecSyntax, err: err})
+	}
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(jsonSpec))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("parsing app spec: %w", err)
+	}
+
+	err = schema.Validate(doc)
+	if err == nil {
+		return nil
+	}
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return fmt.Errorf("validating app spec: %w", err)
+	}
+
+	var root yamlv3.Node
+	if err := yamlv3.Unmarshal(spec, &root); err != nil {
+		return fmt.Errorf("parsing app spec: %w", err)
+	}
+
+	errs := appSpecSchemaErrors(&root, verr)
+	sort.Slice(errs, func(i, j int) bool {
+		if errs[i].line != errs[j].line {
+			return errs[i].line < errs[j].line
+		}
+		if errs[i].path != errs[j].path {
+			return errs[i].path < errs[j].path
+		}
+		return errs[i].message < errs[j].message
+	})
+
+	lines := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msg := e.message
+		if e.path != "" {
+			msg = e.path + ": " + msg
+		}
+		lines = append(lines, fmt.Sprintf("%s:%d: %s", name, e.line, msg))
+	}
+	return &appSpecError{kind: ErrAppSpecSchema, err: errors.New(strings.Join(lines, "\n"))}
+}
+
+// appSpecSchemaErrors flattens verr into its leaf violations, resolving the
+// line of each one from the YAML document root.
+func appSpecSchemaErrors(root *yamlv3.Node, verr *jsonschema.ValidationError) []appSpecSchemaError {
+	if len(verr.Causes) > 0 {
+		var errs []appSpecSchemaError
+		for _, cause := range verr.Causes {
+			errs = append(errs, appSpecSchemaErrors(root, cause)...)
+		}
+		return errs
+	}
+
+	segments := jsonPointerSegments(verr.InstanceLocation)
+	node := yamlNodeAt(root, segments)
+	path := appSpecPath(segments)
+
+	// Report each unknown field separately, at the line of its key.
+	if strings.HasSuffix(verr.KeywordLocation, "/additionalProperties") && node.Kind == yamlv3.MappingNode {
+		var errs []appSpecSchemaError
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if strings.Contains(verr.Message, "'"+key.Value+"'") {
+				errs = append(errs, appSpecSchemaError{
+					line:    key.Line,
+					message: fmt.Sprintf("unknown field %q", key.Value),
+				})
+			}
+		}
+		if len(errs) > 0 {
+			return errs
+		}
+	}
+
+	return []appSpecSchemaError{{
+		line:    node.Line,
+		path:    path,
+		message: verr.Message,
+	}}
+}
+
+// jsonPointerSegments splits a JSON pointer into its unescaped segments.
+func jsonPointerSegments(pointer string) []string {
+	if pointer == "" || pointer == "/" {
+		return nil
+	}
+	segments := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, s := range segments {
+		s = strings.ReplaceAll(s, "~1", "/")
+		segments[i] = strings.ReplaceAll(s, "~0", "~")
+	}
+	return segments
+}
+
+// yamlNodeAt returns the node at the given path below root, or the deepest
+// node found along the way.
+func yamlNodeAt(root *yamlv3.Node, segments []string) *yamlv3.Node {
+	node := root
+	if node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	for _, s := range segments {
+		var next *yamlv3.Node
+		switch node.Kind {
+		case yamlv3.MappingNode:
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == s {
+					next = node.Content[i+1]
+					break
+				}
+			}
+		case yamlv3.SequenceNode:
+			if i, err := strconv.Atoi(s); err == nil && i >= 0 && i < len(node.Content) {
+				next = node.Content[i]
+			}
+		}
+		if next == nil {
+			break
+		}
+		node = next
+	}
+	return node
+}
+
+// appSpecPath formats path segments as a dotted field path, e.g.
+// services[0].instance_count.
+func appSpecPath(segments []string) string {
+	var b strings.Builder
+	for _, s := range segments {
+		if _, err := strconv.Atoi(s); err == nil {
+			fmt.Fprintf(&b, "[%s]", s)
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(s)
+	}
+	return b.String()
+}
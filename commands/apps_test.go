@@ -2,18 +2,32 @@ package commands
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/digitalocean/doctl"
+	"github.com/digitalocean/doctl/commands/displayers"
+	"github.com/digitalocean/doctl/do"
 	"github.com/digitalocean/doctl/pkg/listen"
 	"github.com/digitalocean/godo"
+	"github.com/fatih/color"
+	"github.com/golang/mock/gomock"
 	"github.com/google/uuid"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -25,16 +39,27 @@ func TestAppsCommand(t *testing.T) {
 		"create",
 		"get",
 		"list",
+		"list-bandwidth",
+		"get-metrics",
 		"update",
 		"delete",
 		"create-deployment",
 		"get-deployment",
 		"list-deployments",
+		"events",
+		"cancel-deployment",
+		"restart",
+		"rollback",
 		"list-regions",
 		"logs",
+		"console",
+		"open",
 		"propose",
 		"spec",
 		"tier",
+		"env",
+		"domains",
+		"alert",
 	)
 }
 
@@ -104,73 +129,170 @@ func TestRunAppsCreate(t *testing.T) {
 	})
 }
 
-func TestRunAppsGet(t *testing.T) {
-	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		app := &godo.App{
-			ID:        uuid.New().String(),
-			Spec:      &testAppSpec,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
+func TestRunAppsCreateUpsert(t *testing.T) {
+	other := &godo.App{ID: uuid.New().String(), Spec: &godo.AppSpec{Name: "other"}}
+	existing := &godo.App{ID: uuid.New().String(), Spec: &testAppSpec}
+	spec, err := json.Marshal(&testAppSpec)
+	require.NoError(t, err)
 
-		tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+	t.Run("creates", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, spec)
+			tm.apps.EXPECT().List().Times(1).Return([]*godo.App{other}, nil)
+			tm.apps.EXPECT().Create(&godo.AppCreateRequest{Spec: &testAppSpec}).Times(1).Return(existing, nil)
 
-		config.Args = append(config.Args, app.ID)
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgAppUpsert, true)
 
-		err := RunAppsGet(config)
-		require.NoError(t, err)
+			err := RunAppsCreate(config)
+			require.NoError(t, err)
+		})
+	})
+
+	t.Run("updates", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, spec)
+			tm.apps.EXPECT().List().Times(1).Return([]*godo.App{other, existing}, nil)
+			tm.apps.EXPECT().Update(existing.ID, &godo.AppUpdateRequest{Spec: &testAppSpec}).Times(1).Return(existing, nil)
+
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgAppUpsert, true)
+
+			err := RunAppsCreate(config)
+			require.NoError(t, err)
+		})
+	})
+
+	t.Run("updates and waits for the new deployment", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, spec)
+			previous := &godo.Deployment{ID: uuid.New().String(), Phase: godo.DeploymentPhase_Active}
+			deployment := &godo.Deployment{ID: uuid.New().String(), Phase: godo.DeploymentPhase_Active}
+			updated := &godo.App{ID: existing.ID, Spec: &testAppSpec, ActiveDeployment: previous}
+			deployed := &godo.App{ID: existing.ID, Spec: &testAppSpec, ActiveDeployment: deployment}
+
+			gomock.InOrder(
+				tm.apps.EXPECT().List().Times(1).Return([]*godo.App{other, existing}, nil),
+				tm.apps.EXPECT().Update(existing.ID, &godo.AppUpdateRequest{Spec: &testAppSpec}).Times(1).Return(updated, nil),
+				// The previous deployment is still active until the new one starts.
+				tm.apps.EXPECT().Get(existing.ID).Times(1).Return(updated, nil),
+				tm.apps.EXPECT().Get(existing.ID).Times(1).Return(&godo.App{ID: existing.ID, ActiveDeployment: previous, InProgressDeployment: deployment}, nil),
+				tm.apps.EXPECT().GetDeployment(existing.ID, deployment.ID).Times(1).Return(deployment, nil),
+				tm.apps.EXPECT().Get(existing.ID).Times(1).Return(deployed, nil),
+			)
+
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgAppUpsert, true)
+			config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+			config.Doit.Set(config.NS, doctl.ArgPollInterval, time.Millisecond)
+
+			err := RunAppsCreate(config)
+			require.NoError(t, err)
+		})
+	})
+
+	t.Run("ambiguous", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, spec)
+			duplicate := &godo.App{ID: uuid.New().String(), Spec: &testAppSpec}
+			tm.apps.EXPECT().List().Times(1).Return([]*godo.App{existing, duplicate}, nil)
+
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgAppUpsert, true)
+
+			err := RunAppsCreate(config)
+			require.EqualError(t, err, fmt.Sprintf(`2 apps are named "test": %s, %s`, existing.ID, duplicate.ID))
+		})
 	})
 }
 
-func TestRunAppsList(t *testing.T) {
+func TestRunAppsCreateMultipleDocuments(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		apps := []*godo.App{{
-			ID:        uuid.New().String(),
-			Spec:      &testAppSpec,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}}
+		specFile := testTempFile(t, []byte(validYAMLSpec+"---\n"+validYAMLSpec))
 
-		tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+		config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
 
-		err := RunAppsList(config)
-		require.NoError(t, err)
+		err := RunAppsCreate(config)
+		require.EqualError(t, err, "app spec "+specFile+" contains 2 YAML documents, but only a single app spec is allowed here")
 	})
 }
 
-func TestRunAppsUpdate(t *testing.T) {
+func TestRunAppsCreateWithWait(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		specFile, err := ioutil.TempFile("", "spec")
-		require.NoError(t, err)
-		defer func() {
-			os.Remove(specFile.Name())
-			specFile.Close()
-		}()
-
-		err = json.NewEncoder(specFile).Encode(&testAppSpec)
-		require.NoError(t, err)
+		specFile := testTempFile(t, []byte(validJSONSpec))
 
+		deployment := &godo.Deployment{
+			ID:    uuid.New().String(),
+			Spec:  validAppSpec,
+			Phase: godo.DeploymentPhase_Building,
+		}
+		activeDeployment := &godo.Deployment{
+			ID:    deployment.ID,
+			Spec:  validAppSpec,
+			Phase: godo.DeploymentPhase_Active,
+		}
 		app := &godo.App{
 			ID:        uuid.New().String(),
-			Spec:      &testAppSpec,
+			Spec:      validAppSpec,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
-		updateReq := &godo.AppUpdateRequest{
-			Spec: &testAppSpec,
+		inProgressApp := &godo.App{
+			ID:                   app.ID,
+			Spec:                 validAppSpec,
+			InProgressDeployment: deployment,
+		}
+		activeApp := &godo.App{
+			ID:               app.ID,
+			Spec:             validAppSpec,
+			ActiveDeployment: activeDeployment,
 		}
 
-		tm.apps.EXPECT().Update(app.ID, updateReq).Times(1).Return(app, nil)
+		gomock.InOrder(
+			tm.apps.EXPECT().Create(&godo.AppCreateRequest{Spec: validAppSpec}).Times(1).Return(app, nil),
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil),
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(inProgressApp, nil),
+			tm.apps.EXPECT().GetDeployment(app.ID, deployment.ID).Times(1).Return(activeDeployment, nil),
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(activeApp, nil),
+		)
 
-		config.Args = append(config.Args, app.ID)
-		config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile.Name())
+		config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+		config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+		config.Doit.Set(config.NS, doctl.ArgPollInterval, time.Millisecond)
 
-		err = RunAppsUpdate(config)
+		err := RunAppsCreate(config)
 		require.NoError(t, err)
 	})
+
+	t.Run("deployment error", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, []byte(validJSONSpec))
+
+			deployment := &godo.Deployment{
+				ID:    uuid.New().String(),
+				Spec:  validAppSpec,
+				Phase: godo.DeploymentPhase_Error,
+			}
+			app := &godo.App{
+				ID:                   uuid.New().String(),
+				Spec:                 validAppSpec,
+				InProgressDeployment: deployment,
+			}
+
+			tm.apps.EXPECT().Create(&godo.AppCreateRequest{Spec: validAppSpec}).Times(1).Return(app, nil)
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+			tm.apps.EXPECT().GetDeployment(app.ID, deployment.ID).Times(1).Return(deployment, nil)
+
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+
+			err := RunAppsCreate(config)
+			require.EqualError(t, err, "app deployment "+deployment.ID+" couldn't enter `running` state: phase: [ERROR]")
+		})
+	})
 }
 
-func TestRunAppsDelete(t *testing.T) {
+func TestRunAppsGet(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
 		app := &godo.App{
 			ID:        uuid.New().String(),
@@ -179,466 +301,4370 @@ func TestRunAppsDelete(t *testing.T) {
 			UpdatedAt: time.Now(),
 		}
 
-		tm.apps.EXPECT().Delete(app.ID).Times(1).Return(nil)
+		tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
 
 		config.Args = append(config.Args, app.ID)
-		config.Doit.Set(config.NS, doctl.ArgForce, true)
 
-		err := RunAppsDelete(config)
+		err := RunAppsGet(config)
 		require.NoError(t, err)
 	})
-}
-
-func TestRunAppsCreateDeployment(t *testing.T) {
-	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		appID := uuid.New().String()
-		deployment := &godo.Deployment{
-			ID:   uuid.New().String(),
-			Spec: &testAppSpec,
-			Services: []*godo.DeploymentService{{
-				Name:             "service",
-				SourceCommitHash: "commit",
-			}},
-			Cause: "Manual",
-			Progress: &godo.DeploymentProgress{
-				PendingSteps: 1,
-				RunningSteps: 0,
-				SuccessSteps: 0,
-				ErrorSteps:   0,
-				TotalSteps:   1,
 
-				Steps: []*godo.DeploymentProgressStep{{
-					Name:      "name",
-					Status:    "pending",
-					StartedAt: time.Now(),
-				}},
-			},
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
+	t.Run("wait", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			deployment := &godo.Deployment{
+				ID:    uuid.New().String(),
+				Phase: godo.DeploymentPhase_Deploying,
+			}
+			activeDeployment := &godo.Deployment{
+				ID:    deployment.ID,
+				Phase: godo.DeploymentPhase_Active,
+			}
 
-		tm.apps.EXPECT().CreateDeployment(appID, true).Times(1).Return(deployment, nil)
+			gomock.InOrder(
+				tm.apps.EXPECT().Get(appID).Times(1).Return(&godo.App{ID: appID, Spec: &testAppSpec, InProgressDeployment: deployment}, nil),
+				tm.apps.EXPECT().Get(appID).Times(1).Return(&godo.App{ID: appID, Spec: &testAppSpec, ActiveDeployment: activeDeployment}, nil),
+			)
 
-		config.Args = append(config.Args, appID)
-		config.Doit.Set(config.NS, doctl.ArgAppForceRebuild, true)
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+			config.Doit.Set(config.NS, doctl.ArgPollInterval, time.Millisecond)
 
-		err := RunAppsCreateDeployment(config)
-		require.NoError(t, err)
+			err := RunAppsGet(config)
+			require.NoError(t, err)
+		})
 	})
-}
 
-func TestRunAppsCreateDeploymentWithWait(t *testing.T) {
-	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		appID := uuid.New().String()
-		deployment := &godo.Deployment{
-			ID:   uuid.New().String(),
-			Spec: &testAppSpec,
-			Services: []*godo.DeploymentService{{
-				Name:             "service",
-				SourceCommitHash: "commit",
-			}},
-			Cause: "Manual",
-			Phase: godo.DeploymentPhase_PendingDeploy,
-			Progress: &godo.DeploymentProgress{
-				PendingSteps: 1,
-				RunningSteps: 0,
-				SuccessSteps: 0,
-				ErrorSteps:   0,
-				TotalSteps:   1,
+	t.Run("by name", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			app := &godo.App{ID: uuid.New().String(), Spec: &testAppSpec}
 
-				Steps: []*godo.DeploymentProgressStep{{
-					Name:      "name",
-					Status:    "pending",
-					StartedAt: time.Now(),
-				}},
-			},
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
-		activeDeployment := &godo.Deployment{
-			ID:   uuid.New().String(),
-			Spec: &testAppSpec,
-			Services: []*godo.DeploymentService{{
-				Name:             "service",
-				SourceCommitHash: "commit",
-			}},
-			Cause: "Manual",
-			Phase: godo.DeploymentPhase_Active,
-			Progress: &godo.DeploymentProgress{
-				PendingSteps: 1,
-				RunningSteps: 0,
-				SuccessSteps: 0,
-				ErrorSteps:   0,
-				TotalSteps:   1,
+			tm.apps.EXPECT().List().Times(1).Return([]*godo.App{app}, nil)
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
 
-				Steps: []*godo.DeploymentProgressStep{{
-					Name:      "name",
-					Status:    "pending",
-					StartedAt: time.Now(),
-				}},
-			},
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
+			config.Args = append(config.Args, testAppSpec.Name)
 
-		tm.apps.EXPECT().CreateDeployment(appID, false).Times(1).Return(deployment, nil)
-		tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(activeDeployment, nil)
+			err := RunAppsGet(config)
+			require.NoError(t, err)
+		})
+	})
 
-		config.Args = append(config.Args, appID)
-		config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+	t.Run("wait timeout", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			app := &godo.App{
+				ID:                   appID,
+				Spec:                 &testAppSpec,
+				InProgressDeployment: &godo.Deployment{ID: uuid.New().String(), Phase: godo.DeploymentPhase_Building},
+			}
 
-		err := RunAppsCreateDeployment(config)
-		require.NoError(t, err)
+			tm.apps.EXPECT().Get(appID).AnyTimes().Return(app, nil)
+
+			_, err := waitForAppStable(tm.apps, appID, 50*time.Millisecond, 10*time.Millisecond, 0)
+			require.EqualError(t, err, "app did not become stable within 50ms")
+		})
 	})
 }
 
-func TestRunAppsGetDeployment(t *testing.T) {
-	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		appID := uuid.New().String()
-		deployment := &godo.Deployment{
-			ID:   uuid.New().String(),
-			Spec: &testAppSpec,
-			Services: []*godo.DeploymentService{{
-				Name:             "service",
-				SourceCommitHash: "commit",
-			}},
-			Cause: "Manual",
-			Progress: &godo.DeploymentProgress{
-				PendingSteps: 1,
-				RunningSteps: 0,
-				SuccessSteps: 0,
-				ErrorSteps:   0,
-				TotalSteps:   1,
+func TestRunAppsGetComponentURLs(t *testing.T) {
+	spec := &godo.AppSpec{
+		Name: "test",
+		Services: []*godo.AppServiceSpec{{
+			Name:          "api",
+			HTTPPort:      8080,
+			InternalPorts: []int64{9090},
+			Routes:        []*godo.AppRouteSpec{{Path: "/api"}},
+		}},
+		StaticSites: []*godo.AppStaticSiteSpec{{
+			Name:   "web",
+			Routes: []*godo.AppRouteSpec{{Path: "/"}},
+		}},
+		Workers: []*godo.AppWorkerSpec{{Name: "worker"}},
+	}
+	app := &godo.App{
+		ID:               uuid.New().String(),
+		Spec:             &testAppSpec,
+		LiveURL:          "https://test.ondigitalocean.app/",
+		ActiveDeployment: &godo.Deployment{Spec: spec},
+	}
 
-				Steps: []*godo.DeploymentProgressStep{{
-					Name:      "name",
-					Status:    "pending",
-					StartedAt: time.Now(),
-				}},
-			},
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		}
+	t.Run("text", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
 
-		tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(deployment, nil)
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, app.ID)
+			config.Doit.Set(config.NS, doctl.ArgAppComponentURLs, true)
 
-		config.Args = append(config.Args, appID, deployment.ID)
+			err := RunAppsGet(config)
+			require.NoError(t, err)
+			assert.Equal(t, `Component    Type        URL
+api          public      https://test.ondigitalocean.app/api
+web          public      https://test.ondigitalocean.app/
+api          internal    api:8080
+api          internal    api:9090
+`, buf.String())
+		})
+	})
 
-		err := RunAppsGetDeployment(config)
-		require.NoError(t, err)
+	t.Run("json", func(t *testing.T) {
+		defer func(o string) { Output = o }(Output)
+		Output = "json"
+
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, app.ID)
+			config.Doit.Set(config.NS, doctl.ArgAppComponentURLs, true)
+
+			err := RunAppsGet(config)
+			require.NoError(t, err)
+
+			var urls []map[string]string
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &urls))
+			assert.Len(t, urls, 4)
+			assert.Equal(t, map[string]string{"component": "api", "type": "public", "url": "https://test.ondigitalocean.app/api"}, urls[0])
+		})
+	})
+
+	t.Run("not live", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			notLive := &godo.App{ID: app.ID, Spec: spec}
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(notLive, nil)
+
+			config.Args = append(config.Args, app.ID)
+			config.Doit.Set(config.NS, doctl.ArgAppComponentURLs, true)
+
+			err := RunAppsGet(config)
+			require.EqualError(t, err, "app "+app.ID+" has no live URL yet; it may still be deploying")
+		})
 	})
 }
 
-func TestRunAppsListDeployments(t *testing.T) {
+func TestResolveAppID(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		id := uuid.New().String()
+		resolved, err := resolveAppID(tm.apps, id)
+		require.NoError(t, err)
+		assert.Equal(t, id, resolved)
+
+		apps := []*godo.App{
+			{ID: "1", Spec: &godo.AppSpec{Name: "web"}},
+			{ID: "2", Spec: &godo.AppSpec{Name: "api"}},
+			{ID: "3", Spec: &godo.AppSpec{Name: "api"}},
+		}
+		tm.apps.EXPECT().List().Times(3).Return(apps, nil)
+
+		resolved, err = resolveAppID(tm.apps, "web")
+		require.NoError(t, err)
+		assert.Equal(t, "1", resolved)
+
+		_, err = resolveAppID(tm.apps, "api")
+		require.EqualError(t, err, `2 apps are named "api": 2, 3`)
+
+		_, err = resolveAppID(tm.apps, "missing")
+		require.EqualError(t, err, `no app named "missing" found`)
+	})
+
+	t.Run("resolver lists once", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			apps := []*godo.App{
+				{ID: "1", Spec: &godo.AppSpec{Name: "web"}},
+				{ID: "2", Spec: &godo.AppSpec{Name: "api"}},
+			}
+			tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+
+			resolver := newAppIDResolver(tm.apps)
+			for name, want := range map[string]string{"web": "1", "api": "2"} {
+				id, err := resolver.resolve(name)
+				require.NoError(t, err)
+				assert.Equal(t, want, id)
+			}
+
+			_, err := resolver.resolve("missing")
+			require.EqualError(t, err, `no app named "missing" found`)
+		})
+	})
+}
+
+func TestRunAppsList(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		apps := []*godo.App{{
+			ID:        uuid.New().String(),
+			Spec:      &testAppSpec,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}}
+
+		tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+
+		err := RunAppsList(config)
+		require.NoError(t, err)
+	})
+
+	t.Run("filtered", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			apps := []*godo.App{
+				{ID: "1", Spec: &godo.AppSpec{Name: "staging-web"}, Region: &godo.AppRegion{Slug: "nyc"}},
+				{ID: "2", Spec: &godo.AppSpec{Name: "staging-api"}, Region: &godo.AppRegion{Slug: "ams"}},
+				{ID: "3", Spec: &godo.AppSpec{Name: "prod-web"}, Region: &godo.AppRegion{Slug: "nyc"}},
+			}
+
+			tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Doit.Set(config.NS, doctl.ArgAppFilter, "name=staging-*")
+			config.Doit.Set(config.NS, doctl.ArgRegionSlug, "nyc")
+			config.Doit.Set(config.NS, doctl.ArgFormat, "ID,Spec.Name")
+
+			err := RunAppsList(config)
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), "staging-web")
+			assert.NotContains(t, buf.String(), "staging-api")
+			assert.NotContains(t, buf.String(), "prod-web")
+		})
+	})
+
+	t.Run("sorted", func(t *testing.T) {
+		now := time.Now()
+		apps := []*godo.App{
+			{ID: "1", Spec: &godo.AppSpec{Name: "web"}, CreatedAt: now.Add(-time.Hour), UpdatedAt: now},
+			{ID: "2", Spec: &godo.AppSpec{Name: "api"}, CreatedAt: now, UpdatedAt: now.Add(-2 * time.Hour)},
+			{ID: "3", Spec: &godo.AppSpec{Name: "docs"}, CreatedAt: now.Add(-2 * time.Hour), UpdatedAt: now.Add(-time.Hour)},
+		}
+
+		tcs := []struct {
+			field    string
+			desc     bool
+			expected string
+		}{
+			{field: "name", expected: "api\ndocs\nweb\n"},
+			{field: "name", desc: true, expected: "web\ndocs\napi\n"},
+			{field: "created", expected: "docs\nweb\napi\n"},
+			{field: "updated", desc: true, expected: "web\ndocs\napi\n"},
+		}
+
+		for _, tc := range tcs {
+			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+				tm.apps.EXPECT().List().Times(1).Return(append([]*godo.App(nil), apps...), nil)
+
+				var buf bytes.Buffer
+				config.Out = &buf
+				config.Doit.Set(config.NS, doctl.ArgSort, tc.field)
+				config.Doit.Set(config.NS, doctl.ArgSortDesc, tc.desc)
+				config.Doit.Set(config.NS, doctl.ArgFormat, "Spec.Name")
+				config.Doit.Set(config.NS, doctl.ArgNoHeader, true)
+
+				err := RunAppsList(config)
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, buf.String(), "sorting by %s", tc.field)
+			})
+		}
+
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+
+			config.Doit.Set(config.NS, doctl.ArgSort, "region")
+
+			err := RunAppsList(config)
+			require.EqualError(t, err, `invalid sort field "region", must be one of: name, created, updated`)
+		})
+	})
+
+	t.Run("wide", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			apps := []*godo.App{{
+				ID:               "1",
+				Spec:             &godo.AppSpec{Name: "web"},
+				LiveURL:          "https://web.example.com",
+				Region:           &godo.AppRegion{Slug: "nyc"},
+				TierSlug:         "basic",
+				ActiveDeployment: &godo.Deployment{ID: "d1", Phase: godo.DeploymentPhase_Active},
+			}}
+
+			tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Doit.Set(config.NS, doctl.ArgWide, true)
+
+			err := RunAppsList(config)
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), "Active Deployment Phase")
+			for _, v := range []string{"https://web.example.com", "nyc", "basic", "ACTIVE"} {
+				assert.Contains(t, buf.String(), v)
+			}
+		})
+	})
+
+	t.Run("invalid filter", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doctl.ArgAppFilter, "region=nyc")
+
+			err := RunAppsList(config)
+			require.EqualError(t, err, `invalid filter "region=nyc", must be in the form name=<pattern>`)
+		})
+	})
+}
+
+func TestRunAppsListBandwidth(t *testing.T) {
+	web := &godo.App{ID: uuid.New().String(), Spec: &godo.AppSpec{Name: "web"}}
+	api := &godo.App{ID: uuid.New().String(), Spec: &godo.AppSpec{Name: "api"}}
+	apps := []*godo.App{web, api}
+	day := func(d int) time.Time { return time.Date(2021, time.March, d, 0, 0, 0, 0, time.UTC) }
+
+	t.Run("csv", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			ids := []string{web.ID, api.ID}
+			tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+			gomock.InOrder(
+				tm.apps.EXPECT().ListBandwidthUsage(ids, day(1)).Times(1).Return([]*do.AppBandwidthUsage{
+					{AppID: web.ID, BandwidthBytes: 1 << 30},
+					{AppID: api.ID, BandwidthBytes: 1 << 29},
+				}, nil),
+				tm.apps.EXPECT().ListBandwidthUsage(ids, day(2)).Times(1).Return([]*do.AppBandwidthUsage{
+					{AppID: web.ID, BandwidthBytes: 1 << 29},
+				}, nil),
+			)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Doit.Set(config.NS, doctl.ArgAppBandwidthSince, "2021-03-01")
+			config.Doit.Set(config.NS, doctl.ArgAppBandwidthUntil, "2021-03-02")
+			config.Doit.Set(config.NS, doctl.ArgAppBandwidthCSV, true)
+
+			err := RunAppsListBandwidth(config)
+			require.NoError(t, err)
+			assert.Equal(t, "App ID,Name,GiB Used\n"+web.ID+",web,1.50\n"+api.ID+",api,0.50\n", buf.String())
+		})
+	})
+
+	t.Run("by name", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+			tm.apps.EXPECT().ListBandwidthUsage([]string{api.ID}, day(5)).Times(1).Return([]*do.AppBandwidthUsage{
+				{AppID: api.ID, BandwidthBytes: 3 << 30},
+			}, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, "api")
+			config.Doit.Set(config.NS, doctl.ArgAppBandwidthSince, "2021-03-05")
+			config.Doit.Set(config.NS, doctl.ArgAppBandwidthUntil, "2021-03-05")
+
+			err := RunAppsListBandwidth(config)
+			require.NoError(t, err)
+			assert.Equal(t, `App ID                                  Name    GiB Used
+`+api.ID+`    api     3.00
+`, buf.String())
+		})
+	})
+
+	t.Run("invalid period", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doctl.ArgAppBandwidthSince, "2021-03-05")
+			config.Doit.Set(config.NS, doctl.ArgAppBandwidthUntil, "2021-03-04")
+
+			err := RunAppsListBandwidth(config)
+			require.EqualError(t, err, "--until must not be before --since")
+
+			config.Doit.Set(config.NS, doctl.ArgAppBandwidthSince, "March 1")
+			err = RunAppsListBandwidth(config)
+			require.EqualError(t, err, `invalid --since "March 1", must be a date such as 2021-03-01`)
+		})
+	})
+}
+
+func TestRunAppsGetMetrics(t *testing.T) {
+	appID := uuid.New().String()
+	at := time.Now()
+	cpu := []*do.AppMetricSample{
+		{Component: "web", Time: at, Value: 10},
+		{Component: "web", Time: at, Value: 30},
+		{Component: "worker", Time: at, Value: 5},
+	}
+	memory := []*do.AppMetricSample{
+		{Component: "web", Time: at, Value: 40},
+		{Component: "worker", Time: at, Value: 60},
+		{Component: "worker", Time: at, Value: 80},
+	}
+
+	expect := func(tm *tcMocks, days *int) {
+		tm.apps.EXPECT().GetMetric(appID, do.AppMetricCPUPercentage, gomock.Any(), gomock.Any()).Times(1).
+			DoAndReturn(func(_, _ string, start, end time.Time) ([]*do.AppMetricSample, error) {
+				assert.Equal(t, 2*time.Hour, end.Sub(start))
+				return cpu, nil
+			})
+		tm.apps.EXPECT().GetMetric(appID, do.AppMetricMemoryPercentage, gomock.Any(), gomock.Any()).Times(1).Return(memory, nil)
+		// A two hour period spans one or two days, depending on the time of day.
+		tm.apps.EXPECT().ListBandwidthUsage([]string{appID}, gomock.Any()).MinTimes(1).MaxTimes(2).
+			DoAndReturn(func([]string, time.Time) ([]*do.AppBandwidthUsage, error) {
+				*days++
+				return []*do.AppBandwidthUsage{{AppID: appID, BandwidthBytes: 1 << 30}}, nil
+			})
+	}
+
+	t.Run("text", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			var days int
+			expect(tm, &days)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgAppMetricsPeriod, 2*time.Hour)
+
+			err := RunAppsGetMetrics(config)
+			require.NoError(t, err)
+			table := `Component    CPU Avg %    CPU Max %    Memory Avg %    Memory Max %
+web          20.00        30.00        40.00           40.00
+worker       5.00         5.00         70.00           80.00
+`
+			assert.Regexp(t, fmt.Sprintf(`^%s\nBandwidth used from \d{4}-\d\d-\d\d to \d{4}-\d\d-\d\d: %d\.00 GiB\n$`, regexp.QuoteMeta(table), days), buf.String())
+		})
+	})
+
+	t.Run("json", func(t *testing.T) {
+		defer func(o string) { Output = o }(Output)
+		Output = "json"
+
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			var days int
+			expect(tm, &days)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgAppMetricsPeriod, 2*time.Hour)
+
+			err := RunAppsGetMetrics(config)
+			require.NoError(t, err)
+
+			var out struct {
+				Components     []displayers.AppComponentMetrics `json:"components"`
+				BandwidthBytes uint64                           `json:"bandwidth_bytes"`
+			}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+			assert.Equal(t, []displayers.AppComponentMetrics{
+				{Component: "web", CPUAverage: 20, CPUMax: 30, MemoryAverage: 40, MemoryMax: 40},
+				{Component: "worker", CPUAverage: 5, CPUMax: 5, MemoryAverage: 70, MemoryMax: 80},
+			}, out.Components)
+			assert.Equal(t, uint64(days)<<30, out.BandwidthBytes)
+		})
+	})
+}
+
+func TestAppNameMatcher(t *testing.T) {
+	match, err := appNameMatcher("web")
+	require.NoError(t, err)
+	assert.True(t, match("staging-web-1"))
+	assert.False(t, match("api"))
+
+	match, err = appNameMatcher("staging-*")
+	require.NoError(t, err)
+	assert.True(t, match("staging-web"))
+	assert.False(t, match("prod-staging-web"))
+}
+
+func TestRunAppsUpdate(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		specFile, err := ioutil.TempFile("", "spec")
+		require.NoError(t, err)
+		defer func() {
+			os.Remove(specFile.Name())
+			specFile.Close()
+		}()
+
+		err = json.NewEncoder(specFile).Encode(&testAppSpec)
+		require.NoError(t, err)
+
+		app := &godo.App{
+			ID:        uuid.New().String(),
+			Spec:      &testAppSpec,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		updateReq := &godo.AppUpdateRequest{
+			Spec: &testAppSpec,
+		}
+
+		tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+		tm.apps.EXPECT().Update(app.ID, updateReq).Times(1).Return(app, nil)
+
+		config.Args = append(config.Args, app.ID)
+		config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile.Name())
+
+		err = RunAppsUpdate(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsUpdateRemovesDatabase(t *testing.T) {
+	defer func(f func(string) (string, error)) { retrieveUserInput = f }(retrieveUserInput)
+
+	specFile := testTempFile(t, []byte(validJSONSpec))
+	app := &godo.App{
+		ID: uuid.New().String(),
+		Spec: &godo.AppSpec{
+			Name:      "test",
+			Services:  testAppSpec.Services,
+			Databases: []*godo.AppDatabaseSpec{{Name: "db"}},
+		},
+	}
+
+	t.Run("declined", func(t *testing.T) {
+		var prompt string
+		retrieveUserInput = func(message string) (string, error) {
+			prompt = message
+			return "no", nil
+		}
+
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+
+			config.Args = append(config.Args, app.ID)
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+
+			err := RunAppsUpdate(config)
+			require.EqualError(t, err, "Operation aborted.")
+			assert.Equal(t, "update app "+app.ID+" and remove its database component(s) db? Their data may be lost.", prompt)
+		})
+	})
+
+	t.Run("force", func(t *testing.T) {
+		retrieveUserInput = func(string) (string, error) {
+			t.Fatal("unexpected prompt")
+			return "", nil
+		}
+
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().Update(app.ID, &godo.AppUpdateRequest{Spec: validAppSpec}).Times(1).Return(app, nil)
+
+			config.Args = append(config.Args, app.ID)
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+			err := RunAppsUpdate(config)
+			require.NoError(t, err)
+		})
+	})
+}
+
+func TestCheckAppSpecRegion(t *testing.T) {
+	defer func(a io.Writer) { color.Output = a }(color.Output)
+
+	regions := []*godo.AppRegion{
+		{Slug: "nyc", DataCenters: []string{"nyc1", "nyc3"}},
+		{Slug: "ams", DataCenters: []string{"ams3"}, Disabled: true, Reason: "capacity"},
+	}
+
+	tcs := []struct {
+		region string
+		warn   string
+	}{
+		{region: "nyc"},
+		{region: "nyc3"},
+		{region: "ams", warn: "Warning: Region ams is currently unavailable: capacity\n"},
+		{region: "sfo", warn: "Warning: Unknown region sfo; available regions are: nyc, ams\n"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.region, func(t *testing.T) {
+			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+				var buf bytes.Buffer
+				color.Output = &buf
+
+				tm.apps.EXPECT().ListRegions().Times(1).Return(regions, nil)
+
+				err := checkAppSpecRegion(tm.apps, &godo.AppSpec{Name: "test", Region: tc.region})
+				require.NoError(t, err)
+				assert.Equal(t, tc.warn, buf.String())
+			})
+		})
+	}
+}
+
+func TestCheckAppSpecInstanceSizes(t *testing.T) {
+	sizes := []*godo.AppInstanceSize{testAppInstanceSize, {Slug: "professional-xs"}}
+
+	t.Run("valid", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().ListInstanceSizes().Times(1).Return(sizes, nil)
+
+			err := checkAppSpecInstanceSizes(tm.apps, &godo.AppSpec{
+				Name:     "test",
+				Services: []*godo.AppServiceSpec{{Name: "web", InstanceSizeSlug: "basic-xxs"}},
+				Workers:  []*godo.AppWorkerSpec{{Name: "worker"}},
+			})
+			require.NoError(t, err)
+		})
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().ListInstanceSizes().Times(1).Return(sizes, nil)
+
+			err := checkAppSpecInstanceSizes(tm.apps, &godo.AppSpec{
+				Name:     "test",
+				Services: []*godo.AppServiceSpec{{Name: "web", InstanceSizeSlug: "basic-xxs"}},
+				Jobs:     []*godo.AppJobSpec{{Name: "migrate", InstanceSizeSlug: "basic-huge"}},
+			})
+			require.EqualError(t, err, `component "migrate" has unknown instance size "basic-huge"; valid instance sizes are: basic-xxs, professional-xs`)
+		})
+	})
+}
+
+func TestRunAppsUpdateByName(t *testing.T) {
+	specFile := testTempFile(t, []byte(validJSONSpec))
+	app := &godo.App{ID: uuid.New().String(), Spec: &testAppSpec}
+	other := &godo.App{ID: uuid.New().String(), Spec: &godo.AppSpec{Name: "other"}}
+
+	t.Run("single match", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().List().Times(1).Return([]*godo.App{other, app}, nil)
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+			tm.apps.EXPECT().Update(app.ID, &godo.AppUpdateRequest{Spec: validAppSpec}).Times(1).Return(app, nil)
+
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgAppByName, true)
+
+			err := RunAppsUpdate(config)
+			require.NoError(t, err)
+		})
+	})
+
+	t.Run("no match", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().List().Times(1).Return([]*godo.App{other}, nil)
+
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgAppByName, true)
+
+			err := RunAppsUpdate(config)
+			require.EqualError(t, err, `no app named "test" found`)
+		})
+	})
+
+	t.Run("multiple matches", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			dup := &godo.App{ID: uuid.New().String(), Spec: &testAppSpec}
+			tm.apps.EXPECT().List().Times(1).Return([]*godo.App{app, dup}, nil)
+
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgAppByName, true)
+
+			err := RunAppsUpdate(config)
+			require.EqualError(t, err, fmt.Sprintf(`2 apps are named "test": %s, %s`, app.ID, dup.ID))
+		})
+	})
+}
+
+func TestRunAppsDelete(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		app := &godo.App{
+			ID:        uuid.New().String(),
+			Spec:      &testAppSpec,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		tm.apps.EXPECT().Delete(app.ID).Times(1).Return(nil)
+
+		config.Args = append(config.Args, app.ID)
+		config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+		err := RunAppsDelete(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsDeleteMultiple(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		ids := []string{uuid.New().String(), uuid.New().String(), uuid.New().String()}
+
+		tm.apps.EXPECT().Delete(ids[0]).Times(1).Return(nil)
+		tm.apps.EXPECT().Delete(ids[1]).Times(1).Return(errors.New("not found"))
+		tm.apps.EXPECT().Delete(ids[2]).Times(1).Return(nil)
+
+		config.Args = append(config.Args, ids...)
+		config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+		err := RunAppsDelete(config)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ids[1])
+		assert.NotContains(t, err.Error(), ids[0])
+	})
+
+	t.Run("by name", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			id := uuid.New().String()
+			apps := []*godo.App{
+				{ID: "a1", Spec: &godo.AppSpec{Name: "web"}},
+				{ID: "a2", Spec: &godo.AppSpec{Name: "api"}},
+			}
+
+			tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+			tm.apps.EXPECT().Delete("a1").Times(1).Return(nil)
+			tm.apps.EXPECT().Delete(id).Times(1).Return(nil)
+			tm.apps.EXPECT().Delete("a2").Times(1).Return(nil)
+
+			config.Args = append(config.Args, "web", id, "api")
+			config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+			err := RunAppsDelete(config)
+			require.NoError(t, err)
+		})
+	})
+}
+
+func TestRunAppsDeleteConcurrency(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		ids := []string{uuid.New().String(), uuid.New().String(), uuid.New().String(), uuid.New().String()}
+
+		var mu sync.Mutex
+		var running, maxRunning int
+		del := func(id string) error {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			if id == ids[0] {
+				return errors.New("not found")
+			}
+			return nil
+		}
+		for _, id := range ids {
+			tm.apps.EXPECT().Delete(id).Times(1).DoAndReturn(del)
+		}
+
+		defer func(a io.Writer) { color.Output = a }(color.Output)
+		var stderr bytes.Buffer
+		color.Output = &stderr
+
+		config.Args = append(config.Args, ids...)
+		config.Doit.Set(config.NS, doctl.ArgForce, true)
+		config.Doit.Set(config.NS, doctl.ArgConcurrency, 2)
+
+		err := RunAppsDelete(config)
+		require.EqualError(t, err, "Failed to delete 1 app(s): "+ids[0])
+		assert.Equal(t, 2, maxRunning)
+		assert.Equal(t, `Warning: Unable to delete app `+ids[0]+`: not found
+Notice: App `+ids[1]+` deleted
+Notice: App `+ids[2]+` deleted
+Notice: App `+ids[3]+` deleted
+Notice: Deleted 3 of 4 apps
+`, stderr.String())
+	})
+}
+
+func TestRunAppsDeleteAll(t *testing.T) {
+	apps := []*godo.App{
+		{ID: "a1", Spec: &godo.AppSpec{Name: "preview-1"}},
+		{ID: "a2", Spec: &godo.AppSpec{Name: "production"}},
+		{ID: "a3", Spec: &godo.AppSpec{Name: "preview-2"}},
+	}
+
+	t.Run("with filter", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+			tm.apps.EXPECT().Delete("a1").Times(1).Return(nil)
+			tm.apps.EXPECT().Delete("a3").Times(1).Return(nil)
+
+			config.Doit.Set(config.NS, doctl.ArgAppDeleteAll, true)
+			config.Doit.Set(config.NS, doctl.ArgAppFilter, "name=preview-*")
+			config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+			err := RunAppsDelete(config)
+			require.NoError(t, err)
+		})
+	})
+
+	t.Run("without filter or force", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doctl.ArgAppDeleteAll, true)
+
+			err := RunAppsDelete(config)
+			require.Error(t, err)
+		})
+	})
+
+	t.Run("force requires typed confirmation", func(t *testing.T) {
+		rti := retrieveTypedInput
+		defer func() {
+			retrieveTypedInput = rti
+		}()
+
+		for _, answer := range []string{"yes", "delete all apps"} {
+			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+				retrieveTypedInput = func(string) (string, error) {
+					return answer, nil
+				}
+
+				tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+				if answer == "delete all apps" {
+					for _, app := range apps {
+						tm.apps.EXPECT().Delete(app.ID).Times(1).Return(nil)
+					}
+				}
+
+				config.Doit.Set(config.NS, doctl.ArgAppDeleteAll, true)
+				config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+				err := RunAppsDelete(config)
+				if answer == "delete all apps" {
+					require.NoError(t, err)
+				} else {
+					require.Error(t, err)
+				}
+			})
+		}
+	})
+
+	t.Run("filter matching every app requires typed confirmation", func(t *testing.T) {
+		rti := retrieveTypedInput
+		defer func() {
+			retrieveTypedInput = rti
+		}()
+
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			var prompted bool
+			retrieveTypedInput = func(string) (string, error) {
+				prompted = true
+				return "no", nil
+			}
+
+			tm.apps.EXPECT().List().Times(1).Return(apps, nil)
+
+			config.Doit.Set(config.NS, doctl.ArgAppDeleteAll, true)
+			config.Doit.Set(config.NS, doctl.ArgAppFilter, "name=*")
+			config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+			err := RunAppsDelete(config)
+			require.EqualError(t, err, "Operation aborted.")
+			assert.True(t, prompted)
+		})
+	})
+
+	t.Run("empty filter pattern", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doctl.ArgAppDeleteAll, true)
+			config.Doit.Set(config.NS, doctl.ArgAppFilter, "name=")
+			config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+			err := RunAppsDelete(config)
+			require.EqualError(t, err, `invalid filter "name=", must be in the form name=<pattern>`)
+		})
+	})
+}
+
+func TestRunAppsCreateDeployment(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		deployment := &godo.Deployment{
+			ID:   uuid.New().String(),
+			Spec: &testAppSpec,
+			Services: []*godo.DeploymentService{{
+				Name:             "service",
+				SourceCommitHash: "commit",
+			}},
+			Cause: "Manual",
+			Progress: &godo.DeploymentProgress{
+				PendingSteps: 1,
+				RunningSteps: 0,
+				SuccessSteps: 0,
+				ErrorSteps:   0,
+				TotalSteps:   1,
+
+				Steps: []*godo.DeploymentProgressStep{{
+					Name:      "name",
+					Status:    "pending",
+					StartedAt: time.Now(),
+				}},
+			},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		tm.apps.EXPECT().CreateDeployment(appID, true).Times(1).Return(deployment, nil)
+
+		config.Args = append(config.Args, appID)
+		config.Doit.Set(config.NS, doctl.ArgAppForceRebuild, true)
+
+		err := RunAppsCreateDeployment(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsCreateDeploymentSetInstances(t *testing.T) {
+	newApp := func() *godo.App {
+		return &godo.App{
+			ID: uuid.New().String(),
+			Spec: &godo.AppSpec{
+				Name: "test",
+				Services: []*godo.AppServiceSpec{{
+					Name:             "web",
+					InstanceCount:    1,
+					InstanceSizeSlug: "basic-xxs",
+				}},
+				Workers: []*godo.AppWorkerSpec{{
+					Name:          "worker",
+					InstanceCount: 1,
+				}},
+				StaticSites: []*godo.AppStaticSiteSpec{{
+					Name: "site",
+				}},
+			},
+		}
+	}
+
+	t.Run("scales components", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			app := newApp()
+			deployment := &godo.Deployment{ID: uuid.New().String(), Progress: &godo.DeploymentProgress{}}
+
+			gomock.InOrder(
+				tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil),
+				tm.apps.EXPECT().ListInstanceSizes().Times(1).Return([]*godo.AppInstanceSize{testAppInstanceSize, {Slug: "professional-s"}}, nil),
+				tm.apps.EXPECT().Update(app.ID, gomock.Any()).Times(1).DoAndReturn(func(id string, req *godo.AppUpdateRequest) (*godo.App, error) {
+					assert.Equal(t, int64(3), req.Spec.Services[0].InstanceCount)
+					assert.Equal(t, "professional-s", req.Spec.Services[0].InstanceSizeSlug)
+					assert.Equal(t, int64(2), req.Spec.Workers[0].InstanceCount)
+					return &godo.App{ID: app.ID, Spec: req.Spec, InProgressDeployment: deployment}, nil
+				}),
+			)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, app.ID)
+			config.Doit.Set(config.NS, doctl.ArgAppSetInstanceCount, []string{"web=3", "worker=2"})
+			config.Doit.Set(config.NS, doctl.ArgAppSetInstanceSize, []string{"web=professional-s"})
+
+			err := RunAppsCreateDeployment(config)
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), deployment.ID)
+		})
+	})
+
+	t.Run("waits for the update's deployment", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			app := newApp()
+			app.ActiveDeployment = &godo.Deployment{ID: uuid.New().String(), Phase: godo.DeploymentPhase_Active}
+			deployment := &godo.Deployment{ID: uuid.New().String(), Phase: godo.DeploymentPhase_Active, Progress: &godo.DeploymentProgress{}}
+
+			gomock.InOrder(
+				tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil),
+				tm.apps.EXPECT().Update(app.ID, gomock.Any()).Times(1).Return(app, nil),
+				tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil),
+				tm.apps.EXPECT().Get(app.ID).Times(1).Return(&godo.App{ID: app.ID, ActiveDeployment: app.ActiveDeployment, InProgressDeployment: deployment}, nil),
+				tm.apps.EXPECT().GetDeployment(app.ID, deployment.ID).Times(1).Return(deployment, nil),
+			)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, app.ID)
+			config.Doit.Set(config.NS, doctl.ArgAppSetInstanceCount, []string{"web=3"})
+			config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+			config.Doit.Set(config.NS, doctl.ArgPollInterval, time.Millisecond)
+
+			err := RunAppsCreateDeployment(config)
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), deployment.ID)
+		})
+	})
+
+	for _, tc := range []struct {
+		name   string
+		counts []string
+		sizes  []string
+		err    string
+	}{
+		{
+			name:   "unknown component",
+			counts: []string{"api=2"},
+			err:    `component "api" not found in app spec; available components are: web, site, worker`,
+		},
+		{
+			name:   "static site",
+			counts: []string{"site=2"},
+			err:    `component "site" has no instances to scale`,
+		},
+		{
+			name:   "invalid count",
+			counts: []string{"web=0"},
+			err:    `invalid --set-instance-count "web=0", the instance count must be a positive number`,
+		},
+		{
+			name:  "invalid form",
+			sizes: []string{"professional-s"},
+			err:   `invalid --set-instance-size "professional-s", must be in the form component=value`,
+		},
+		{
+			name:  "unknown size",
+			sizes: []string{"web=huge"},
+			err:   `component "web" has unknown instance size "huge"; valid instance sizes are: basic-xxs`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+				app := newApp()
+				tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+				tm.apps.EXPECT().ListInstanceSizes().AnyTimes().Return([]*godo.AppInstanceSize{testAppInstanceSize}, nil)
+
+				config.Args = append(config.Args, app.ID)
+				config.Doit.Set(config.NS, doctl.ArgAppSetInstanceCount, tc.counts)
+				config.Doit.Set(config.NS, doctl.ArgAppSetInstanceSize, tc.sizes)
+
+				err := RunAppsCreateDeployment(config)
+				assert.EqualError(t, err, tc.err)
+			})
+		})
+	}
+}
+
+func TestRunAppsCreateDeploymentWithWait(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		deployment := &godo.Deployment{
+			ID:   uuid.New().String(),
+			Spec: &testAppSpec,
+			Services: []*godo.DeploymentService{{
+				Name:             "service",
+				SourceCommitHash: "commit",
+			}},
+			Cause: "Manual",
+			Phase: godo.DeploymentPhase_PendingDeploy,
+			Progress: &godo.DeploymentProgress{
+				PendingSteps: 1,
+				RunningSteps: 0,
+				SuccessSteps: 0,
+				ErrorSteps:   0,
+				TotalSteps:   1,
+
+				Steps: []*godo.DeploymentProgressStep{{
+					Name:      "name",
+					Status:    "pending",
+					StartedAt: time.Now(),
+				}},
+			},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+		activeDeployment := &godo.Deployment{
+			ID:   uuid.New().String(),
+			Spec: &testAppSpec,
+			Services: []*godo.DeploymentService{{
+				Name:             "service",
+				SourceCommitHash: "commit",
+			}},
+			Cause: "Manual",
+			Phase: godo.DeploymentPhase_Active,
+			Progress: &godo.DeploymentProgress{
+				PendingSteps: 1,
+				RunningSteps: 0,
+				SuccessSteps: 0,
+				ErrorSteps:   0,
+				TotalSteps:   1,
+
+				Steps: []*godo.DeploymentProgressStep{{
+					Name:      "name",
+					Status:    "pending",
+					StartedAt: time.Now(),
+				}},
+			},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		tm.apps.EXPECT().CreateDeployment(appID, false).Times(1).Return(deployment, nil)
+		tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(activeDeployment, nil)
+
+		config.Args = append(config.Args, appID)
+		config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+
+		err := RunAppsCreateDeployment(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsCreateDeploymentWithWaitTimeout(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		deployment := &godo.Deployment{
+			ID:    uuid.New().String(),
+			Spec:  &testAppSpec,
+			Cause: "Manual",
+			Phase: godo.DeploymentPhase_Building,
+			Progress: &godo.DeploymentProgress{
+				PendingSteps: 1,
+				TotalSteps:   1,
+			},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		tm.apps.EXPECT().GetDeployment(appID, deployment.ID).MinTimes(1).Return(deployment, nil)
+
+		d, err := waitForAppDeploymentRunning(tm.apps, appID, deployment.ID, 50*time.Millisecond, 10*time.Millisecond, 0)
+		require.EqualError(t, err, "deployment did not reach active phase within 50ms")
+		assert.Equal(t, exitCodeDeploymentTimeout, exitCode(err))
+		assert.Equal(t, deployment, d)
+	})
+}
+
+func TestRunAppsCreateDeploymentWithWaitFailure(t *testing.T) {
+	tcs := []struct {
+		name     string
+		phase    godo.DeploymentPhase
+		steps    []*godo.DeploymentProgressStep
+		exitCode int
+	}{
+		{
+			name:  "build error",
+			phase: godo.DeploymentPhase_Error,
+			steps: []*godo.DeploymentProgressStep{
+				{Name: "build", Status: godo.DeploymentProgressStepStatus_Error},
+				{Name: "deploy", Status: godo.DeploymentProgressStepStatus_Pending},
+			},
+			exitCode: exitCodeDeploymentBuildFailed,
+		},
+		{
+			name:  "deploy error",
+			phase: godo.DeploymentPhase_Error,
+			steps: []*godo.DeploymentProgressStep{
+				{Name: "build", Status: godo.DeploymentProgressStepStatus_Success},
+				{Name: "deploy", Status: godo.DeploymentProgressStepStatus_Error},
+			},
+			exitCode: exitCodeDeploymentDeployFailed,
+		},
+		{
+			name:     "canceled",
+			phase:    godo.DeploymentPhase_Canceled,
+			exitCode: exitCodeDeploymentCanceled,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+				appID := uuid.New().String()
+				deployment := &godo.Deployment{
+					ID:       uuid.New().String(),
+					Spec:     &testAppSpec,
+					Phase:    godo.DeploymentPhase_PendingBuild,
+					Progress: &godo.DeploymentProgress{},
+				}
+				failedDeployment := &godo.Deployment{
+					ID:       deployment.ID,
+					Spec:     &testAppSpec,
+					Phase:    tc.phase,
+					Progress: &godo.DeploymentProgress{Steps: tc.steps},
+				}
+
+				tm.apps.EXPECT().CreateDeployment(appID, false).Times(1).Return(deployment, nil)
+				tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(failedDeployment, nil)
+
+				config.Args = append(config.Args, appID)
+				config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+
+				err := RunAppsCreateDeployment(config)
+				require.EqualError(t, err, fmt.Sprintf("app deployment couldn't enter `running` state: phase: [%s]", tc.phase))
+				assert.Equal(t, tc.exitCode, exitCode(err))
+			})
+		})
+	}
+}
+
+func TestWaitForAppDeploymentRunningTransientFailures(t *testing.T) {
+	backoff := appWaitRetryBackoff
+	appWaitRetryBackoff = time.Millisecond
+	defer func() {
+		appWaitRetryBackoff = backoff
+	}()
+
+	deployment := &godo.Deployment{ID: uuid.New().String(), Phase: godo.DeploymentPhase_Active}
+
+	t.Run("recovers", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			gomock.InOrder(
+				tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(2).Return(nil, errors.New("unavailable")),
+				tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(deployment, nil),
+			)
+
+			d, err := waitForAppDeploymentRunning(tm.apps, appID, deployment.ID, 0, time.Millisecond, 3)
+			require.NoError(t, err)
+			assert.Equal(t, deployment, d)
+		})
+	})
+
+	t.Run("exceeds max failures", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(2).Return(nil, errors.New("unavailable"))
+
+			_, err := waitForAppDeploymentRunning(tm.apps, appID, deployment.ID, 0, time.Millisecond, 2)
+			require.EqualError(t, err, "unavailable")
+		})
+	})
+}
+
+func TestAppWaitRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Second, appWaitRetryDelay(1))
+	assert.Equal(t, 4*time.Second, appWaitRetryDelay(3))
+	assert.Equal(t, 30*time.Second, appWaitRetryDelay(10))
+	assert.Equal(t, 30*time.Second, appWaitRetryDelay(100))
+}
+
+func TestRunAppsGetDeployment(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		deployment := &godo.Deployment{
+			ID:   uuid.New().String(),
+			Spec: &testAppSpec,
+			Services: []*godo.DeploymentService{{
+				Name:             "service",
+				SourceCommitHash: "commit",
+			}},
+			Cause: "Manual",
+			Progress: &godo.DeploymentProgress{
+				PendingSteps: 1,
+				RunningSteps: 0,
+				SuccessSteps: 0,
+				ErrorSteps:   0,
+				TotalSteps:   1,
+
+				Steps: []*godo.DeploymentProgressStep{{
+					Name:      "name",
+					Status:    "pending",
+					StartedAt: time.Now(),
+				}},
+			},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}
+
+		tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(deployment, nil)
+
+		config.Args = append(config.Args, appID, deployment.ID)
+
+		err := RunAppsGetDeployment(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsGetDeploymentLogs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "building\nbuild failed\n")
+	}))
+	defer server.Close()
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		deployment := &godo.Deployment{
+			ID:       uuid.New().String(),
+			Spec:     &testAppSpec,
+			Phase:    godo.DeploymentPhase_Error,
+			Progress: &godo.DeploymentProgress{},
+		}
+
+		tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(deployment, nil)
+		tm.apps.EXPECT().GetLogs(appID, deployment.ID, "", godo.AppLogTypeBuild, false).Times(1).Return(&godo.AppLogs{
+			HistoricURLs: []string{server.URL},
+		}, nil)
+
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, appID, deployment.ID)
+		config.Doit.Set(config.NS, doctl.ArgAppDeploymentLogs, "build")
+
+		err := RunAppsGetDeployment(config)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), deployment.ID)
+		assert.True(t, strings.HasSuffix(buf.String(), "\nbuilding\nbuild failed\n"))
+	})
+}
+
+func TestRunAppsGetDeploymentWithWait(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		deployment := &godo.Deployment{
+			ID:       uuid.New().String(),
+			Spec:     &testAppSpec,
+			Phase:    godo.DeploymentPhase_Building,
+			Progress: &godo.DeploymentProgress{},
+		}
+		activeDeployment := &godo.Deployment{
+			ID:       deployment.ID,
+			Spec:     &testAppSpec,
+			Phase:    godo.DeploymentPhase_Active,
+			Progress: &godo.DeploymentProgress{SuccessSteps: 2, TotalSteps: 2},
+		}
+
+		gomock.InOrder(
+			tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(deployment, nil),
+			tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(activeDeployment, nil),
+		)
+
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, appID, deployment.ID)
+		config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+		config.Doit.Set(config.NS, doctl.ArgPollInterval, time.Millisecond)
+
+		err := RunAppsGetDeployment(config)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "2/2")
+	})
+}
+
+func TestRunAppsGetDeploymentWatch(t *testing.T) {
+	defer func(f func() bool) { stdoutIsTerminal = f }(stdoutIsTerminal)
+
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	deployment := func(phase godo.DeploymentPhase) *godo.Deployment {
+		return &godo.Deployment{
+			ID:       deploymentID,
+			Spec:     &testAppSpec,
+			Phase:    phase,
+			Progress: &godo.DeploymentProgress{},
+		}
+	}
+
+	t.Run("not a terminal", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			stdoutIsTerminal = func() bool { return false }
+			gomock.InOrder(
+				tm.apps.EXPECT().GetDeployment(appID, deploymentID).Times(1).Return(deployment(godo.DeploymentPhase_Building), nil),
+				tm.apps.EXPECT().GetDeployment(appID, deploymentID).Times(1).Return(deployment(godo.DeploymentPhase_Active), nil),
+			)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppDeploymentWatch, true)
+			config.Doit.Set(config.NS, doctl.ArgPollInterval, time.Millisecond)
+
+			err := RunAppsGetDeployment(config)
+			require.NoError(t, err)
+
+			out := buf.String()
+			assert.NotContains(t, out, "\033[")
+			views := strings.Split(out, "\n\nEvery 1ms: ")
+			require.Len(t, views, 2)
+			assert.Contains(t, views[0], "Every 1ms: deployment "+deploymentID+"    Phase: Building    Elapsed: 0s\n\n")
+			assert.Contains(t, views[1], "Phase: Active")
+			assert.Equal(t, 4, strings.Count(out, deploymentID))
+		})
+	})
+
+	t.Run("terminal", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			stdoutIsTerminal = func() bool { return true }
+			gomock.InOrder(
+				tm.apps.EXPECT().GetDeployment(appID, deploymentID).Times(1).Return(deployment(godo.DeploymentPhase_PendingBuild), nil),
+				tm.apps.EXPECT().GetDeployment(appID, deploymentID).Times(1).Return(deployment(godo.DeploymentPhase_Error), nil),
+			)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppDeploymentWatch, true)
+			config.Doit.Set(config.NS, doctl.ArgPollInterval, time.Millisecond)
+
+			err := RunAppsGetDeployment(config)
+			require.Error(t, err)
+			var exitErr *exitCodeErr
+			require.True(t, errors.As(err, &exitErr))
+			assert.Equal(t, "app deployment "+deploymentID+" failed", err.Error())
+
+			out := buf.String()
+			assert.Equal(t, 2, strings.Count(out, "\033[H\033[2J"))
+			assert.True(t, strings.HasPrefix(out, "\033[H\033[2JEvery 1ms: "))
+			assert.Contains(t, out, "Phase: Pending build")
+			assert.Contains(t, out, "Phase: Error")
+		})
+	})
+
+	t.Run("with wait", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, appID, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppDeploymentWatch, true)
+			config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+
+			err := RunAppsGetDeployment(config)
+			assert.EqualError(t, err, "--watch cannot be used with --wait")
+		})
+	})
+}
+
+func TestWriteDeploymentProgressSteps(t *testing.T) {
+	started := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	steps := []*godo.DeploymentProgressStep{{
+		Name:      "build",
+		Status:    godo.DeploymentProgressStepStatus_Error,
+		StartedAt: started,
+		EndedAt:   started.Add(time.Minute),
+		Steps: []*godo.DeploymentProgressStep{{
+			Name:          "build-service",
+			MessageBase:   "Building service",
+			ComponentName: "api",
+			Status:        godo.DeploymentProgressStepStatus_Error,
+			Reason: &godo.DeploymentProgressStepReason{
+				Code:    "BuildJobFailed",
+				Message: "exit status 1",
+			},
+		}},
+	}, {
+		Name:   "deploy",
+		Status: godo.DeploymentProgressStepStatus_Pending,
+	}}
+
+	var buf bytes.Buffer
+	writeDeploymentProgressSteps(&buf, steps, 1)
+
+	expected := `  build [ERROR] started 2021-03-01T12:00:00Z ended 2021-03-01T12:01:00Z
+    Building service api [ERROR]
+      reason: BuildJobFailed exit status 1
+  deploy [PENDING]
+`
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestRunAppsListDeployments(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		deployments := []*godo.Deployment{{
+			ID:   uuid.New().String(),
+			Spec: &testAppSpec,
+			Services: []*godo.DeploymentService{{
+				Name:             "service",
+				SourceCommitHash: "commit",
+			}},
+			Cause: "Manual",
+			Progress: &godo.DeploymentProgress{
+				PendingSteps: 1,
+				RunningSteps: 0,
+				SuccessSteps: 0,
+				ErrorSteps:   0,
+				TotalSteps:   1,
+
+				Steps: []*godo.DeploymentProgressStep{{
+					Name:      "name",
+					Status:    "pending",
+					StartedAt: time.Now(),
+				}},
+			},
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		}}
+
+		tm.apps.EXPECT().ListDeployments(appID).Times(1).Return(deployments, nil)
+
+		config.Args = append(config.Args, appID)
+
+		err := RunAppsListDeployments(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsEvents(t *testing.T) {
+	start := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	specV1 := &godo.AppSpec{Name: "test"}
+	specV2 := &godo.AppSpec{Name: "test", Region: "ams"}
+	deployments := []*godo.Deployment{
+		{
+			ID:                 "d3",
+			Spec:               specV2,
+			Cause:              "manual",
+			CreatedAt:          start.Add(2 * time.Hour),
+			UpdatedAt:          start.Add(2 * time.Hour),
+			Phase:              godo.DeploymentPhase_PendingBuild,
+			PhaseLastUpdatedAt: start.Add(2 * time.Hour),
+		},
+		{
+			ID:        "d2",
+			Spec:      specV2,
+			Cause:     "app spec updated",
+			CreatedAt: start.Add(time.Hour),
+			UpdatedAt: start.Add(time.Hour + 3*time.Minute),
+			Progress: &godo.DeploymentProgress{Steps: []*godo.DeploymentProgressStep{{
+				Name:      "build",
+				Status:    godo.DeploymentProgressStepStatus_Error,
+				StartedAt: start.Add(time.Hour + time.Minute),
+				EndedAt:   start.Add(time.Hour + 2*time.Minute),
+				Reason:    &godo.DeploymentProgressStepReason{Message: "build failed"},
+			}}},
+			Phase:              godo.DeploymentPhase_Error,
+			PhaseLastUpdatedAt: start.Add(time.Hour + 3*time.Minute),
+		},
+		{
+			ID:                 "d1",
+			Spec:               specV1,
+			Cause:              "initial deployment",
+			CreatedAt:          start,
+			UpdatedAt:          start.Add(time.Minute),
+			Phase:              godo.DeploymentPhase_Active,
+			PhaseLastUpdatedAt: start.Add(time.Minute),
+		},
+	}
+	forEach := func(appID string, fn func(*godo.Deployment) error) error {
+		for _, d := range deployments {
+			if err := fn(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t.Run("all", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			tm.apps.EXPECT().ForEachDeployment(appID, gomock.Any()).Times(1).DoAndReturn(forEach)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgFormat, "DeploymentID,Detail,Type")
+
+			err := RunAppsEvents(config)
+			require.NoError(t, err)
+			assert.Equal(t, `Deployment ID    Detail                 Event
+d1               initial deployment     created
+d1               ACTIVE                 phase
+d2               app spec updated       created
+d2                                      spec updated
+d2                                      build started
+d2               ERROR: build failed    build ended
+d2               ERROR                  phase
+d3               manual                 created
+d3               PENDING_BUILD          phase
+`, buf.String())
+		})
+	})
+
+	t.Run("since", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			tm.apps.EXPECT().ForEachDeployment(appID, gomock.Any()).Times(1).DoAndReturn(forEach)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgFormat, "DeploymentID,Type")
+			config.Doit.Set(config.NS, doctl.ArgAppLogSince, start.Add(time.Hour+time.Minute).Format(time.RFC3339))
+
+			err := RunAppsEvents(config)
+			require.NoError(t, err)
+			assert.Equal(t, `Deployment ID    Event
+d2               build started
+d2               build ended
+d2               phase
+d3               created
+d3               phase
+`, buf.String())
+		})
+	})
+}
+
+func TestRunAppsListDeploymentsJSONStream(t *testing.T) {
+	defer func(o string) { Output = o }(Output)
+	Output = "json"
+
+	deployments := []*godo.Deployment{{ID: "d1"}, {ID: "d2"}, {ID: "d3"}}
+	forEach := func(appID string, fn func(*godo.Deployment) error) error {
+		for _, d := range deployments {
+			if err := fn(d); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t.Run("all", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			tm.apps.EXPECT().ForEachDeployment(appID, gomock.Any()).Times(1).DoAndReturn(forEach)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID)
+
+			err := RunAppsListDeployments(config)
+			require.NoError(t, err)
+
+			var expected bytes.Buffer
+			require.NoError(t, displayers.Deployments(deployments).JSON(&expected))
+			assert.Equal(t, expected.String(), buf.String())
+		})
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			tm.apps.EXPECT().ForEachDeployment(appID, gomock.Any()).Times(1).DoAndReturn(forEach)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgLimit, 2)
+
+			err := RunAppsListDeployments(config)
+			require.NoError(t, err)
+
+			var got []*godo.Deployment
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &got))
+			assert.Equal(t, deployments[:2], got)
+		})
+	})
+}
+
+func TestRunAppsListDeploymentsPaginated(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		deployments := []*godo.Deployment{
+			{ID: "d1", Spec: &testAppSpec, Progress: &godo.DeploymentProgress{}},
+			{ID: "d2", Spec: &testAppSpec, Progress: &godo.DeploymentProgress{}},
+			{ID: "d3", Spec: &testAppSpec, Progress: &godo.DeploymentProgress{}},
+		}
+
+		tm.apps.EXPECT().ListDeploymentsPage(appID, 2, 3).Times(1).Return(deployments, nil)
+
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, appID)
+		config.Doit.Set(config.NS, doctl.ArgPage, 2)
+		config.Doit.Set(config.NS, doctl.ArgPerPage, 3)
+		config.Doit.Set(config.NS, doctl.ArgLimit, 2)
+
+		err := RunAppsListDeployments(config)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "d1")
+		assert.Contains(t, buf.String(), "d2")
+		assert.NotContains(t, buf.String(), "d3")
+	})
+}
+
+func TestRunAppsListDeploymentsActiveOnly(t *testing.T) {
+	appID := uuid.New().String()
+	app := &godo.App{
+		ID:                   appID,
+		Spec:                 &testAppSpec,
+		ActiveDeployment:     &godo.Deployment{ID: "active-deployment", Spec: &testAppSpec, Progress: &godo.DeploymentProgress{}},
+		InProgressDeployment: &godo.Deployment{ID: "pending-deployment", Spec: &testAppSpec, Progress: &godo.DeploymentProgress{}},
+	}
+
+	tests := []struct {
+		name       string
+		activeOnly bool
+		inProgress bool
+		want       []string
+		notWant    []string
+	}{
+		{name: "active only", activeOnly: true, want: []string{"active-deployment"}, notWant: []string{"pending-deployment"}},
+		{name: "in progress", inProgress: true, want: []string{"pending-deployment"}, notWant: []string{"active-deployment"}},
+		{name: "both", activeOnly: true, inProgress: true, want: []string{"active-deployment", "pending-deployment"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+				tm.apps.EXPECT().Get(appID).Times(1).Return(app, nil)
+
+				var buf bytes.Buffer
+				config.Out = &buf
+				config.Args = append(config.Args, appID)
+				config.Doit.Set(config.NS, doctl.ArgAppActiveOnly, tt.activeOnly)
+				config.Doit.Set(config.NS, doctl.ArgAppInProgress, tt.inProgress)
+
+				err := RunAppsListDeployments(config)
+				require.NoError(t, err)
+				for _, id := range tt.want {
+					assert.Contains(t, buf.String(), id)
+				}
+				for _, id := range tt.notWant {
+					assert.NotContains(t, buf.String(), id)
+				}
+			})
+		})
+	}
+}
+
+func TestRunAppsCancelDeployment(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		deployment := &godo.Deployment{
+			ID:       uuid.New().String(),
+			Spec:     &testAppSpec,
+			Cause:    "Manual",
+			Phase:    godo.DeploymentPhase_Building,
+			Progress: &godo.DeploymentProgress{},
+		}
+		canceledDeployment := &godo.Deployment{
+			ID:       deployment.ID,
+			Spec:     &testAppSpec,
+			Cause:    "Manual",
+			Phase:    godo.DeploymentPhase_Canceled,
+			Progress: &godo.DeploymentProgress{},
+		}
+
+		tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(deployment, nil)
+		tm.apps.EXPECT().CancelDeployment(appID, deployment.ID).Times(1).Return(canceledDeployment, nil)
+
+		config.Args = append(config.Args, appID, deployment.ID)
+		config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+		err := RunAppsCancelDeployment(config)
+		require.NoError(t, err)
+	})
+
+	t.Run("terminal phase", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			deployment := &godo.Deployment{
+				ID:    uuid.New().String(),
+				Phase: godo.DeploymentPhase_Active,
+			}
+
+			tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(deployment, nil)
+
+			config.Args = append(config.Args, appID, deployment.ID)
+			config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+			err := RunAppsCancelDeployment(config)
+			require.EqualError(t, err, "deployment "+deployment.ID+" cannot be canceled; it is already in the terminal phase [ACTIVE]")
+		})
+	})
+}
+
+func TestRunAppsRollback(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
 		appID := uuid.New().String()
-		deployments := []*godo.Deployment{{
+		deployment := &godo.Deployment{
+			ID:   uuid.New().String(),
+			Spec: &testAppSpec,
+		}
+		app := &godo.App{
+			ID:   appID,
+			Spec: validAppSpec,
+		}
+
+		tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(deployment, nil)
+		tm.apps.EXPECT().Get(appID).Times(1).Return(app, nil)
+		tm.apps.EXPECT().Update(appID, &godo.AppUpdateRequest{Spec: &testAppSpec}).Times(1).Return(&godo.App{ID: appID, Spec: &testAppSpec}, nil)
+
+		config.Args = append(config.Args, appID, deployment.ID)
+		config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+		err := RunAppsRollback(config)
+		require.NoError(t, err)
+	})
+
+	t.Run("waits for the rollback deployment", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			target := &godo.Deployment{ID: uuid.New().String(), Spec: &testAppSpec}
+			previous := &godo.Deployment{ID: uuid.New().String(), Phase: godo.DeploymentPhase_Active}
+			rollback := &godo.Deployment{ID: uuid.New().String(), Phase: godo.DeploymentPhase_Active}
+			current := &godo.App{ID: appID, Spec: validAppSpec, ActiveDeployment: previous}
+			updated := &godo.App{ID: appID, Spec: &testAppSpec, ActiveDeployment: previous}
+			rolledBack := &godo.App{ID: appID, Spec: &testAppSpec, ActiveDeployment: rollback}
+
+			gomock.InOrder(
+				tm.apps.EXPECT().GetDeployment(appID, target.ID).Times(1).Return(target, nil),
+				tm.apps.EXPECT().Get(appID).Times(1).Return(current, nil),
+				// The update response only has the deployment from before the
+				// rollback, which must not be mistaken for the rollback.
+				tm.apps.EXPECT().Update(appID, &godo.AppUpdateRequest{Spec: &testAppSpec}).Times(1).Return(updated, nil),
+				tm.apps.EXPECT().Get(appID).Times(2).Return(updated, nil),
+				tm.apps.EXPECT().Get(appID).Times(1).Return(rolledBack, nil),
+				tm.apps.EXPECT().GetDeployment(appID, rollback.ID).Times(1).Return(rollback, nil),
+				tm.apps.EXPECT().Get(appID).Times(1).Return(rolledBack, nil),
+			)
+
+			config.Args = append(config.Args, appID, target.ID)
+			config.Doit.Set(config.NS, doctl.ArgForce, true)
+			config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+			config.Doit.Set(config.NS, doctl.ArgPollInterval, time.Millisecond)
+
+			err := RunAppsRollback(config)
+			require.NoError(t, err)
+		})
+	})
+}
+
+func TestAppSpecRemovals(t *testing.T) {
+	current := &godo.AppSpec{
+		Name:      "test",
+		Services:  []*godo.AppServiceSpec{{Name: "web"}, {Name: "api"}},
+		Databases: []*godo.AppDatabaseSpec{{Name: "db"}},
+		Domains:   []*godo.AppDomainSpec{{Domain: "example.com"}},
+	}
+	target := &godo.AppSpec{
+		Name:     "test",
+		Services: []*godo.AppServiceSpec{{Name: "web"}},
+	}
+
+	assert.Equal(t, []string{"component api", "database db", "domain example.com"}, appSpecRemovals(current, target))
+	assert.Empty(t, appSpecRemovals(target, current))
+}
+
+func TestAppComponentChecklist(t *testing.T) {
+	deployment := &godo.Deployment{
+		Phase: godo.DeploymentPhase_Deploying,
+		Progress: &godo.DeploymentProgress{Steps: []*godo.DeploymentProgressStep{{
+			Name:   "deploy",
+			Status: godo.DeploymentProgressStepStatus_Running,
+			Steps: []*godo.DeploymentProgressStep{
+				{
+					Name:          "deploy",
+					ComponentName: "web",
+					Status:        godo.DeploymentProgressStepStatus_Running,
+					Steps: []*godo.DeploymentProgressStep{
+						{Name: "initialize", Status: godo.DeploymentProgressStepStatus_Success},
+						{Name: "components", Status: godo.DeploymentProgressStepStatus_Running},
+					},
+				},
+				{Name: "deploy", ComponentName: "api", Status: godo.DeploymentProgressStepStatus_Success},
+				{Name: "deploy", ComponentName: "worker", Status: godo.DeploymentProgressStepStatus_Error},
+				{Name: "deploy", ComponentName: "cron", Status: godo.DeploymentProgressStepStatus_Pending},
+			},
+		}}},
+	}
+
+	assert.Equal(t, `Deploying
+  [ ] web     restarting
+  [x] api     healthy
+  [!] worker  failed
+  [ ] cron    pending`, appComponentChecklist(deployment))
+}
+
+func TestRunAppsRestart(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		deployment := &godo.Deployment{
+			ID:       uuid.New().String(),
+			Spec:     &testAppSpec,
+			Cause:    "Manual",
+			Progress: &godo.DeploymentProgress{},
+		}
+
+		tm.apps.EXPECT().Restart(appID, []string{"service"}).Times(1).Return(deployment, nil)
+
+		config.Args = append(config.Args, appID)
+		config.Doit.Set(config.NS, doctl.ArgAppComponents, []string{"service"})
+
+		err := RunAppsRestart(config)
+		require.NoError(t, err)
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		defer func(a io.Writer) { color.Output = a }(color.Output)
+		var buf bytes.Buffer
+		color.Output = &buf
+
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			deployment := &godo.Deployment{
+				ID:    uuid.New().String(),
+				Spec:  &testAppSpec,
+				Phase: godo.DeploymentPhase_Error,
+				Progress: &godo.DeploymentProgress{Steps: []*godo.DeploymentProgressStep{{
+					Name:   "deploy",
+					Status: godo.DeploymentProgressStepStatus_Error,
+					Steps: []*godo.DeploymentProgressStep{
+						{Name: "deploy", ComponentName: "web", Status: godo.DeploymentProgressStepStatus_Success},
+						{Name: "deploy", ComponentName: "worker", Status: godo.DeploymentProgressStepStatus_Error},
+					},
+				}}},
+			}
+
+			tm.apps.EXPECT().Restart(appID, nil).Times(1).Return(deployment, nil)
+			tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(deployment, nil)
+
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgCommandWait, true)
+
+			err := RunAppsRestart(config)
+			require.Error(t, err)
+			assert.Contains(t, buf.String(), "Warning: Component worker is failed\n")
+			assert.Contains(t, buf.String(), "Notice: 1 of 2 components are healthy: web\n")
+		})
+	})
+
+	t.Run("restart not supported", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			deployment := &godo.Deployment{
+				ID:       uuid.New().String(),
+				Spec:     &testAppSpec,
+				Cause:    "Manual",
+				Progress: &godo.DeploymentProgress{},
+			}
+			notFound := &godo.ErrorResponse{
+				Response: &http.Response{StatusCode: http.StatusNotFound},
+				Message:  "not found",
+			}
+
+			tm.apps.EXPECT().Restart(appID, nil).Times(1).Return(nil, notFound)
+			tm.apps.EXPECT().CreateDeployment(appID, false).Times(1).Return(deployment, nil)
+
+			config.Args = append(config.Args, appID)
+
+			err := RunAppsRestart(config)
+			require.NoError(t, err)
+		})
+	})
+}
+
+func TestRunAppsGetLogs(t *testing.T) {
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	component := "service"
+
+	types := map[string]godo.AppLogType{
+		"build":  godo.AppLogTypeBuild,
+		"deploy": godo.AppLogTypeDeploy,
+		"run":    godo.AppLogTypeRun,
+	}
+
+	for typeStr, logType := range types {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, component, logType, true).Times(1).Return(&godo.AppLogs{LiveURL: "https://proxy-apps-prod-ams3-001.ondigitalocean.app/?token=aa-bb-11-cc-33"}, nil)
+			tm.listen.EXPECT().Start().Times(1).Return(nil)
+
+			tc := config.Doit.(*doctl.TestConfig)
+			tc.ListenFn = func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService {
+				assert.Equal(t, token, "aa-bb-11-cc-33")
+				assert.Equal(t, url.String(), "wss://proxy-apps-prod-ams3-001.ondigitalocean.app/?token=aa-bb-11-cc-33")
+				return tm.listen
+			}
+
+			config.Args = append(config.Args, appID, component)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, typeStr)
+			config.Doit.Set(config.NS, doctl.ArgAppLogFollow, true)
+			config.Doit.Set(config.NS, doctl.ArgAppLogNoReconnect, true)
+
+			err := RunAppsGetLogs(config)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestRunAppsGetLogsJSONRaw(t *testing.T) {
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+
+	t.Run("app logs", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, "", godo.AppLogTypeRun, true).Times(1).Return(&godo.AppLogs{LiveURL: "https://logs.example.com/?token=aa"}, nil)
+			tm.listen.EXPECT().Start().Times(1).Return(nil)
+
+			var frames []string
+			tc := config.Doit.(*doctl.TestConfig)
+			tc.ListenFn = func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService {
+				for _, message := range []string{`{"data":"line\n","pod":"web-1"}`, "{\"data\":\"line\\n\"}\n"} {
+					r, err := schemaFunc([]byte(message))
+					require.NoError(t, err)
+					b, err := ioutil.ReadAll(r)
+					require.NoError(t, err)
+					frames = append(frames, string(b))
+				}
+				return tm.listen
+			}
+
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+			config.Doit.Set(config.NS, doctl.ArgAppLogFollow, true)
+			config.Doit.Set(config.NS, doctl.ArgAppLogNoReconnect, true)
+			config.Doit.Set(config.NS, doctl.ArgAppLogJSONRaw, true)
+
+			err := RunAppsGetLogs(config)
+			require.NoError(t, err)
+			assert.Equal(t, []string{`{"data":"line\n","pod":"web-1"}` + "\n", "{\"data\":\"line\\n\"}\n"}, frames)
+		})
+	})
+
+	t.Run("without follow", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+			config.Doit.Set(config.NS, doctl.ArgAppLogJSONRaw, true)
+
+			err := RunAppsGetLogs(config)
+			assert.EqualError(t, err, "--json-raw can only be used with --follow")
+		})
+	})
+}
+
+func TestRunAppsGetLogsReconnect(t *testing.T) {
+	minBackoff, maxBackoff := appLogsReconnectMinBackoff, appLogsReconnectMaxBackoff
+	appLogsReconnectMinBackoff, appLogsReconnectMaxBackoff = time.Millisecond, 2*time.Millisecond
+	defer func() {
+		appLogsReconnectMinBackoff, appLogsReconnectMaxBackoff = minBackoff, maxBackoff
+	}()
+
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	component := "service"
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		gomock.InOrder(
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, true).Times(1).Return(&godo.AppLogs{LiveURL: "https://logs.example.com/?token=first"}, nil),
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, true).Times(1).Return(&godo.AppLogs{LiveURL: "https://logs.example.com/?token=second"}, nil),
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, true).Times(1).Return(&godo.AppLogs{}, nil),
+		)
+		gomock.InOrder(
+			tm.listen.EXPECT().Start().Times(1).Return(errors.New("connection reset")),
+			tm.listen.EXPECT().Start().Times(1).Return(nil),
+		)
+
+		var tokens []string
+		tc := config.Doit.(*doctl.TestConfig)
+		tc.ListenFn = func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService {
+			tokens = append(tokens, token)
+			return tm.listen
+		}
+
+		config.Args = append(config.Args, appID, component)
+		config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+		config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+		config.Doit.Set(config.NS, doctl.ArgAppLogFollow, true)
+
+		err := RunAppsGetLogs(config)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"first", "second"}, tokens)
+	})
+}
+
+func TestRunAppsGetLogsFollowAllComponents(t *testing.T) {
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	spec := &godo.AppSpec{
+		Name:     "test",
+		Services: []*godo.AppServiceSpec{{Name: "web"}},
+		Workers:  []*godo.AppWorkerSpec{{Name: "queue"}, {Name: "broken"}},
+	}
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.apps.EXPECT().GetDeployment(appID, deploymentID).Times(1).Return(&godo.Deployment{ID: deploymentID, Spec: spec}, nil)
+		tm.apps.EXPECT().GetLogs(appID, deploymentID, "web", godo.AppLogTypeRun, true).Times(1).Return(&godo.AppLogs{LiveURL: "https://logs.example.com/?token=web"}, nil)
+		tm.apps.EXPECT().GetLogs(appID, deploymentID, "queue", godo.AppLogTypeRun, true).Times(1).Return(&godo.AppLogs{LiveURL: "https://logs.example.com/?token=queue"}, nil)
+		tm.apps.EXPECT().GetLogs(appID, deploymentID, "broken", godo.AppLogTypeRun, true).Times(1).Return(nil, errors.New("boom"))
+		tm.listen.EXPECT().Start().Times(2).Return(nil)
+
+		tc := config.Doit.(*doctl.TestConfig)
+		tc.ListenFn = func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService {
+			fmt.Fprintf(out, "hello from %s\n", token)
+			return tm.listen
+		}
+
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, appID)
+		config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+		config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+		config.Doit.Set(config.NS, doctl.ArgAppLogFollow, true)
+		config.Doit.Set(config.NS, doctl.ArgAppLogNoReconnect, true)
+
+		err := RunAppsGetLogs(config)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "web    | hello from web\n")
+		assert.Contains(t, buf.String(), "queue  | hello from queue\n")
+		assert.NotContains(t, buf.String(), "broken")
+	})
+}
+
+func TestRunAppsConsole(t *testing.T) {
+	appID := uuid.New().String()
+	execURL := "wss://exec.example.com/?token=aa-bb-11-cc-33"
+
+	t.Run("command", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().GetExec(appID, "web").Times(1).Return(&do.AppExec{URL: execURL}, nil)
+
+			var input []byte
+			tc := config.Doit.(*doctl.TestConfig)
+			tc.ListenFn = func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService {
+				assert.Equal(t, "aa-bb-11-cc-33", token)
+				assert.Equal(t, execURL, url.String())
+
+				r, err := schemaFunc([]byte(`{"data":"hello\n"}`))
+				require.NoError(t, err)
+				io.Copy(out, r)
+
+				tm.listen.EXPECT().Start().Times(1).DoAndReturn(func() error {
+					input = <-inputCh
+					return nil
+				})
+				return tm.listen
+			}
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID, "web")
+			config.Doit.Set(config.NS, doctl.ArgAppConsoleCommand, "echo a, b")
+
+			err := RunAppsConsole(config)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"op":"stdin","data":"echo a, b\nexit\n"}`, string(input))
+			assert.Equal(t, "hello\n", buf.String())
+		})
+	})
+
+	t.Run("interactive", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			defer func(f *os.File) { appConsoleStdin = f }(appConsoleStdin)
+			r, w, err := os.Pipe()
+			require.NoError(t, err)
+			defer r.Close()
+			_, err = w.WriteString("ls\n")
+			require.NoError(t, err)
+			w.Close()
+			appConsoleStdin = r
+
+			tm.apps.EXPECT().GetExec(appID, "web").Times(1).Return(&do.AppExec{URL: execURL}, nil)
+
+			var input []byte
+			tc := config.Doit.(*doctl.TestConfig)
+			tc.ListenFn = func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService {
+				tm.listen.EXPECT().Start().Times(1).DoAndReturn(func() error {
+					input = <-inputCh
+					return nil
+				})
+				return tm.listen
+			}
+
+			config.Args = append(config.Args, appID, "web")
+
+			err = RunAppsConsole(config)
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"op":"stdin","data":"ls\n"}`, string(input))
+		})
+	})
+}
+
+func TestRunAppsGetLogsHistoric(t *testing.T) {
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	component := "service"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s\n", r.URL.Path)
+	}))
+	defer server.Close()
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+			HistoricURLs: []string{server.URL + "/first", server.URL + "/second"},
+		}, nil)
+
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, appID, component)
+		config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+		config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+
+		err := RunAppsGetLogs(config)
+		require.NoError(t, err)
+		assert.Equal(t, "/first\n/second\n", buf.String())
+	})
+}
+
+func TestRunAppsGetLogsHistoricExpiredURL(t *testing.T) {
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	component := "service"
+
+	// URLs are valid once the token is fresh, like signed URLs that expire.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") != "fresh" {
+			http.Error(w, "<Error>AccessDenied</Error>", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, "%s\n", r.URL.Path)
+	}))
+	defer server.Close()
+
+	t.Run("retries with fresh urls", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			gomock.InOrder(
+				tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+					HistoricURLs: []string{server.URL + "/first?token=expired"},
+				}, nil),
+				tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+					HistoricURLs: []string{server.URL + "/first?token=fresh"},
+				}, nil),
+			)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID, component)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+
+			err := RunAppsGetLogs(config)
+			require.NoError(t, err)
+			assert.Equal(t, "/first\n", buf.String())
+		})
+	})
+
+	t.Run("persistent failure", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, false).Times(2).Return(&godo.AppLogs{
+				HistoricURLs: []string{server.URL + "/first?token=expired"},
+			}, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID, component)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+
+			err := RunAppsGetLogs(config)
+			assert.EqualError(t, err, "unable to download logs: server responded with 403 Forbidden")
+			assert.Empty(t, buf.String())
+		})
+	})
+}
+
+func TestRunAppsGetLogsHistoricGzip(t *testing.T) {
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	component := "service"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/encoded":
+			assert.Equal(t, "gzip", r.Header.Get("Accept-Encoding"))
+			w.Header().Set("Content-Encoding", "gzip")
+			gz := gzip.NewWriter(w)
+			fmt.Fprint(gz, "encoded\n")
+			gz.Close()
+		case "/stored.gz":
+			w.Header().Set("Content-Type", "application/gzip")
+			gz := gzip.NewWriter(w)
+			fmt.Fprint(gz, "stored\n")
+			gz.Close()
+		default:
+			fmt.Fprint(w, "plain\n")
+		}
+	}))
+	defer server.Close()
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+			HistoricURLs: []string{server.URL + "/encoded", server.URL + "/stored.gz", server.URL + "/plain"},
+		}, nil)
+
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, appID, component)
+		config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+		config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+
+		err := RunAppsGetLogs(config)
+		require.NoError(t, err)
+		assert.Equal(t, "encoded\nstored\nplain\n", buf.String())
+	})
+}
+
+func TestRunAppsGetLogsTail(t *testing.T) {
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	component := "service"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s-1\n%s-2\n", r.URL.Path, r.URL.Path)
+	}))
+	defer server.Close()
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+			HistoricURLs: []string{server.URL + "/first", server.URL + "/second"},
+		}, nil)
+
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, appID, component)
+		config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+		config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+		config.Doit.Set(config.NS, doctl.ArgAppLogTail, 3)
+
+		err := RunAppsGetLogs(config)
+		require.NoError(t, err)
+		assert.Equal(t, "/first-2\n/second-1\n/second-2\n", buf.String())
+	})
+}
+
+func TestRunAppsGetLogsGrep(t *testing.T) {
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	component := "service"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "GET / 200\nGET /health 200\nGET /missing 404\nerror: timeout\n")
+	}))
+	defer server.Close()
+
+	t.Run("historic", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+				HistoricURLs: []string{server.URL},
+			}, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID, component)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+			config.Doit.Set(config.NS, doctl.ArgAppLogGrep, "^GET")
+			config.Doit.Set(config.NS, doctl.ArgAppLogGrepInvert, "/health")
+			config.Doit.Set(config.NS, doctl.ArgAppLogTail, 1)
+
+			err := RunAppsGetLogs(config)
+			require.NoError(t, err)
+			assert.Equal(t, "GET /missing 404\n", buf.String())
+		})
+	})
+
+	t.Run("follow", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, true).Times(1).Return(&godo.AppLogs{LiveURL: "https://logs.example.com/?token=live"}, nil)
+			tm.listen.EXPECT().Start().Times(1).Return(nil)
+
+			tc := config.Doit.(*doctl.TestConfig)
+			tc.ListenFn = func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService {
+				fmt.Fprint(out, "GET / 200\nerror: timeout\nerror: refused")
+				return tm.listen
+			}
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID, component)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+			config.Doit.Set(config.NS, doctl.ArgAppLogFollow, true)
+			config.Doit.Set(config.NS, doctl.ArgAppLogNoReconnect, true)
+			config.Doit.Set(config.NS, doctl.ArgAppLogGrep, "^error")
+
+			err := RunAppsGetLogs(config)
+			require.NoError(t, err)
+			assert.Equal(t, "error: timeout\nerror: refused\n", buf.String())
+		})
+	})
+
+	t.Run("invalid pattern", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, appID, component)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+			config.Doit.Set(config.NS, doctl.ArgAppLogGrep, "(")
+
+			err := RunAppsGetLogs(config)
+			require.EqualError(t, err, "invalid --grep pattern: error parsing regexp: missing closing ): `(`")
+		})
+	})
+}
+
+func TestRunAppsGetLogsColor(t *testing.T) {
+	defer func(noColor bool) { color.NoColor = noColor }(color.NoColor)
+
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	component := "service"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "INFO started\nWARN slow request\nERROR timeout\nlistening\n")
+	}))
+	defer server.Close()
+
+	colored := "\x1b[36mINFO started\x1b[0m\n\x1b[33mWARN slow request\x1b[0m\n\x1b[31mERROR timeout\x1b[0m\nlistening\n"
+	plain := "INFO started\nWARN slow request\nERROR timeout\nlistening\n"
+
+	tcs := []struct {
+		mode     string
+		noColor  bool
+		expected string
+	}{
+		{mode: "auto", noColor: false, expected: colored},
+		{mode: "auto", noColor: true, expected: plain},
+		{mode: "never", noColor: false, expected: plain},
+		{mode: "always", noColor: true, expected: colored},
+	}
+
+	for _, tc := range tcs {
+		t.Run(fmt.Sprintf("%s noColor=%v", tc.mode, tc.noColor), func(t *testing.T) {
+			color.NoColor = tc.noColor
+
+			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+				tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+					HistoricURLs: []string{server.URL},
+				}, nil)
+
+				var buf bytes.Buffer
+				config.Out = &buf
+				config.Args = append(config.Args, appID, component)
+				config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+				config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+				config.Doit.Set(config.NS, doctl.ArgAppLogColor, tc.mode)
+
+				err := RunAppsGetLogs(config)
+				require.NoError(t, err)
+				assert.Equal(t, tc.expected, buf.String())
+			})
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, appID, component)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+			config.Doit.Set(config.NS, doctl.ArgAppLogColor, "sometimes")
+
+			err := RunAppsGetLogs(config)
+			require.EqualError(t, err, `invalid --color value "sometimes", must be one of: auto, always, never`)
+		})
+	})
+}
+
+func TestRunAppsGetLogsJSON(t *testing.T) {
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	component := "service"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "service 2021-03-01T12:00:00.000000000Z listening on :8080\nno timestamp here\n")
+	}))
+	defer server.Close()
+
+	tcs := []struct {
+		name   string
+		format string
+		output string
+	}{
+		{name: "format flag", format: "json", output: "text"},
+		{name: "output flag", output: "json"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+				defer func(o string) { Output = o }(Output)
+				Output = tc.output
+
+				tm.apps.EXPECT().GetLogs(appID, deploymentID, component, godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+					HistoricURLs: []string{server.URL},
+				}, nil)
+
+				var buf bytes.Buffer
+				config.Out = &buf
+				config.Args = append(config.Args, appID, component)
+				config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+				config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+				config.Doit.Set(config.NS, doctl.ArgFormat, tc.format)
+
+				err := RunAppsGetLogs(config)
+				require.NoError(t, err)
+				assert.Equal(t, `{"component":"service","type":"run","time":"2021-03-01T12:00:00.000000000Z","message":"listening on :8080"}
+{"component":"service","type":"run","message":"no timestamp here"}
+`, buf.String())
+			})
+		})
+	}
+}
+
+func TestTailLines(t *testing.T) {
+	tcs := []struct {
+		data string
+		n    int
+		want string
+	}{
+		{data: "a\nb\nc\n", n: 2, want: "b\nc\n"},
+		{data: "a\nb\nc", n: 2, want: "b\nc"},
+		{data: "a\nb\nc\n", n: 5, want: "a\nb\nc\n"},
+		{data: "", n: 1, want: ""},
+	}
+
+	for _, tc := range tcs {
+		assert.Equal(t, tc.want, string(tailLines([]byte(tc.data), tc.n)))
+	}
+}
+
+func TestCopyAppLogsRequestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	viper.Set(doctl.ArgRequestTimeout, 50*time.Millisecond)
+	defer viper.Set(doctl.ArgRequestTimeout, nil)
+
+	err := copyAppLogs(ioutil.Discard, []string{server.URL}, nil, appLogFilter{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Client.Timeout")
+}
+
+func TestWindowLines(t *testing.T) {
+	data := `web 2021-03-01T10:00:00.000000000Z starting
+web 2021-03-01T11:00:00.000000000Z panic: boom
+goroutine 1 [running]:
+2021-03-01T12:00:00Z recovered
+web 2021-03-01T13:00:00.000000000Z shutting down
+`
+	since := time.Date(2021, 3, 1, 10, 30, 0, 0, time.UTC)
+	until := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, `web 2021-03-01T11:00:00.000000000Z panic: boom
+goroutine 1 [running]:
+2021-03-01T12:00:00Z recovered
+`, string(windowLines([]byte(data), since, until)))
+	assert.Equal(t, `web 2021-03-01T10:00:00.000000000Z starting
+`, string(windowLines([]byte(data), time.Time{}, since)))
+}
+
+func TestParseAppLogTime(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	ts, err := parseAppLogTime("since", "2021-03-01T09:30:00Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 3, 1, 9, 30, 0, 0, time.UTC), ts)
+
+	ts, err = parseAppLogTime("since", "90m", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2021, 3, 1, 10, 30, 0, 0, time.UTC), ts)
+
+	_, err = parseAppLogTime("since", "yesterday", now)
+	assert.EqualError(t, err, `invalid --since value "yesterday": expected an RFC3339 timestamp like 2021-03-01T15:04:05Z or a duration like 1h30m`)
+}
+
+func TestRunAppsGetLogsOutputDir(t *testing.T) {
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s\n", r.URL.Path)
+	}))
+	defer server.Close()
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		dir := filepath.Join(t.TempDir(), "logs")
+
+		tm.apps.EXPECT().GetDeployment(appID, deploymentID).Times(1).Return(&godo.Deployment{
+			ID:   deploymentID,
+			Spec: &testAppSpec,
+		}, nil)
+		tm.apps.EXPECT().GetLogs(appID, deploymentID, "service", godo.AppLogTypeBuild, false).Times(1).Return(&godo.AppLogs{
+			HistoricURLs: []string{server.URL + "/service-1", server.URL + "/service-2"},
+		}, nil)
+
+		config.Args = append(config.Args, appID)
+		config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+		config.Doit.Set(config.NS, doctl.ArgAppLogType, "build")
+		config.Doit.Set(config.NS, doctl.ArgAppLogOutputDir, dir)
+
+		err := RunAppsGetLogs(config)
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, "service-build.log"))
+		require.NoError(t, err)
+		assert.Equal(t, "/service-1\n/service-2\n", string(data))
+	})
+}
+
+func TestRunAppsGetLogsComponents(t *testing.T) {
+	appID := uuid.New().String()
+	deploymentID := uuid.New().String()
+	spec := &godo.AppSpec{
+		Name:     "test",
+		Services: []*godo.AppServiceSpec{{Name: "web"}},
+		Workers:  []*godo.AppWorkerSpec{{Name: "worker"}},
+		Jobs:     []*godo.AppJobSpec{{Name: "migrate"}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "line from %s\n", strings.TrimPrefix(r.URL.Path, "/"))
+	}))
+	defer server.Close()
+
+	t.Run("historic", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().GetDeployment(appID, deploymentID).Times(1).Return(&godo.Deployment{ID: deploymentID, Spec: spec}, nil)
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, "worker", godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+				HistoricURLs: []string{server.URL + "/worker"},
+			}, nil)
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, "web", godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+				HistoricURLs: []string{server.URL + "/web"},
+			}, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+			config.Doit.Set(config.NS, doctl.ArgAppLogComponents, []string{"worker", "web", "worker"})
+
+			err := RunAppsGetLogs(config)
+			require.NoError(t, err)
+			assert.Equal(t, "worker | line from worker\nweb    | line from web\n", buf.String())
+		})
+	})
+
+	t.Run("historic merged by timestamp", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/web":
+				fmt.Fprint(w, "web 2021-03-01T12:00:01Z started\nweb 2021-03-01T12:00:03Z panic: boom\n  at main.go:1\n")
+			case "/worker":
+				fmt.Fprint(w, "worker 2021-03-01T12:00:00Z started\nworker 2021-03-01T12:00:02Z working\nworker 2021-03-01T12:00:04Z done\n")
+			}
+		}))
+		defer server.Close()
+
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().GetDeployment(appID, deploymentID).Times(1).Return(&godo.Deployment{ID: deploymentID, Spec: spec}, nil)
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, "web", godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+				HistoricURLs: []string{server.URL + "/web"},
+			}, nil)
+			tm.apps.EXPECT().GetLogs(appID, deploymentID, "worker", godo.AppLogTypeRun, false).Times(1).Return(&godo.AppLogs{
+				HistoricURLs: []string{server.URL + "/worker"},
+			}, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+			config.Doit.Set(config.NS, doctl.ArgAppLogComponents, []string{"web", "worker"})
+
+			err := RunAppsGetLogs(config)
+			require.NoError(t, err)
+			assert.Equal(t, `worker | worker 2021-03-01T12:00:00Z started
+web    | web 2021-03-01T12:00:01Z started
+worker | worker 2021-03-01T12:00:02Z working
+web    | web 2021-03-01T12:00:03Z panic: boom
+web    |   at main.go:1
+worker | worker 2021-03-01T12:00:04Z done
+`, buf.String())
+		})
+	})
+
+	t.Run("unknown component", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().GetDeployment(appID, deploymentID).Times(1).Return(&godo.Deployment{ID: deploymentID, Spec: spec}, nil)
+
+			config.Args = append(config.Args, appID)
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
+			config.Doit.Set(config.NS, doctl.ArgAppLogType, "run")
+			config.Doit.Set(config.NS, doctl.ArgAppLogComponents, []string{"web", "api"})
+
+			err := RunAppsGetLogs(config)
+			require.Error(t, err)
+			assert.Equal(t, fmt.Sprintf(`component "api" not found in deployment %s; valid components are: web, worker, migrate`, deploymentID), err.Error())
+		})
+	})
+
+	t.Run("with component argument", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, appID, "web")
+			config.Doit.Set(config.NS, doctl.ArgAppLogComponents, []string{"worker"})
+
+			err := RunAppsGetLogs(config)
+			require.Error(t, err)
+		})
+	})
+}
+
+const (
+	validJSONSpec = `{
+	"name": "test",
+	"services": [
+		{
+			"name": "web",
+			"github": {
+				"repo": "digitalocean/sample-golang",
+				"branch": "main"
+			}
+		}
+	],
+	"static_sites": [
+		{
+			"name": "static",
+			"git": {
+				"repo_clone_url": "git@github.com:digitalocean/sample-gatsby.git",
+				"branch": "main"
+			},
+			"routes": [
+				{
+				"path": "/static"
+				}
+			]
+		}
+	]
+}`
+	validYAMLSpec = `name: test
+services:
+- github:
+    branch: main
+    repo: digitalocean/sample-golang
+  name: web
+static_sites:
+- git:
+    branch: main
+    repo_clone_url: git@github.com:digitalocean/sample-gatsby.git
+  name: static
+  routes:
+  - path: /static
+`
+	unknownFieldSpec = `
+name: test
+bugField: bad
+services:
+- name: web
+  github:
+    repo: digitalocean/sample-golang
+    branch: main
+static_sites:
+- name: static
+  git:
+    repo_clone_url: git@github.com:digitalocean/sample-gatsby.git
+    branch: main
+  routes:
+  - path: /static
+`
+)
+
+var validAppSpec = &godo.AppSpec{
+	Name: "test",
+	Services: []*godo.AppServiceSpec{
+		{
+			Name: "web",
+			GitHub: &godo.GitHubSourceSpec{
+				Repo:   "digitalocean/sample-golang",
+				Branch: "main",
+			},
+		},
+	},
+	StaticSites: []*godo.AppStaticSiteSpec{
+		{
+			Name: "static",
+			Git: &godo.GitSourceSpec{
+				RepoCloneURL: "git@github.com:digitalocean/sample-gatsby.git",
+				Branch:       "main",
+			},
+			Routes: []*godo.AppRouteSpec{
+				{Path: "/static"},
+			},
+		},
+	},
+}
+
+func Test_parseAppSpec(t *testing.T) {
+	expectedSpec := validAppSpec
+
+	t.Run("json", func(t *testing.T) {
+		spec, err := parseAppSpec([]byte(validJSONSpec))
+		require.NoError(t, err)
+		assert.Equal(t, expectedSpec, spec)
+	})
+	t.Run("yaml", func(t *testing.T) {
+		spec, err := parseAppSpec([]byte(validYAMLSpec))
+		require.NoError(t, err)
+		assert.Equal(t, expectedSpec, spec)
+	})
+	t.Run("invalid", func(t *testing.T) {
+		_, err := parseAppSpec([]byte("invalid spec"))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrAppSpecSchema))
+	})
+	t.Run("syntax error", func(t *testing.T) {
+		_, err := parseAppSpec([]byte("name: [test"))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrAppSpecSyntax))
+		assert.False(t, errors.Is(err, ErrAppSpecSchema))
+	})
+	t.Run("unknown fields", func(t *testing.T) {
+		_, err := parseAppSpec([]byte(unknownFieldSpec))
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, ErrAppSpecSchema))
+		assert.False(t, errors.Is(err, ErrAppSpecSyntax))
+	})
+}
+
+func Test_readAppSpec(t *testing.T) {
+	tcs := []struct {
+		name  string
+		setup func(t *testing.T) (path string, stdin io.Reader)
+
+		wantSpec *godo.AppSpec
+		wantErr  error
+	}{
+		{
+			name: "stdin",
+			setup: func(t *testing.T) (string, io.Reader) {
+				return "-", bytes.NewBufferString(validYAMLSpec)
+			},
+			wantSpec: validAppSpec,
+		},
+		{
+			name: "file yaml",
+			setup: func(t *testing.T) (string, io.Reader) {
+				return testTempFile(t, []byte(validJSONSpec)), nil
+			},
+			wantSpec: validAppSpec,
+		},
+		{
+			name: "file in home dir",
+			setup: func(t *testing.T) (string, io.Reader) {
+				home := t.TempDir()
+				oldHome := os.Getenv("HOME")
+				os.Setenv("HOME", home)
+				t.Cleanup(func() { os.Setenv("HOME", oldHome) })
+
+				require.NoError(t, os.Mkdir(filepath.Join(home, "specs"), 0755))
+				require.NoError(t, ioutil.WriteFile(filepath.Join(home, "specs", "app.yaml"), []byte(validYAMLSpec), 0644))
+				return "~/specs/app.yaml", nil
+			},
+			wantSpec: validAppSpec,
+		},
+		{
+			name: "file json with comments",
+			setup: func(t *testing.T) (string, io.Reader) {
+				spec := "// generated\n" + strings.Replace(validJSONSpec, `"name": "test",`, `"name": "test", /* the app name */`, 1)
+				return testTempFile(t, []byte(spec)), nil
+			},
+			wantSpec: validAppSpec,
+		},
+		{
+			name: "url",
+			setup: func(t *testing.T) (string, io.Reader) {
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+					w.Write([]byte(validYAMLSpec))
+				}))
+				t.Cleanup(server.Close)
+				return server.URL + "/app.yaml", nil
+			},
+			wantSpec: validAppSpec,
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			path, stdin := tc.setup(t)
+			spec, err := readAppSpec(stdin, path)
+			if tc.wantErr != nil {
+				require.Equal(t, tc.wantErr, err)
+			} else {
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tc.wantSpec, spec)
+		})
+	}
+}
+
+func Test_stripJSONComments(t *testing.T) {
+	tcs := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "line comments",
+			in:   "// spec\n{\n  \"name\": \"test\" // name\n}",
+			want: "       \n{\n  \"name\": \"test\"        \n}",
+		},
+		{
+			name: "block comments",
+			in:   "{ /* a\nb */ \"name\": \"test\" }",
+			want: "{     \n     \"name\": \"test\" }",
+		},
+		{
+			name: "comment markers in strings",
+			in:   `{"repo_clone_url": "https://example.com/a/*b*/c", "name": "a\"//b"}`,
+			want: `{"repo_clone_url": "https://example.com/a/*b*/c", "name": "a\"//b"}`,
+		},
+		{
+			name: "yaml",
+			in:   "name: test\nstatic_sites:\n- git:\n    repo_clone_url: https://example.com/repo.git\n",
+			want: "name: test\nstatic_sites:\n- git:\n    repo_clone_url: https://example.com/repo.git\n",
+		},
+		{
+			name: "unterminated block comment",
+			in:   "{ /* name",
+			want: "{ /* name",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, string(stripJSONComments([]byte(tc.in))))
+		})
+	}
+}
+
+func Test_readAppSpecs(t *testing.T) {
+	base := testTempFile(t, []byte(`name: test
+region: nyc
+services:
+- name: web
+  github:
+    repo: digitalocean/sample-golang
+    branch: main
+envs:
+- key: LOG_LEVEL
+  value: info
+`))
+	overlay := testTempFile(t, []byte(`name: test-staging
+services:
+- name: web
+  github:
+    repo: digitalocean/sample-golang
+    branch: staging
+`))
+
+	spec, err := readAppSpecs(nil, []string{base, overlay})
+	require.NoError(t, err)
+	assert.Equal(t, &godo.AppSpec{
+		Name:   "test-staging",
+		Region: "nyc",
+		Services: []*godo.AppServiceSpec{{
+			Name: "web",
+			GitHub: &godo.GitHubSourceSpec{
+				Repo:   "digitalocean/sample-golang",
+				Branch: "staging",
+			},
+		}},
+		Envs: []*godo.AppVariableDefinition{{
+			Key:   "LOG_LEVEL",
+			Value: "info",
+		}},
+	}, spec)
+}
+
+func TestMergeAppSpecMaps(t *testing.T) {
+	dst := map[string]interface{}{
+		"name":   "base",
+		"nested": map[string]interface{}{"a": "1", "b": "2"},
+		"list":   []interface{}{"x", "y"},
+	}
+	mergeAppSpecMaps(dst, map[string]interface{}{
+		"nested": map[string]interface{}{"b": "3"},
+		"list":   []interface{}{"z"},
+	})
+
+	assert.Equal(t, map[string]interface{}{
+		"name":   "base",
+		"nested": map[string]interface{}{"a": "1", "b": "3"},
+		"list":   []interface{}{"z"},
+	}, dst)
+}
+
+func TestSetAppSpecPath(t *testing.T) {
+	tcs := []struct {
+		name          string
+		path          string
+		value         string
+		createMissing bool
+
+		want    interface{}
+		wantErr string
+	}{
+		{name: "int", path: "services.0.instance_count", value: "3", want: float64(3)},
+		{name: "bool", path: "services.0.github.deploy_on_push", value: "true", createMissing: true, want: true},
+		{name: "string", path: "services.0.github.branch", value: "staging", want: "staging"},
+		{name: "missing", path: "services.0.github.deploy_on_push", value: "true", wantErr: `path "services.0.github.deploy_on_push" does not exist in the app spec`},
+		{name: "index out of range", path: "services.1.name", value: "web", wantErr: `path "services.1" does not exist in the app spec`},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			spec := map[string]interface{}{
+				"name": "test",
+				"services": []interface{}{
+					map[string]interface{}{
+						"name":           "web",
+						"instance_count": float64(1),
+						"github": map[string]interface{}{
+							"branch": "main",
+						},
+					},
+				},
+			}
+
+			err := setAppSpecPath(spec, tc.path, tc.value, tc.createMissing)
+			if tc.wantErr != "" {
+				require.EqualError(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var node interface{} = spec
+			for _, part := range strings.Split(tc.path, ".") {
+				switch n := node.(type) {
+				case map[string]interface{}:
+					node = n[part]
+				case []interface{}:
+					node = n[0]
+				}
+			}
+			assert.Equal(t, tc.want, node)
+		})
+	}
+}
+
+func TestRunAppsCreateWithSet(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		specFile := testTempFile(t, []byte(validYAMLSpec))
+
+		expected := *validAppSpec
+		expected.Services = []*godo.AppServiceSpec{{
+			Name: "web",
+			GitHub: &godo.GitHubSourceSpec{
+				Repo:   "digitalocean/sample-golang",
+				Branch: "staging",
+			},
+			InstanceCount: 3,
+		}}
+		app := &godo.App{
+			ID:   uuid.New().String(),
+			Spec: &expected,
+		}
+
+		tm.apps.EXPECT().Create(&godo.AppCreateRequest{Spec: &expected}).Times(1).Return(app, nil)
+
+		config.Doit.Set(config.NS, doctl.ArgAppSpec, []string{specFile})
+		config.Doit.Set(config.NS, doctl.ArgAppSet, []string{"services.0.github.branch=staging", "services.0.instance_count=3"})
+		config.Doit.Set(config.NS, doctl.ArgAppSetCreate, true)
+
+		err := RunAppsCreate(config)
+		require.NoError(t, err)
+	})
+}
+
+func Test_readAppSpecURLErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing.yaml":
+			http.NotFound(w, r)
+		default:
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(validYAMLSpec))
+		}
+	}))
+	defer server.Close()
+
+	_, err := readAppSpec(nil, server.URL+"/missing.yaml")
+	require.EqualError(t, err, "fetching app spec: GET "+server.URL+"/missing.yaml returned 404 Not Found")
+
+	_, err = readAppSpec(nil, server.URL+"/app.png")
+	require.EqualError(t, err, `fetching app spec: unsupported content type "image/png"; expected YAML or JSON`)
+}
+
+func testTempFile(t *testing.T, data []byte) string {
+	t.Helper()
+	file := t.TempDir() + "/file"
+	err := ioutil.WriteFile(file, data, 0644)
+	require.NoError(t, err, "writing temp file")
+	return file
+}
+
+func TestRunAppSpecValidate(t *testing.T) {
+	tcs := []struct {
+		name       string
+		spec       string
+		schemaOnly bool
+		mock       func(tm *tcMocks)
+
+		wantError string
+		wantOut   string
+	}{
+		{
+			name:       "valid yaml",
+			spec:       validYAMLSpec,
+			schemaOnly: true,
+			wantOut:    validYAMLSpec,
+		},
+		{
+			name:       "valid json",
+			spec:       validJSONSpec,
+			schemaOnly: true,
+			wantOut:    validYAMLSpec,
+		},
+		{
+			name: "valid json with ProposeApp req",
+			spec: validJSONSpec,
+			mock: func(tm *tcMocks) {
+				tm.apps.EXPECT().Propose(&godo.AppProposeRequest{
+					Spec: validAppSpec,
+				}).Return(&godo.AppProposeResponse{
+					Spec: &godo.AppSpec{
+						Name: "validated-spec",
+					},
+				}, nil)
+			},
+			wantOut: "name: validated-spec\n",
+		},
+		{
+			name:       "invalid",
+			spec:       "hello",
+			schemaOnly: true,
+			wantError:  "<spec>:1: expected object, but got string",
+		},
+		{
+			name:      "invalid with ProposeApp req",
+			spec:      "hello",
+			wantError: "parsing app spec: json: cannot unmarshal string into Go value of type godo.AppSpec",
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+				specFile := testTempFile(t, []byte(tc.spec))
+				config.Args = append(config.Args, specFile)
+				config.Doit.Set(config.NS, doctl.ArgSchemaOnly, tc.schemaOnly)
+				var buf bytes.Buffer
+				config.Out = &buf
+
+				if tc.mock != nil {
+					tc.mock(tm)
+				}
+
+				err := RunAppsSpecValidate(config)
+				if tc.wantError != "" {
+					require.EqualError(t, err, strings.ReplaceAll(tc.wantError, "<spec>", specFile))
+					return
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantOut, buf.String())
+			})
+		})
+	}
+}
+
+func TestRunAppSpecValidateDir(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		dir := t.TempDir()
+		files := map[string]string{
+			"valid.yaml":   validYAMLSpec,
+			"invalid.json": "hello",
+			"README.md":    "not a spec",
+		}
+		for name, data := range files {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(data), 0644))
+		}
+
+		tm.apps.EXPECT().Propose(&godo.AppProposeRequest{Spec: validAppSpec}).Times(1).Return(&godo.AppProposeResponse{Spec: validAppSpec}, nil)
+
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Doit.Set(config.NS, doctl.ArgAppSpecDir, dir)
+
+		err := RunAppsSpecValidate(config)
+		require.EqualError(t, err, "1 app spec(s) failed validation")
+
+		invalid := filepath.Join(dir, "invalid.json")
+		valid := filepath.Join(dir, "valid.yaml")
+		assert.Equal(t, `FAIL  `+invalid+`
+      parsing app spec: json: cannot unmarshal string into Go value of type godo.AppSpec
+PASS  `+valid+`
+
+1 of 2 app specs are valid
+`, buf.String())
+	})
+}
+
+func TestRunAppSpecValidateMultiple(t *testing.T) {
+	t.Run("files", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			valid := testTempFile(t, []byte(validYAMLSpec))
+			invalid := testTempFile(t, []byte("hello"))
+
+			tm.apps.EXPECT().Propose(&godo.AppProposeRequest{Spec: validAppSpec}).Times(1).Return(&godo.AppProposeResponse{Spec: validAppSpec}, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, valid, invalid)
+
+			err := RunAppsSpecValidate(config)
+			require.EqualError(t, err, "1 app spec(s) failed validation")
+			assert.Equal(t, `PASS  `+valid+`
+FAIL  `+invalid+`
+      parsing app spec: json: cannot unmarshal string into Go value of type godo.AppSpec
+
+1 of 2 app specs are valid
+`, buf.String())
+		})
+	})
+
+	t.Run("documents", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, []byte(validYAMLSpec+"---\n"+validYAMLSpec+"--- # invalid\nhello\n---\n"))
+
+			tm.apps.EXPECT().Propose(&godo.AppProposeRequest{Spec: validAppSpec}).Times(2).Return(&godo.AppProposeResponse{Spec: validAppSpec}, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, specFile)
+
+			err := RunAppsSpecValidate(config)
+			require.EqualError(t, err, "1 app spec(s) failed validation")
+			assert.Equal(t, `PASS  `+specFile+` (document 1)
+PASS  `+specFile+` (document 2)
+FAIL  `+specFile+` (document 3)
+      parsing app spec: json: cannot unmarshal string into Go value of type godo.AppSpec
+
+2 of 3 app specs are valid
+`, buf.String())
+		})
+	})
+
+	t.Run("stdin twice", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, "-", "-")
+
+			err := RunAppsSpecValidate(config)
+			require.EqualError(t, err, "- can only be passed once")
+		})
+	})
+}
+
+func TestRunAppsSpecFormat(t *testing.T) {
+	unformatted := `static_sites:
+- routes:
+  - path: /static
+  name: static
+  git: {repo_clone_url: "git@github.com:digitalocean/sample-gatsby.git", branch: main}
+name: test
+services:
+- name: web
+  github:
+      repo: digitalocean/sample-golang
+      branch: main
+`
+
+	t.Run("stdout", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, []byte(unformatted))
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, specFile)
+
+			err := RunAppsSpecFormat(config)
+			require.NoError(t, err)
+			assert.Equal(t, validYAMLSpec, buf.String())
+
+			byt, err := ioutil.ReadFile(specFile)
+			require.NoError(t, err)
+			assert.Equal(t, unformatted, string(byt))
+		})
+	})
+
+	t.Run("write", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, []byte(unformatted))
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, specFile)
+			config.Doit.Set(config.NS, doctl.ArgAppSpecWrite, true)
+
+			err := RunAppsSpecFormat(config)
+			require.NoError(t, err)
+			assert.Empty(t, buf.String())
+
+			byt, err := ioutil.ReadFile(specFile)
+			require.NoError(t, err)
+			assert.Equal(t, validYAMLSpec, string(byt))
+		})
+	})
+
+	t.Run("write json", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, []byte(validJSONSpec))
+
+			config.Out = &bytes.Buffer{}
+			config.Args = append(config.Args, specFile)
+			config.Doit.Set(config.NS, doctl.ArgAppSpecWrite, true)
+
+			err := RunAppsSpecFormat(config)
+			require.NoError(t, err)
+
+			byt, err := ioutil.ReadFile(specFile)
+			require.NoError(t, err)
+			assert.Equal(t, `{
+  "name": "test",
+  "services": [
+    {
+      "github": {
+        "branch": "main",
+        "repo": "digitalocean/sample-golang"
+      },
+      "name": "web"
+    }
+  ],
+  "static_sites": [
+    {
+      "git": {
+        "branch": "main",
+        "repo_clone_url": "git@github.com:digitalocean/sample-gatsby.git"
+      },
+      "name": "static",
+      "routes": [
+        {
+          "path": "/static"
+        }
+      ]
+    }
+  ]
+}
+`, string(byt))
+		})
+	})
+
+	t.Run("write stdin", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, "-")
+			config.Doit.Set(config.NS, doctl.ArgAppSpecWrite, true)
+
+			err := RunAppsSpecFormat(config)
+			require.EqualError(t, err, "--write can only be used with a local spec file")
+		})
+	})
+}
+
+func TestRunAppSpecValidateStrictEnv(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		specFile := testTempFile(t, []byte(`name: test
+envs:
+- key: API_TOKEN
+  type: SECRET
+  value: EV[1:abc]
+- key: NPM_TOKEN
+  type: SECRET
+services:
+- name: web
+  github:
+    repo: digitalocean/sample-golang
+    branch: main
+  envs:
+  - key: TOKEN
+    value: ${API_TOKEN}
+  - key: URL
+    value: ${APP_URL}/web
+  - key: DATABASE_URL
+    value: ${db.DATABASE_URL}
+  - key: API_KEY
+    value: ${API_KEY}
+  - key: BP_NODE_VERSION
+    value: "16"
+    scope: RUN_TIME
+`))
+
+		defer func(a io.Writer) { color.Output = a }(color.Output)
+		var stderr bytes.Buffer
+		color.Output = &stderr
+
+		config.Out = ioutil.Discard
+		config.Args = append(config.Args, specFile)
+		config.Doit.Set(config.NS, doctl.ArgSchemaOnly, true)
+		config.Doit.Set(config.NS, doctl.ArgAppSpecStrictEnv, true)
+
+		err := RunAppsSpecValidate(config)
+		require.NoError(t, err)
+		assert.Equal(t, `Warning: `+specFile+`: app env NPM_TOKEN is a secret with no value
+Warning: `+specFile+`: component web env DATABASE_URL references ${db.DATABASE_URL}, but there is no component named db
+Warning: `+specFile+`: component web env API_KEY references ${API_KEY}, which is not an app-level variable
+Warning: `+specFile+`: component web env BP_NODE_VERSION looks like a build-time variable but is only available at run time
+`, stderr.String())
+	})
+}
+
+func TestRunAppSpecValidateUnknownField(t *testing.T) {
+	for _, schemaOnly := range []bool{true, false} {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, []byte(`name: test
+services:
+- name: web
+  github:
+    repo: digitalocean/sample-golang
+    branch: main
+    bugField: bad
+`))
+			config.Args = append(config.Args, specFile)
+			config.Doit.Set(config.NS, doctl.ArgSchemaOnly, schemaOnly)
+
+			err := RunAppsSpecValidate(config)
+			require.EqualError(t, err, specFile+`:7: unknown field "bugField"`)
+		})
+	}
+}
+
+func TestRunAppSpecValidateSchemaOnly(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		specFile := testTempFile(t, []byte(`name: Test
+services:
+- name: web
+  instance_count: two
+  github:
+    branch: main
+  bugField: bad
+- http_port: 0
+domains:
+- type: PRIMARY
+`))
+		config.Args = append(config.Args, specFile)
+		config.Doit.Set(config.NS, doctl.ArgSchemaOnly, true)
+
+		err := RunAppsSpecValidate(config)
+		require.Error(t, err)
+		assert.Equal(t, []string{
+			specFile + `:1: name: does not match pattern '^[a-z][a-z0-9-]{0,30}[a-z0-9]$'`,
+			specFile + `:4: services[0].instance_count: expected integer, but got string`,
+			specFile + `:6: services[0].github: missing properties: 'repo'`,
+			specFile + `:7: unknown field "bugField"`,
+			specFile + `:8: services[1]: missing properties: 'name'`,
+			specFile + `:8: services[1].http_port: must be >= 1 but found 0`,
+			specFile + `:10: domains[0]: missing properties: 'domain'`,
+		}, strings.Split(err.Error(), "\n"))
+	})
+}
+
+func TestFindUnknownAppSpecField(t *testing.T) {
+	key, line, ok := findUnknownAppSpecField([]byte(unknownFieldSpec))
+	require.True(t, ok)
+	assert.Equal(t, "bugField", key)
+	assert.Equal(t, 3, line)
+
+	_, _, ok = findUnknownAppSpecField([]byte(validJSONSpec))
+	assert.False(t, ok)
+}
+
+func TestRunAppSpecGet(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		app := &godo.App{
+			ID:   uuid.New().String(),
+			Spec: &testAppSpec,
+		}
+
+		tm.apps.EXPECT().Get(app.ID).Times(2).Return(app, nil)
+
+		t.Run("yaml", func(t *testing.T) {
+			var buf bytes.Buffer
+			config.Doit.Set(config.NS, doctl.ArgFormat, "yaml")
+			config.Args = append(config.Args, app.ID)
+			config.Out = &buf
+
+			err := RunAppsSpecGet(config)
+			require.NoError(t, err)
+			require.Equal(t, `name: test
+services:
+- github:
+    branch: main
+    repo: digitalocean/doctl
+  name: service
+`, buf.String())
+		})
+
+		t.Run("json", func(t *testing.T) {
+			var buf bytes.Buffer
+			config.Doit.Set(config.NS, doctl.ArgFormat, "json")
+			config.Args = append(config.Args, app.ID)
+			config.Out = &buf
+
+			err := RunAppsSpecGet(config)
+			require.NoError(t, err)
+			require.Equal(t, `{
+  "name": "test",
+  "services": [
+    {
+      "name": "service",
+      "github": {
+        "repo": "digitalocean/doctl",
+        "branch": "main"
+      }
+    }
+  ]
+}
+`, buf.String())
+		})
+	})
+
+	t.Run("with-deployment", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			appID := uuid.New().String()
+			deployment := &godo.Deployment{
+				ID:   uuid.New().String(),
+				Spec: &testAppSpec,
+			}
+
+			tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(deployment, nil)
+
+			var buf bytes.Buffer
+			config.Doit.Set(config.NS, doctl.ArgFormat, "yaml")
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deployment.ID)
+			config.Args = append(config.Args, appID)
+			config.Out = &buf
+
+			err := RunAppsSpecGet(config)
+			require.NoError(t, err)
+			require.Equal(t, `name: test
+services:
+- github:
+    branch: main
+    repo: digitalocean/doctl
+  name: service
+`, buf.String())
+		})
+	})
+}
+
+func TestRunAppSpecGetLatestDeployment(t *testing.T) {
+	appID := uuid.New().String()
+	latest := &godo.Deployment{
+		ID:   uuid.New().String(),
+		Spec: &godo.AppSpec{Name: "latest"},
+	}
+	active := &godo.Deployment{
+		ID:   uuid.New().String(),
+		Spec: &godo.AppSpec{Name: "active"},
+	}
+
+	t.Run("latest", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().ForEachDeployment(appID, gomock.Any()).Times(1).DoAndReturn(func(id string, fn func(*godo.Deployment) error) error {
+				for _, d := range []*godo.Deployment{latest, active} {
+					if err := fn(d); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Doit.Set(config.NS, doctl.ArgFormat, "yaml")
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, "latest")
+			config.Args = append(config.Args, appID)
+
+			err := RunAppsSpecGet(config)
+			require.NoError(t, err)
+			assert.Equal(t, "name: latest\n", buf.String())
+		})
+	})
+
+	t.Run("latest-active", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().Get(appID).Times(1).Return(&godo.App{
+				ID:               appID,
+				Spec:             &godo.AppSpec{Name: "pending"},
+				ActiveDeployment: &godo.Deployment{ID: active.ID},
+			}, nil)
+			tm.apps.EXPECT().GetDeployment(appID, active.ID).Times(1).Return(active, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Doit.Set(config.NS, doctl.ArgFormat, "yaml")
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, "latest-active")
+			config.Args = append(config.Args, appID)
+
+			err := RunAppsSpecGet(config)
+			require.NoError(t, err)
+			assert.Equal(t, "name: active\n", buf.String())
+		})
+	})
+
+	t.Run("no active deployment", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().Get(appID).Times(1).Return(&godo.App{ID: appID}, nil)
+
+			config.Doit.Set(config.NS, doctl.ArgFormat, "yaml")
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, "latest-active")
+			config.Args = append(config.Args, appID)
+
+			err := RunAppsSpecGet(config)
+			assert.EqualError(t, err, "app "+appID+" has no active deployment")
+		})
+	})
+}
+
+func TestRunAppSpecGetRedactSecrets(t *testing.T) {
+	newApp := func() *godo.App {
+		return &godo.App{
+			ID: uuid.New().String(),
+			Spec: &godo.AppSpec{
+				Name: "test",
+				Envs: []*godo.AppVariableDefinition{
+					{Key: "API_KEY", Value: "EV[1:abc]", Type: godo.AppVariableType_Secret},
+				},
+				Workers: []*godo.AppWorkerSpec{{
+					Name: "worker",
+					Envs: []*godo.AppVariableDefinition{
+						{Key: "LOG_LEVEL", Value: "debug", Type: godo.AppVariableType_General},
+						{Key: "DB_PASSWORD", Value: "EV[1:def]", Type: godo.AppVariableType_Secret},
+					},
+				}},
+			},
+		}
+	}
+
+	t.Run("yaml", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			app := newApp()
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Doit.Set(config.NS, doctl.ArgFormat, "yaml")
+			config.Doit.Set(config.NS, doctl.ArgAppSpecRedactSecrets, true)
+			config.Args = append(config.Args, app.ID)
+
+			err := RunAppsSpecGet(config)
+			require.NoError(t, err)
+			assert.Equal(t, `envs:
+- key: API_KEY
+  type: SECRET
+  value: REDACTED
+name: test
+workers:
+- envs:
+  - key: LOG_LEVEL
+    type: GENERAL
+    value: debug
+  - key: DB_PASSWORD
+    type: SECRET
+    value: REDACTED
+  name: worker
+`, buf.String())
+		})
+	})
+
+	t.Run("json component", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			app := newApp()
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Doit.Set(config.NS, doctl.ArgFormat, "json")
+			config.Doit.Set(config.NS, doctl.ArgAppComponent, "worker")
+			config.Doit.Set(config.NS, doctl.ArgAppSpecRedactSecrets, true)
+			config.Args = append(config.Args, app.ID)
+
+			err := RunAppsSpecGet(config)
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), `"value": "debug"`)
+			assert.Contains(t, buf.String(), `"value": "REDACTED"`)
+			assert.NotContains(t, buf.String(), "EV[")
+		})
+	})
+}
+
+func TestRunAppSpecGetComponent(t *testing.T) {
+	app := &godo.App{
+		ID:   uuid.New().String(),
+		Spec: &testAppSpec,
+	}
+
+	t.Run("found", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Doit.Set(config.NS, doctl.ArgFormat, "yaml")
+			config.Doit.Set(config.NS, doctl.ArgAppComponent, "service")
+			config.Args = append(config.Args, app.ID)
+
+			err := RunAppsSpecGet(config)
+			require.NoError(t, err)
+			assert.Equal(t, `github:
+  branch: main
+  repo: digitalocean/doctl
+name: service
+`, buf.String())
+		})
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+
+			config.Doit.Set(config.NS, doctl.ArgFormat, "yaml")
+			config.Doit.Set(config.NS, doctl.ArgAppComponent, "worker")
+			config.Args = append(config.Args, app.ID)
+
+			err := RunAppsSpecGet(config)
+			require.EqualError(t, err, `component "worker" not found in app spec; available components are: service`)
+		})
+	})
+}
+
+func TestRunAppSpecGetOutputFile(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		app := &godo.App{
 			ID:   uuid.New().String(),
 			Spec: &testAppSpec,
-			Services: []*godo.DeploymentService{{
-				Name:             "service",
-				SourceCommitHash: "commit",
+		}
+		path := filepath.Join(t.TempDir(), "spec.json")
+
+		tm.apps.EXPECT().Get(app.ID).Times(3).Return(app, nil)
+
+		config.Doit.Set(config.NS, doctl.ArgFormat, "json")
+		config.Doit.Set(config.NS, doctl.ArgAppSpecOutputFile, path)
+		config.Args = append(config.Args, app.ID)
+
+		err := RunAppsSpecGet(config)
+		require.NoError(t, err)
+
+		data, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		var spec godo.AppSpec
+		require.NoError(t, json.Unmarshal(data, &spec))
+		assert.Equal(t, testAppSpec, spec)
+
+		err = RunAppsSpecGet(config)
+		require.EqualError(t, err, path+" already exists; use --force to overwrite it")
+
+		config.Doit.Set(config.NS, doctl.ArgForce, true)
+		err = RunAppsSpecGet(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsSpecDiff(t *testing.T) {
+	appID := uuid.New().String()
+	from := &godo.Deployment{
+		ID:   uuid.New().String(),
+		Spec: &testAppSpec,
+	}
+	to := &godo.Deployment{
+		ID: uuid.New().String(),
+		Spec: &godo.AppSpec{
+			Name: "test",
+			Services: []*godo.AppServiceSpec{{
+				Name: "service",
+				GitHub: &godo.GitHubSourceSpec{
+					Repo:   "digitalocean/doctl",
+					Branch: "feature",
+				},
 			}},
-			Cause: "Manual",
-			Progress: &godo.DeploymentProgress{
-				PendingSteps: 1,
-				RunningSteps: 0,
-				SuccessSteps: 0,
-				ErrorSteps:   0,
-				TotalSteps:   1,
+		},
+	}
 
-				Steps: []*godo.DeploymentProgressStep{{
-					Name:      "name",
-					Status:    "pending",
-					StartedAt: time.Now(),
-				}},
-			},
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+	t.Run("text", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().GetDeployment(appID, from.ID).Times(1).Return(from, nil)
+			tm.apps.EXPECT().GetDeployment(appID, to.ID).Times(1).Return(to, nil)
+
+			var buf bytes.Buffer
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, []string{from.ID, to.ID})
+			config.Doit.Set(config.NS, doctl.ArgFormat, "text")
+			config.Args = append(config.Args, appID)
+			config.Out = &buf
+
+			err := RunAppsSpecDiff(config)
+			require.NoError(t, err)
+			require.Equal(t, `--- deployment `+from.ID+`
++++ deployment `+to.ID+`
+@@ -1,6 +1,6 @@
+ name: test
+ services:
+ - github:
+-    branch: main
++    branch: feature
+     repo: digitalocean/doctl
+   name: service
+`, buf.String())
+		})
+	})
+
+	t.Run("json with spec file", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, []byte(`name: test
+services:
+- name: service
+  github:
+    repo: digitalocean/doctl
+    branch: feature
+`))
+			app := &godo.App{
+				ID:               appID,
+				Spec:             &testAppSpec,
+				ActiveDeployment: from,
+			}
+
+			tm.apps.EXPECT().Get(appID).Times(1).Return(app, nil)
+
+			var buf bytes.Buffer
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgFormat, "json")
+			config.Args = append(config.Args, appID)
+			config.Out = &buf
+
+			err := RunAppsSpecDiff(config)
+			require.NoError(t, err)
+			require.Equal(t, `{
+  "from": "deployment `+from.ID+`",
+  "to": "`+specFile+`",
+  "changes": [
+    {
+      "op": "replace",
+      "from_line": 4,
+      "to_line": 4,
+      "removed": [
+        "    branch: main"
+      ],
+      "added": [
+        "    branch: feature"
+      ]
+    }
+  ]
+}
+`, buf.String())
+		})
+	})
+
+	t.Run("missing comparison", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doctl.ArgAppDeployment, []string{from.ID})
+			config.Doit.Set(config.NS, doctl.ArgFormat, "text")
+			config.Args = append(config.Args, appID)
+
+			err := RunAppsSpecDiff(config)
+			require.EqualError(t, err, "either two --deployment IDs or --spec must be provided")
+		})
+	})
+}
+
+func TestRunAppsOpen(t *testing.T) {
+	defer func(f func(string) error) { openBrowser = f }(openBrowser)
+
+	app := &godo.App{
+		ID:             uuid.New().String(),
+		Spec:           &testAppSpec,
+		DefaultIngress: "https://test.ondigitalocean.app",
+		LiveURL:        "https://test.example.com",
+	}
+
+	t.Run("open", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			var opened string
+			openBrowser = func(url string) error {
+				opened = url
+				return nil
+			}
+
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+
+			config.Args = append(config.Args, app.ID)
+
+			err := RunAppsOpen(config)
+			require.NoError(t, err)
+			assert.Equal(t, app.LiveURL, opened)
+		})
+	})
+
+	t.Run("print", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			openBrowser = func(url string) error {
+				t.Fatal("unexpected call to openBrowser")
+				return nil
+			}
+
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Args = append(config.Args, app.ID)
+			config.Doit.Set(config.NS, doctl.ArgAppOpenPrint, true)
+
+			err := RunAppsOpen(config)
+			require.NoError(t, err)
+			assert.Equal(t, app.LiveURL+"\n", buf.String())
+		})
+	})
+
+	t.Run("no live url", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			deploying := &godo.App{ID: app.ID, Spec: &testAppSpec}
+			tm.apps.EXPECT().Get(app.ID).Times(1).Return(deploying, nil)
+
+			config.Args = append(config.Args, app.ID)
+
+			err := RunAppsOpen(config)
+			require.EqualError(t, err, "app "+app.ID+" has no live URL yet; it may still be deploying")
+		})
+	})
+}
+
+func TestRunAppsListRegions(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		regions := []*godo.AppRegion{{
+			Slug:        "ams",
+			Label:       "Amsterdam",
+			Flag:        "netherlands",
+			Continent:   "Europe",
+			DataCenters: []string{"ams3"},
+			Default:     true,
 		}}
 
-		tm.apps.EXPECT().ListDeployments(appID).Times(1).Return(deployments, nil)
+		tm.apps.EXPECT().ListRegions().Times(1).Return(regions, nil)
+
+		err := RunAppsListRegions(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsTierList(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tiers := []*godo.AppTier{testAppTier}
+
+		tm.apps.EXPECT().ListTiers().Times(1).Return(tiers, nil)
+		tm.apps.EXPECT().ListInstanceSizes().Times(1).Return([]*godo.AppInstanceSize{testAppInstanceSize}, nil)
+
+		err := RunAppsTierList(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsTierListJSON(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		defer func(o string) { Output = o }(Output)
+		Output = "json"
+
+		basic := &godo.AppTier{Name: "Basic", Slug: "basic", BuildSeconds: "400"}
+		tm.apps.EXPECT().ListTiers().Times(1).Return([]*godo.AppTier{basic, testAppTier}, nil)
+		tm.apps.EXPECT().ListInstanceSizes().Times(1).Return([]*godo.AppInstanceSize{testAppInstanceSize}, nil)
+
+		var buf bytes.Buffer
+		config.Out = &buf
+
+		err := RunAppsTierList(config)
+		require.NoError(t, err)
+		assert.JSONEq(t, `[
+  {"name": "Basic", "slug": "basic", "build_seconds": "400", "monthly_price": 500},
+  {"name": "Test", "slug": "test", "egress_bandwidth_bytes": "10240", "build_seconds": "3000"}
+]`, buf.String())
+	})
+}
+
+func TestRunAppsTierListWithoutPrices(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.apps.EXPECT().ListTiers().Times(1).Return([]*godo.AppTier{testAppTier}, nil)
+		tm.apps.EXPECT().ListInstanceSizes().Times(1).Return(nil, errors.New("boom"))
+
+		var buf bytes.Buffer
+		config.Out = &buf
+
+		err := RunAppsTierList(config)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), testAppTier.Name)
+	})
+}
+
+func TestRunAppsTierGet(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.apps.EXPECT().GetTier(testAppTier.Slug).Times(1).Return(testAppTier, nil)
+		tm.apps.EXPECT().ListInstanceSizes().Times(1).Return([]*godo.AppInstanceSize{testAppInstanceSize}, nil)
+
+		config.Args = append(config.Args, testAppTier.Slug)
+		err := RunAppsTierGet(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsTierGetMultiple(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		basic := &godo.AppTier{Name: "Basic", Slug: "basic", BuildSeconds: "400"}
+		notFound := &godo.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+
+		tm.apps.EXPECT().GetTier(testAppTier.Slug).Times(1).Return(testAppTier, nil)
+		tm.apps.EXPECT().GetTier("missing").Times(1).Return(nil, notFound)
+		tm.apps.EXPECT().GetTier(basic.Slug).Times(1).Return(basic, nil)
+		tm.apps.EXPECT().ListInstanceSizes().Times(1).Return([]*godo.AppInstanceSize{testAppInstanceSize}, nil)
+
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, testAppTier.Slug, "missing", basic.Slug)
+
+		err := RunAppsTierGet(config)
+		require.EqualError(t, err, "app tier(s) not found: missing")
+		assert.Contains(t, buf.String(), testAppTier.Name)
+		assert.Contains(t, buf.String(), basic.Name)
+	})
+}
+
+func TestRunAppsTierCompare(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		basic := &godo.AppTier{Name: "Basic", Slug: "basic", BuildSeconds: "400"}
+		professional := &godo.AppTier{Name: "Professional", Slug: "professional", BuildSeconds: "4000"}
+		sizes := []*godo.AppInstanceSize{
+			testAppInstanceSize,
+			{Slug: "basic-xxxs", USDPerMonth: "4.50", TierSlug: "basic"},
+			{Slug: "professional-xs", USDPerMonth: "12", TierSlug: "professional"},
+		}
+
+		tm.apps.EXPECT().GetTier(basic.Slug).Times(1).Return(basic, nil)
+		tm.apps.EXPECT().GetTier(professional.Slug).Times(1).Return(professional, nil)
+		tm.apps.EXPECT().ListInstanceSizes().Times(1).Return(sizes, nil)
 
-		config.Args = append(config.Args, appID)
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, basic.Slug, professional.Slug)
 
-		err := RunAppsListDeployments(config)
+		err := RunAppsTierCompare(config)
 		require.NoError(t, err)
+		assert.Contains(t, buf.String(), "$4.50")
+		assert.Contains(t, buf.String(), "$12.00")
+		assert.Contains(t, buf.String(), "+$7.50")
 	})
 }
 
-func TestRunAppsGetLogs(t *testing.T) {
-	appID := uuid.New().String()
-	deploymentID := uuid.New().String()
-	component := "service"
+func TestRunAppsTierInstanceSizeList(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		instanceSizes := []*godo.AppInstanceSize{testAppInstanceSize}
 
-	types := map[string]godo.AppLogType{
-		"build":  godo.AppLogTypeBuild,
-		"deploy": godo.AppLogTypeDeploy,
-		"run":    godo.AppLogTypeRun,
+		tm.apps.EXPECT().ListInstanceSizes().Times(1).Return(instanceSizes, nil)
+
+		err := RunAppsTierInstanceSizeList(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsTierInstanceSizeListFiltered(t *testing.T) {
+	shared := &godo.AppInstanceSize{
+		Slug:     "basic-shared",
+		CPUType:  godo.AppInstanceSizeCPUType_Shared,
+		TierSlug: "basic",
+	}
+	professional := &godo.AppInstanceSize{
+		Slug:     "professional-dedicated",
+		CPUType:  godo.AppInstanceSizeCPUType_Dedicated,
+		TierSlug: "professional",
 	}
 
-	for typeStr, logType := range types {
-		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-			tm.apps.EXPECT().GetLogs(appID, deploymentID, component, logType, true).Times(1).Return(&godo.AppLogs{LiveURL: "https://proxy-apps-prod-ams3-001.ondigitalocean.app/?token=aa-bb-11-cc-33"}, nil)
-			tm.listen.EXPECT().Start().Times(1).Return(nil)
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		var buf bytes.Buffer
+		config.Out = &buf
 
-			tc := config.Doit.(*doctl.TestConfig)
-			tc.ListenFn = func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer) listen.ListenerService {
-				assert.Equal(t, token, "aa-bb-11-cc-33")
-				assert.Equal(t, url.String(), "wss://proxy-apps-prod-ams3-001.ondigitalocean.app/?token=aa-bb-11-cc-33")
-				return tm.listen
-			}
+		tm.apps.EXPECT().ListInstanceSizes().Times(1).Return([]*godo.AppInstanceSize{testAppInstanceSize, shared, professional}, nil)
 
-			config.Args = append(config.Args, appID, component)
-			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deploymentID)
-			config.Doit.Set(config.NS, doctl.ArgAppLogType, typeStr)
-			config.Doit.Set(config.NS, doctl.ArgAppLogFollow, true)
+		config.Doit.Set(config.NS, doctl.ArgAppTier, "basic")
+		config.Doit.Set(config.NS, doctl.ArgAppCPUType, "dedicated")
 
-			err := RunAppsGetLogs(config)
-			require.NoError(t, err)
-		})
-	}
+		err := RunAppsTierInstanceSizeList(config)
+		require.NoError(t, err)
+		assert.Contains(t, buf.String(), testAppInstanceSize.Slug)
+		assert.NotContains(t, buf.String(), shared.Slug)
+		assert.NotContains(t, buf.String(), professional.Slug)
+	})
+
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		config.Doit.Set(config.NS, doctl.ArgAppCPUType, "burstable")
+
+		err := RunAppsTierInstanceSizeList(config)
+		require.Error(t, err)
+	})
 }
 
-const (
-	validJSONSpec = `{
-	"name": "test",
-	"services": [
-		{
-			"name": "web",
-			"github": {
-				"repo": "digitalocean/sample-golang",
-				"branch": "main"
-			}
-		}
-	],
-	"static_sites": [
-		{
-			"name": "static",
-			"git": {
-				"repo_clone_url": "git@github.com:digitalocean/sample-gatsby.git",
-				"branch": "main"
+func TestRunAppsTierInstanceSizeGet(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		tm.apps.EXPECT().GetInstanceSize(testAppInstanceSize.Slug).Times(1).Return(testAppInstanceSize, nil)
+
+		config.Args = append(config.Args, testAppInstanceSize.Slug)
+		err := RunAppsTierInstanceSizeGet(config)
+		require.NoError(t, err)
+	})
+}
+
+func TestRunAppsTierInstanceSizeGetMultiple(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		professional := &godo.AppInstanceSize{Name: "Professional XS", Slug: "professional-xs", USDPerMonth: "12.50", USDPerSecond: "0.0000047", TierSlug: "professional"}
+		notFound := &godo.ErrorResponse{Response: &http.Response{StatusCode: http.StatusNotFound}}
+
+		tm.apps.EXPECT().GetInstanceSize(testAppInstanceSize.Slug).Times(2).Return(testAppInstanceSize, nil)
+		tm.apps.EXPECT().GetInstanceSize("missing").Times(1).Return(nil, notFound)
+		tm.apps.EXPECT().GetInstanceSize(professional.Slug).Times(1).Return(professional, nil)
+
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, testAppInstanceSize.Slug, "missing", professional.Slug, testAppInstanceSize.Slug)
+		config.Doit.Set(config.NS, doctl.ArgAppTierPriceTotals, true)
+
+		err := RunAppsTierInstanceSizeGet(config)
+		require.EqualError(t, err, "app instance size(s) not found: missing")
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		require.Len(t, lines, 5)
+		assert.Contains(t, lines[2], professional.Name)
+		assert.Regexp(t, `^Total\s+22.5\s+0.0000085\s*$`, lines[4])
+	})
+}
+
+func TestRunAppsEnvList(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		app := &godo.App{
+			ID: uuid.New().String(),
+			Spec: &godo.AppSpec{
+				Name: "test",
+				Envs: []*godo.AppVariableDefinition{
+					{Key: "PLAIN", Value: "visible", Scope: godo.AppVariableScope_RunTime, Type: godo.AppVariableType_General},
+					{Key: "TOKEN", Value: "hidden", Scope: godo.AppVariableScope_RunTime, Type: godo.AppVariableType_Secret},
+				},
 			},
-			"routes": [
-				{
-				"path": "/static"
-				}
-			]
 		}
-	]
-}`
-	validYAMLSpec = `name: test
-services:
-- github:
-    branch: main
-    repo: digitalocean/sample-golang
-  name: web
-static_sites:
-- git:
-    branch: main
-    repo_clone_url: git@github.com:digitalocean/sample-gatsby.git
-  name: static
-  routes:
-  - path: /static
-`
-	unknownFieldSpec = `
-name: test
-bugField: bad
-services:
-- name: web
-  github:
-    repo: digitalocean/sample-golang
-    branch: main
-static_sites:
-- name: static
-  git:
-    repo_clone_url: git@github.com:digitalocean/sample-gatsby.git
-    branch: main
-  routes:
-  - path: /static
-`
-)
 
-var validAppSpec = &godo.AppSpec{
-	Name: "test",
-	Services: []*godo.AppServiceSpec{
-		{
-			Name: "web",
-			GitHub: &godo.GitHubSourceSpec{
-				Repo:   "digitalocean/sample-golang",
-				Branch: "main",
-			},
-		},
-	},
-	StaticSites: []*godo.AppStaticSiteSpec{
-		{
-			Name: "static",
-			Git: &godo.GitSourceSpec{
-				RepoCloneURL: "git@github.com:digitalocean/sample-gatsby.git",
-				Branch:       "main",
-			},
-			Routes: []*godo.AppRouteSpec{
-				{Path: "/static"},
-			},
-		},
-	},
-}
+		tm.apps.EXPECT().Get(app.ID).Times(1).Return(app, nil)
 
-func Test_parseAppSpec(t *testing.T) {
-	expectedSpec := validAppSpec
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, app.ID)
 
-	t.Run("json", func(t *testing.T) {
-		spec, err := parseAppSpec([]byte(validJSONSpec))
+		err := RunAppsEnvList(config)
 		require.NoError(t, err)
-		assert.Equal(t, expectedSpec, spec)
+		assert.Contains(t, buf.String(), "visible")
+		assert.NotContains(t, buf.String(), "hidden")
+		assert.Equal(t, "hidden", app.Spec.Envs[1].Value)
 	})
-	t.Run("yaml", func(t *testing.T) {
-		spec, err := parseAppSpec([]byte(validYAMLSpec))
+}
+
+func TestRunAppsEnvSet(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		app := &godo.App{
+			ID: appID,
+			Spec: &godo.AppSpec{
+				Name: "test",
+				Services: []*godo.AppServiceSpec{{
+					Name: "web",
+					Envs: []*godo.AppVariableDefinition{
+						{Key: "EXISTING", Value: "old", Scope: godo.AppVariableScope_BuildTime, Type: godo.AppVariableType_General},
+					},
+				}},
+			},
+		}
+		expected := &godo.AppSpec{
+			Name: "test",
+			Services: []*godo.AppServiceSpec{{
+				Name: "web",
+				Envs: []*godo.AppVariableDefinition{
+					{Key: "EXISTING", Value: "new", Scope: godo.AppVariableScope_BuildTime, Type: godo.AppVariableType_General},
+					{Key: "ADDED", Value: "a=b", Scope: godo.AppVariableScope_RunTime, Type: godo.AppVariableType_General},
+				},
+			}},
+		}
+
+		tm.apps.EXPECT().Get(appID).Times(1).Return(app, nil)
+		tm.apps.EXPECT().Update(appID, &godo.AppUpdateRequest{Spec: expected}).Times(1).Return(&godo.App{ID: appID, Spec: expected}, nil)
+
+		config.Args = append(config.Args, appID, "EXISTING=new", "ADDED=a=b")
+		config.Doit.Set(config.NS, doctl.ArgAppComponent, "web")
+		config.Doit.Set(config.NS, doctl.ArgAppEnvScope, "run_time")
+
+		err := RunAppsEnvSet(config)
 		require.NoError(t, err)
-		assert.Equal(t, expectedSpec, spec)
 	})
-	t.Run("invalid", func(t *testing.T) {
-		_, err := parseAppSpec([]byte("invalid spec"))
-		require.Error(t, err)
+
+	t.Run("invalid variable", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, uuid.New().String(), "NOVALUE")
+
+			err := RunAppsEnvSet(config)
+			require.EqualError(t, err, `invalid environment variable "NOVALUE", must be in the form KEY=VALUE`)
+		})
 	})
-	t.Run("unknown fields", func(t *testing.T) {
-		_, err := parseAppSpec([]byte(unknownFieldSpec))
-		require.Error(t, err)
+}
+
+func TestRunAppsEnvUnset(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		app := &godo.App{
+			ID: appID,
+			Spec: &godo.AppSpec{
+				Name: "test",
+				Envs: []*godo.AppVariableDefinition{
+					{Key: "KEEP", Value: "1"},
+					{Key: "REMOVE", Value: "2"},
+				},
+			},
+		}
+		expected := &godo.AppSpec{
+			Name: "test",
+			Envs: []*godo.AppVariableDefinition{
+				{Key: "KEEP", Value: "1"},
+			},
+		}
+
+		tm.apps.EXPECT().Get(appID).Times(1).Return(app, nil)
+		tm.apps.EXPECT().Update(appID, &godo.AppUpdateRequest{Spec: expected}).Times(1).Return(&godo.App{ID: appID, Spec: expected}, nil)
+
+		config.Args = append(config.Args, appID, "REMOVE")
+
+		err := RunAppsEnvUnset(config)
+		require.NoError(t, err)
 	})
 }
 
-func Test_readAppSpec(t *testing.T) {
-	tcs := []struct {
-		name  string
-		setup func(t *testing.T) (path string, stdin io.Reader)
+func TestRunAppsDomainsList(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		app := &godo.App{
+			ID: appID,
+			Spec: &godo.AppSpec{
+				Name: "test",
+				Domains: []*godo.AppDomainSpec{
+					{Domain: "example.com", Type: godo.AppDomainSpecType_Primary},
+					{Domain: "www.example.com", Type: godo.AppDomainSpecType_Alias},
+				},
+			},
+			Domains: []*godo.AppDomain{
+				{Spec: &godo.AppDomainSpec{Domain: "example.com", Type: godo.AppDomainSpecType_Primary}, Phase: godo.AppJobSpecKindPHASE_Active},
+			},
+		}
 
-		wantSpec *godo.AppSpec
-		wantErr  error
-	}{
-		{
-			name: "stdin",
-			setup: func(t *testing.T) (string, io.Reader) {
-				return "-", bytes.NewBufferString(validYAMLSpec)
+		tm.apps.EXPECT().Get(appID).Times(1).Return(app, nil)
+
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, appID)
+
+		err := RunAppsDomainsList(config)
+		require.NoError(t, err)
+		assert.Equal(t, `Domain             Type       Wildcard    Zone    Certificate Status
+example.com        PRIMARY    false               ACTIVE
+www.example.com    ALIAS      false               
+`, buf.String())
+	})
+}
+
+func TestRunAppsDomainsAdd(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		app := &godo.App{
+			ID: appID,
+			Spec: &godo.AppSpec{
+				Name: "test",
+				Domains: []*godo.AppDomainSpec{
+					{Domain: "example.com", Type: godo.AppDomainSpecType_Primary},
+				},
 			},
-			wantSpec: validAppSpec,
-		},
-		{
-			name: "file yaml",
-			setup: func(t *testing.T) (string, io.Reader) {
-				return testTempFile(t, []byte(validJSONSpec)), nil
+		}
+		expected := &godo.AppSpec{
+			Name: "test",
+			Domains: []*godo.AppDomainSpec{
+				{Domain: "example.com", Type: godo.AppDomainSpecType_Primary},
+				{Domain: "*.example.com", Type: godo.AppDomainSpecType_Alias, Wildcard: true, Zone: "example.com"},
 			},
-			wantSpec: validAppSpec,
-		},
-	}
+		}
 
-	for _, tc := range tcs {
-		t.Run(tc.name, func(t *testing.T) {
-			path, stdin := tc.setup(t)
-			spec, err := readAppSpec(stdin, path)
-			if tc.wantErr != nil {
-				require.Equal(t, tc.wantErr, err)
-			} else {
-				require.NoError(t, err)
-			}
+		tm.apps.EXPECT().Get(appID).Times(1).Return(app, nil)
+		tm.apps.EXPECT().Update(appID, &godo.AppUpdateRequest{Spec: expected}).Times(1).Return(&godo.App{ID: appID, Spec: expected}, nil)
 
-			assert.Equal(t, tc.wantSpec, spec)
-		})
-	}
+		config.Args = append(config.Args, appID)
+		config.Doit.Set(config.NS, doctl.ArgAppDomain, "*.example.com")
+		config.Doit.Set(config.NS, doctl.ArgAppDomainType, "alias")
+		config.Doit.Set(config.NS, doctl.ArgAppDomainWildcard, true)
+		config.Doit.Set(config.NS, doctl.ArgAppDomainZone, "example.com")
+
+		err := RunAppsDomainsAdd(config)
+		require.NoError(t, err)
+	})
 }
 
-func testTempFile(t *testing.T, data []byte) string {
-	t.Helper()
-	file := t.TempDir() + "/file"
-	err := ioutil.WriteFile(file, data, 0644)
-	require.NoError(t, err, "writing temp file")
-	return file
-}
+func TestRunAppsDomainsAddSecondPrimary(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		app := &godo.App{
+			ID: appID,
+			Spec: &godo.AppSpec{
+				Name: "test",
+				Domains: []*godo.AppDomainSpec{
+					{Domain: "example.com", Type: godo.AppDomainSpecType_Primary},
+				},
+			},
+		}
 
-func TestRunAppSpecValidate(t *testing.T) {
-	tcs := []struct {
-		name       string
-		spec       string
-		schemaOnly bool
-		mock       func(tm *tcMocks)
+		tm.apps.EXPECT().Get(appID).Times(1).Return(app, nil)
 
-		wantError string
-		wantOut   string
-	}{
-		{
-			name:       "valid yaml",
-			spec:       validYAMLSpec,
-			schemaOnly: true,
-			wantOut:    validYAMLSpec,
-		},
-		{
-			name:       "valid json",
-			spec:       validJSONSpec,
-			schemaOnly: true,
-			wantOut:    validYAMLSpec,
-		},
-		{
-			name: "valid json with ProposeApp req",
-			spec: validJSONSpec,
-			mock: func(tm *tcMocks) {
-				tm.apps.EXPECT().Propose(&godo.AppProposeRequest{
-					Spec: validAppSpec,
-				}).Return(&godo.AppProposeResponse{
-					Spec: &godo.AppSpec{
-						Name: "validated-spec",
-					},
-				}, nil)
-			},
-			wantOut: "name: validated-spec\n",
-		},
-		{
-			name:       "invalid",
-			spec:       "hello",
-			schemaOnly: true,
-			wantError:  "parsing app spec: json: cannot unmarshal string into Go value of type godo.AppSpec",
-		},
-	}
+		config.Args = append(config.Args, appID)
+		config.Doit.Set(config.NS, doctl.ArgAppDomain, "example.org")
+		config.Doit.Set(config.NS, doctl.ArgAppDomainType, "PRIMARY")
 
-	for _, tc := range tcs {
-		t.Run(tc.name, func(t *testing.T) {
-			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-				config.Args = append(config.Args, testTempFile(t, []byte(tc.spec)))
-				config.Doit.Set(config.NS, doctl.ArgSchemaOnly, tc.schemaOnly)
-				var buf bytes.Buffer
-				config.Out = &buf
+		err := RunAppsDomainsAdd(config)
+		require.EqualError(t, err, "app already has a primary domain example.com")
+	})
+}
 
-				if tc.mock != nil {
-					tc.mock(tm)
-				}
+func TestRunAppsDomainsRemove(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		appID := uuid.New().String()
+		app := &godo.App{
+			ID: appID,
+			Spec: &godo.AppSpec{
+				Name: "test",
+				Domains: []*godo.AppDomainSpec{
+					{Domain: "example.com", Type: godo.AppDomainSpecType_Primary},
+					{Domain: "www.example.com", Type: godo.AppDomainSpecType_Alias},
+				},
+			},
+		}
+		expected := &godo.AppSpec{
+			Name: "test",
+			Domains: []*godo.AppDomainSpec{
+				{Domain: "example.com", Type: godo.AppDomainSpecType_Primary},
+			},
+		}
 
-				err := RunAppsSpecValidate(config)
-				if tc.wantError != "" {
-					require.Equal(t, tc.wantError, err.Error())
-					return
-				}
+		tm.apps.EXPECT().Get(appID).Times(1).Return(app, nil)
+		tm.apps.EXPECT().Update(appID, &godo.AppUpdateRequest{Spec: expected}).Times(1).Return(&godo.App{ID: appID, Spec: expected}, nil)
 
-				require.NoError(t, err)
-				assert.Equal(t, tc.wantOut, buf.String())
-			})
-		})
-	}
+		config.Args = append(config.Args, appID, "WWW.example.com")
+
+		err := RunAppsDomainsRemove(config)
+		require.NoError(t, err)
+	})
 }
 
-func TestRunAppSpecGet(t *testing.T) {
+func TestRunAppsPropose(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		app := &godo.App{
-			ID:   uuid.New().String(),
-			Spec: &testAppSpec,
+		specFile := testTempFile(t, []byte(validJSONSpec))
+		res := &godo.AppProposeResponse{
+			AppNameAvailable: true,
+			Spec:             &testAppSpec,
 		}
 
-		tm.apps.EXPECT().Get(app.ID).Times(2).Return(app, nil)
+		tm.apps.EXPECT().Propose(&godo.AppProposeRequest{Spec: validAppSpec}).Times(2).Return(res, nil)
 
 		t.Run("yaml", func(t *testing.T) {
 			var buf bytes.Buffer
-			config.Doit.Set(config.NS, doctl.ArgFormat, "yaml")
-			config.Args = append(config.Args, app.ID)
 			config.Out = &buf
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgAppProposeSpecFormat, "yaml")
 
-			err := RunAppsSpecGet(config)
+			err := RunAppsPropose(config)
 			require.NoError(t, err)
 			require.Equal(t, `name: test
 services:
@@ -649,115 +4675,322 @@ services:
 `, buf.String())
 		})
 
-		t.Run("json", func(t *testing.T) {
+		t.Run("default", func(t *testing.T) {
 			var buf bytes.Buffer
-			config.Doit.Set(config.NS, doctl.ArgFormat, "json")
-			config.Args = append(config.Args, app.ID)
 			config.Out = &buf
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+			config.Doit.Set(config.NS, doctl.ArgAppProposeSpecFormat, "")
 
-			err := RunAppsSpecGet(config)
+			err := RunAppsPropose(config)
 			require.NoError(t, err)
-			require.Equal(t, `{
-  "name": "test",
-  "services": [
-    {
-      "name": "service",
-      "github": {
-        "repo": "digitalocean/doctl",
-        "branch": "main"
-      }
-    }
-  ]
+			require.Contains(t, buf.String(), "App Name Available?")
+		})
+	})
 }
+
+func TestRunAppsProposeDocuments(t *testing.T) {
+	specs := `# first app
+name: one
+---
+name: two
+---
+name: three
+bugField: bad
+`
+
+	t.Run("each document", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			specFile := testTempFile(t, []byte(specs))
+			one, two := &godo.AppSpec{Name: "one"}, &godo.AppSpec{Name: "two"}
+
+			tm.apps.EXPECT().Propose(&godo.AppProposeRequest{Spec: one}).Times(1).Return(&godo.AppProposeResponse{Spec: one, AppCost: 5}, nil)
+			tm.apps.EXPECT().Propose(&godo.AppProposeRequest{Spec: two}).Times(1).Return(&godo.AppProposeResponse{Spec: two, AppCost: 12}, nil)
+
+			var buf bytes.Buffer
+			config.Out = &buf
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+
+			err := RunAppsPropose(config)
+			require.EqualError(t, err, "1 app spec(s) failed validation")
+			assert.Equal(t, `PASS  `+specFile+` (document 1)  one  $5.00/month
+PASS  `+specFile+` (document 2)  two  $12.00/month
+FAIL  `+specFile+` (document 3)
+      parsing app spec: json: unknown field "bugField"
+
+2 of 3 app specs are valid
 `, buf.String())
 		})
 	})
 
-	t.Run("with-deployment", func(t *testing.T) {
+	t.Run("with app", func(t *testing.T) {
 		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-			appID := uuid.New().String()
-			deployment := &godo.Deployment{
-				ID:   uuid.New().String(),
-				Spec: &testAppSpec,
-			}
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, testTempFile(t, []byte(specs)))
+			config.Doit.Set(config.NS, doctl.ArgApp, uuid.New().String())
 
-			tm.apps.EXPECT().GetDeployment(appID, deployment.ID).Times(1).Return(deployment, nil)
+			err := RunAppsPropose(config)
+			require.EqualError(t, err, "--app, --diff, and --spec-format cannot be used with an app spec containing several YAML documents")
+		})
+	})
+
+	t.Run("with json output", func(t *testing.T) {
+		defer func(o string) { Output = o }(Output)
+		Output = "json"
+
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, testTempFile(t, []byte(specs)))
+
+			err := RunAppsPropose(config)
+			require.EqualError(t, err, "JSON output cannot be used with an app spec containing several YAML documents")
+		})
+	})
+
+	t.Run("with columns", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			one, two := &godo.AppSpec{Name: "one"}, &godo.AppSpec{Name: "two"}
+			tm.apps.EXPECT().Propose(&godo.AppProposeRequest{Spec: one}).Times(1).Return(&godo.AppProposeResponse{Spec: one}, nil)
+			tm.apps.EXPECT().Propose(&godo.AppProposeRequest{Spec: two}).Times(1).Return(&godo.AppProposeResponse{Spec: two}, nil)
+
+			// --format may be set by context defaults; it only selects columns
+			// and doesn't prevent proposing each document.
+			config.Out = &bytes.Buffer{}
+			config.Doit.Set(config.NS, doctl.ArgAppSpec, testTempFile(t, []byte(specs)))
+			config.Doit.Set(config.NS, doctl.ArgFormat, "AppCost")
+
+			err := RunAppsPropose(config)
+			require.EqualError(t, err, "1 app spec(s) failed validation")
+		})
+	})
+}
+
+func TestRunAppsProposeOnlyCost(t *testing.T) {
+	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+		specFile := testTempFile(t, []byte(validJSONSpec))
+		res := &godo.AppProposeResponse{
+			AppNameAvailable:   true,
+			Spec:               &testAppSpec,
+			AppCost:            5,
+			AppTierUpgradeCost: 12,
+		}
 
+		tm.apps.EXPECT().Propose(&godo.AppProposeRequest{Spec: validAppSpec}).Times(2).Return(res, nil)
+
+		config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+		config.Doit.Set(config.NS, doctl.ArgAppProposeOnlyCost, true)
+
+		t.Run("text", func(t *testing.T) {
 			var buf bytes.Buffer
-			config.Doit.Set(config.NS, doctl.ArgFormat, "yaml")
-			config.Doit.Set(config.NS, doctl.ArgAppDeployment, deployment.ID)
-			config.Args = append(config.Args, appID)
 			config.Out = &buf
 
-			err := RunAppsSpecGet(config)
+			err := RunAppsPropose(config)
 			require.NoError(t, err)
-			require.Equal(t, `name: test
-services:
-- github:
-    branch: main
-    repo: digitalocean/doctl
-  name: service
+			assert.Equal(t, `$/month    $/month on higher tier    $/month on lower tier
+5.00       12.00                     n/a
+`, buf.String())
+		})
+
+		t.Run("json", func(t *testing.T) {
+			defer func(o string) { Output = o }(Output)
+			Output = "json"
+
+			var buf bytes.Buffer
+			config.Out = &buf
+
+			err := RunAppsPropose(config)
+			require.NoError(t, err)
+			assert.Equal(t, `{
+  "app_cost": 5,
+  "app_tier_upgrade_cost": 12,
+  "app_tier_downgrade_cost": 0
+}
 `, buf.String())
 		})
 	})
 }
 
-func TestRunAppsListRegions(t *testing.T) {
+func TestRunAppsProposeByName(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		regions := []*godo.AppRegion{{
-			Slug:        "ams",
-			Label:       "Amsterdam",
-			Flag:        "netherlands",
-			Continent:   "Europe",
-			DataCenters: []string{"ams3"},
-			Default:     true,
-		}}
+		specFile := testTempFile(t, []byte(validJSONSpec))
+		app := &godo.App{ID: uuid.New().String(), Spec: &testAppSpec}
+		res := &godo.AppProposeResponse{Spec: &testAppSpec}
 
-		tm.apps.EXPECT().ListRegions().Times(1).Return(regions, nil)
+		tm.apps.EXPECT().List().Times(1).Return([]*godo.App{app}, nil)
+		tm.apps.EXPECT().Propose(&godo.AppProposeRequest{Spec: validAppSpec, AppID: app.ID}).Times(1).Return(res, nil)
 
-		err := RunAppsListRegions(config)
+		config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+		config.Doit.Set(config.NS, doctl.ArgAppByName, true)
+		config.Doit.Set(config.NS, doctl.ArgAppProposeSpecFormat, "yaml")
+
+		err := RunAppsPropose(config)
 		require.NoError(t, err)
 	})
 }
 
-func TestRunAppsTierList(t *testing.T) {
+func TestRunAppsProposeDiff(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		tiers := []*godo.AppTier{testAppTier}
+		specFile := testTempFile(t, []byte(validJSONSpec))
+		appID := uuid.New().String()
+		existing := &godo.App{
+			ID: appID,
+			Spec: &godo.AppSpec{
+				Name: "test",
+				Services: []*godo.AppServiceSpec{{
+					Name: "service",
+					GitHub: &godo.GitHubSourceSpec{
+						Repo:   "digitalocean/doctl",
+						Branch: "develop",
+					},
+				}},
+			},
+		}
+		res := &godo.AppProposeResponse{
+			AppNameAvailable: true,
+			Spec:             &testAppSpec,
+		}
 
-		tm.apps.EXPECT().ListTiers().Times(1).Return(tiers, nil)
+		tm.apps.EXPECT().Propose(&godo.AppProposeRequest{Spec: validAppSpec, AppID: appID}).Times(2).Return(res, nil)
+		tm.apps.EXPECT().Get(appID).Times(2).Return(existing, nil)
 
-		err := RunAppsTierList(config)
-		require.NoError(t, err)
+		config.Doit.Set(config.NS, doctl.ArgAppSpec, specFile)
+		config.Doit.Set(config.NS, doctl.ArgApp, appID)
+		config.Doit.Set(config.NS, doctl.ArgAppProposeDiff, true)
+
+		t.Run("text", func(t *testing.T) {
+			var buf bytes.Buffer
+			config.Out = &buf
+
+			err := RunAppsPropose(config)
+			require.NoError(t, err)
+			assert.Contains(t, buf.String(), "App Name Available?")
+			assert.Contains(t, buf.String(), `--- app `+appID+`
++++ proposed
+@@ -1,6 +1,6 @@
+ name: test
+ services:
+ - github:
+-    branch: develop
++    branch: main
+     repo: digitalocean/doctl
+   name: service
+`)
+		})
+
+		t.Run("json", func(t *testing.T) {
+			defer func(o string) { Output = o }(Output)
+			Output = "json"
+
+			var buf bytes.Buffer
+			config.Out = &buf
+
+			err := RunAppsPropose(config)
+			require.NoError(t, err)
+
+			var out struct {
+				Spec *godo.AppSpec `json:"spec"`
+				Diff *appSpecDiff  `json:"diff"`
+			}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+			assert.Equal(t, &testAppSpec, out.Spec)
+			assert.Equal(t, []appSpecDiffChange{{
+				Op:       "replace",
+				FromLine: 4,
+				ToLine:   4,
+				Removed:  []string{"    branch: develop"},
+				Added:    []string{"    branch: main"},
+			}}, out.Diff.Changes)
+		})
 	})
 }
 
-func TestRunAppsTierGet(t *testing.T) {
+func TestRunAppsAlertList(t *testing.T) {
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		tm.apps.EXPECT().GetTier(testAppTier.Slug).Times(1).Return(testAppTier, nil)
+		appID := uuid.New().String()
+		alerts := []*do.AppAlert{
+			{ID: "1", Spec: &do.AppAlertSpec{Rule: "DEPLOYMENT_FAILED"}, Emails: []string{"ops@example.com"}, Phase: "ACTIVE"},
+			{
+				ID:            "2",
+				ComponentName: "web",
+				Spec:          &do.AppAlertSpec{Rule: "CPU_UTILIZATION", Operator: "GREATER_THAN", Value: 80, Window: "FIVE_MINUTES"},
+				SlackWebhooks: []*do.AppAlertSlackWebhook{{URL: "https://hooks.slack.com/services/x", Channel: "#ops"}},
+				Phase:         "ACTIVE",
+			},
+		}
+		tm.apps.EXPECT().ListAlerts(appID).Times(1).Return(alerts, nil)
 
-		config.Args = append(config.Args, testAppTier.Slug)
-		err := RunAppsTierGet(config)
+		var buf bytes.Buffer
+		config.Out = &buf
+		config.Args = append(config.Args, appID)
+
+		err := RunAppsAlertList(config)
 		require.NoError(t, err)
+		assert.Equal(t, `ID    Component    Rule                 Trigger                         Disabled    Emails             Slack Webhooks    Phase
+1                  DEPLOYMENT_FAILED                                    false       ops@example.com                      ACTIVE
+2     web          CPU_UTILIZATION      GREATER_THAN 80 FIVE_MINUTES    false                          #ops              ACTIVE
+`, buf.String())
 	})
 }
 
-func TestRunAppsTierInstanceSizeList(t *testing.T) {
-	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		instanceSizes := []*godo.AppInstanceSize{testAppInstanceSize}
+func TestRunAppsAlertUpdate(t *testing.T) {
+	appID := uuid.New().String()
+	webhooks := []*do.AppAlertSlackWebhook{{URL: "https://hooks.slack.com/services/x", Channel: "#ops"}}
+	alert := &do.AppAlert{
+		ID:            "1",
+		Spec:          &do.AppAlertSpec{Rule: "DEPLOYMENT_FAILED"},
+		Emails:        []string{"old@example.com"},
+		SlackWebhooks: webhooks,
+	}
 
-		tm.apps.EXPECT().ListInstanceSizes().Times(1).Return(instanceSizes, nil)
+	t.Run("emails", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			destinations := &do.AppAlertDestinations{
+				Emails:        []string{"ops@example.com", "dev@example.com"},
+				SlackWebhooks: webhooks,
+			}
+			tm.apps.EXPECT().ListAlerts(appID).Times(1).Return([]*do.AppAlert{alert}, nil)
+			tm.apps.EXPECT().UpdateAlertDestinations(appID, alert.ID, destinations).Times(1).Return(alert, nil)
 
-		err := RunAppsTierInstanceSizeList(config)
-		require.NoError(t, err)
+			config.Args = append(config.Args, appID, alert.ID)
+			config.Doit.Set(config.NS, doctl.ArgAppAlertEmails, []string{"ops@example.com", " dev@example.com"})
+
+			err := RunAppsAlertUpdate(config)
+			require.NoError(t, err)
+		})
 	})
-}
 
-func TestRunAppsTierInstanceSizeGet(t *testing.T) {
-	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
-		tm.apps.EXPECT().GetInstanceSize(testAppInstanceSize.Slug).Times(1).Return(testAppInstanceSize, nil)
+	t.Run("slack webhooks", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			destinations := &do.AppAlertDestinations{
+				Emails:        alert.Emails,
+				SlackWebhooks: []*do.AppAlertSlackWebhook{{URL: "https://hooks.slack.com/services/y"}},
+			}
+			tm.apps.EXPECT().ListAlerts(appID).Times(1).Return([]*do.AppAlert{alert}, nil)
+			tm.apps.EXPECT().UpdateAlertDestinations(appID, alert.ID, destinations).Times(1).Return(alert, nil)
 
-		config.Args = append(config.Args, testAppInstanceSize.Slug)
-		err := RunAppsTierInstanceSizeGet(config)
-		require.NoError(t, err)
+			config.Args = append(config.Args, appID, alert.ID)
+			config.Doit.Set(config.NS, doctl.ArgAppAlertSlackWebhooks, []string{"https://hooks.slack.com/services/y"})
+
+			err := RunAppsAlertUpdate(config)
+			require.NoError(t, err)
+		})
+	})
+
+	t.Run("no destinations", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, appID, alert.ID)
+
+			err := RunAppsAlertUpdate(config)
+			require.EqualError(t, err, "pass --emails or --slack-webhooks to update the alert")
+		})
+	})
+
+	t.Run("unknown alert", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.apps.EXPECT().ListAlerts(appID).Times(1).Return([]*do.AppAlert{alert}, nil)
+
+			config.Args = append(config.Args, appID, "2")
+			config.Doit.Set(config.NS, doctl.ArgAppAlertEmails, []string{"ops@example.com"})
+
+			err := RunAppsAlertUpdate(config)
+			require.EqualError(t, err, "app "+appID+" has no alert 2")
+		})
 	})
 }
@@ -79,7 +79,9 @@ If you work with a just one account, you can call ` + "`" + `doctl auth init` +
 
 To switch between multiple DigitalOcean accounts, including team accounts, you can create named contexts by using ` + "`" + `doctl auth init --context <name>` + "`" + `, then providing a token when prompted. This saves the token under the name you provide. To switch between accounts, use ` + "`" + `doctl auth switch --context <name>` + "`" + `.,
 
-To remove accounts from the configuration file, you can run ` + "`" + `doctl auth remove --context <name>` + "`" + `. This removes the token under the name you provide.`,
+To remove accounts from the configuration file, you can run ` + "`" + `doctl auth remove --context <name>` + "`" + `. This removes the token under the name you provide.
+
+Each context can also set its own flag defaults in the configuration file under ` + "`" + `context-defaults.<name>` + "`" + `. Global flags are keyed by name and command flags by command, for example ` + "`" + `output: json` + "`" + ` or ` + "`" + `apps.list.format: ID,Spec.Name` + "`" + `. Flags passed on the command line always take precedence.`,
 		},
 	}
 
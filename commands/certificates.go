@@ -60,7 +60,7 @@ To create a Let's Encrypt certificate, you'll need to add the domain(s) to your
 
 To upload a custom certificate, you'll need to provide a certificate name, the path to the certificate, the path to the private key for the certificate, and the path to the certificate chain, all in PEM format:
 
-	doctl compute certificate create --type custom --name mycert --leaf-certificate-path cert.pem --certificate-chain-path fullchain.pem --private-key-path privkey.pem`, Writer, aliasOpt("c"))
+	doctl compute certificate create --type custom --name mycert --leaf-certificate-path cert.pem --certificate-chain-path fullchain.pem --private-key-path privkey.pem`, Writer, aliasOpt("c"), displayerType(&displayers.Certificate{}))
 	AddStringFlag(cmdCertificateCreate, doctl.ArgCertificateName, "", "",
 		"Certificate name", requiredOpt())
 	AddStringSliceFlag(cmdCertificateCreate, doctl.ArgCertificateDNSNames, "",
@@ -205,7 +205,15 @@ func RunCertificateDelete(c *CmdConfig) error {
 		return err
 	}
 
-	if force || AskForConfirmDelete("certificate", 1) == nil {
+	if !force {
+		cert, err := c.Certificates().Get(cID)
+		if err != nil {
+			return err
+		}
+		force = AskForConfirmDeleteNamed("certificate", cert.Name, cID) == nil
+	}
+
+	if force {
 		cs := c.Certificates()
 		if err := cs.Delete(cID); err != nil {
 			return err
@@ -57,6 +57,8 @@ func cmdBuilderWithInit(parent *Command, cr CmdRunner, cliText, shortdesc string
 		Short: shortdesc,
 		Long:  longdesc,
 		Run: func(cmd *cobra.Command, args []string) {
+			checkErr(applyContextDefaults(cmd))
+
 			c, err := NewCmdConfig(
 				cmdNS(cmd),
 				&doctl.LiveConfig{},
@@ -86,6 +88,8 @@ func cmdBuilderWithInit(parent *Command, cr CmdRunner, cliText, shortdesc string
 			strings.Join(cols, "`"+", "+"`"))
 		AddStringFlag(c, doctl.ArgFormat, "", "", formatHelp)
 		AddBoolFlag(c, doctl.ArgNoHeader, "", false, "Return raw data with no headers")
+		AddBoolFlag(c, doctl.ArgIgnoreNotFound, "", false, "Don't return an error when a jsonpath output expression matches nothing")
+		AddStringFlag(c, doctl.ArgFormatTemplate, "", "", "Format each result with a Go template, e.g. '{{.ID}} {{.Name}}'")
 	}
 
 	return c
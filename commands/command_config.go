@@ -78,6 +78,10 @@ func NewCmdConfig(ns string, dc doctl.Config, out io.Writer, args []string, init
 		Args: args,
 
 		initServices: func(c *CmdConfig) error {
+			if viper.GetBool(doctl.ArgInsecureSkipVerify) {
+				warn("TLS certificate verification is disabled by --%s. Connections are not secure.", doctl.ArgInsecureSkipVerify)
+			}
+
 			accessToken := c.getContextAccessToken()
 			godoClient, err := c.Doit.GetGodoClient(Trace, accessToken)
 			if err != nil {
@@ -200,8 +204,18 @@ func (c *CmdConfig) Display(d displayers.Displayable) error {
 		return err
 	}
 
+	tmpl, err := c.Doit.GetString(c.NS, doctl.ArgFormatTemplate)
+	if err != nil {
+		return err
+	}
+
 	dc.NoHeaders = withHeaders
 	dc.ColumnList = columnList
+	dc.Template = tmpl
+	dc.IgnoreNotFound, err = c.Doit.GetBool(c.NS, doctl.ArgIgnoreNotFound)
+	if err != nil {
+		return err
+	}
 	dc.OutputType = Output
 
 	return dc.Display()
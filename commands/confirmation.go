@@ -14,12 +14,23 @@ var retrieveUserInput = func(message string) (string, error) {
 	return readUserInput(os.Stdin, message)
 }
 
+// retrieveTypedInput prompts the user with the given message and returns the
+// text they typed. Like retrieveUserInput, it can be replaced in tests.
+var retrieveTypedInput = func(prompt string) (string, error) {
+	return readAnswer(os.Stdin, prompt)
+}
+
 // readUserInput is similar to retrieveUserInput but takes an explicit
 // io.Reader to read user input from. It is meant to allow simplified testing
 // as to-be-read inputs can be injected conveniently.
 func readUserInput(in io.Reader, message string) (string, error) {
+	return readAnswer(in, "Are you sure you want to "+message+" (y/N) ? ")
+}
+
+// readAnswer prints prompt and reads a single line of input from in.
+func readAnswer(in io.Reader, prompt string) (string, error) {
 	reader := bufio.NewReader(in)
-	warnConfirm("Are you sure you want to " + message + " (y/N) ? ")
+	warnConfirm(prompt)
 	answer, err := reader.ReadString('\n')
 	if err != nil {
 		return "", err
@@ -44,6 +55,21 @@ func AskForConfirm(message string) error {
 	return nil
 }
 
+// AskForTypedConfirm asks the user to confirm a dangerous operation by typing
+// the expected phrase, rather than simply answering yes.
+func AskForTypedConfirm(message, expected string) error {
+	answer, err := retrieveTypedInput(fmt.Sprintf("%s Type %q to confirm: ", message, expected))
+	if err != nil {
+		return fmt.Errorf("Unable to parse users input: %s", err)
+	}
+
+	if answer != strings.ToLower(expected) {
+		return fmt.Errorf("Invalid user input")
+	}
+
+	return nil
+}
+
 // AskForConfirmDelete builds a message to ask the user to confirm deleteing
 // one or multiple resources and then sends it through to AskForConfirm to
 // parses and verifies user input.
@@ -61,3 +87,15 @@ func AskForConfirmDelete(resourceType string, count int) error {
 
 	return nil
 }
+
+// AskForConfirmDeleteNamed asks the user to confirm deleting a single
+// resource, identifying it by both its name and ID so that the user can tell
+// which resource is about to be deleted.
+func AskForConfirmDeleteNamed(resourceType, name, id string) error {
+	message := fmt.Sprintf("delete %s %q (%s)?", resourceType, name, id)
+	if name == "" || name == id {
+		message = fmt.Sprintf("delete %s %s?", resourceType, id)
+	}
+
+	return AskForConfirm(message)
+}
@@ -88,3 +88,32 @@ func TestReadUserInput(t *testing.T) {
 		})
 	}
 }
+
+func TestAskForConfirmDeleteNamed(t *testing.T) {
+	rui := retrieveUserInput
+	defer func() {
+		retrieveUserInput = rui
+	}()
+
+	tests := []struct {
+		name string
+		id   string
+		want string
+	}{
+		{name: "my-api", id: "abc123", want: `delete app "my-api" (abc123)?`},
+		{name: "", id: "abc123", want: "delete app abc123?"},
+		{name: "abc123", id: "abc123", want: "delete app abc123?"},
+	}
+
+	for _, tt := range tests {
+		var got string
+		retrieveUserInput = func(message string) (string, error) {
+			got = message
+			return "yes", nil
+		}
+
+		err := AskForConfirmDeleteNamed("app", tt.name, tt.id)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got)
+	}
+}
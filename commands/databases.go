@@ -16,6 +16,10 @@ package commands
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/digitalocean/doctl"
@@ -23,6 +27,7 @@ import (
 	"github.com/digitalocean/doctl/do"
 	"github.com/digitalocean/godo"
 	"github.com/spf13/cobra"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
 const (
@@ -69,7 +74,7 @@ func Databases() *Command {
 	cmdDatabaseCreate := CmdBuilder(cmd, RunDatabaseCreate, "create <name>", "Create a database cluster", `This command creates a database cluster with the specified name.
 
 There are a number of flags that customize the configuration, all of which are optional. Without any flags set, a single-node, single-CPU PostgreSQL database cluster will be created.`, Writer,
-		aliasOpt("c"))
+		aliasOpt("c"), displayerType(&displayers.Databases{}))
 	AddIntFlag(cmdDatabaseCreate, doctl.ArgDatabaseNumNodes, "", defaultDatabaseNodeCount, nodeNumberDetails)
 	AddStringFlag(cmdDatabaseCreate, doctl.ArgRegionSlug, "", defaultDatabaseRegion, "The region where the database cluster will be created, e.g. `nyc1` or `sfo2`")
 	AddStringFlag(cmdDatabaseCreate, doctl.ArgSizeSlug, "", defaultDatabaseNodeSize, nodeSizeDetails)
@@ -225,8 +230,16 @@ func RunDatabaseDelete(c *CmdConfig) error {
 		return err
 	}
 
-	if force || AskForConfirmDelete("database cluster", 1) == nil {
-		id := c.Args[0]
+	id := c.Args[0]
+	if !force {
+		db, err := c.Databases().Get(id)
+		if err != nil {
+			return err
+		}
+		force = AskForConfirmDeleteNamed("database cluster", db.Name, id) == nil
+	}
+
+	if force {
 		return c.Databases().Delete(id)
 	}
 
@@ -478,13 +491,13 @@ To retrieve a list of database users for a database, call `+"`"+`doctl databases
 
 The user will be created with the role set to `+"`"+`normal`+"`"+`, and given an automatically-generated password.
 
-To retrieve a list of your databases and their IDs, call `+"`"+`doctl databases list`+"`"+`.`, Writer, aliasOpt("c"))
+To retrieve a list of your databases and their IDs, call `+"`"+`doctl databases list`+"`"+`.`, Writer, aliasOpt("c"), displayerType(&displayers.DatabaseUsers{}))
 
 	AddStringFlag(cmdDatabaseUserCreate, doctl.ArgDatabaseUserMySQLAuthPlugin, "", "",
 		"set auth mode for MySQL users")
 
 	CmdBuilder(cmd, RunDatabaseUserResetAuth, "reset <database-id> <user-name> <new-auth-mode>",
-		"Resets a user's MySQL auth plugin", "This command resets the MySQL auth plugin for a given user. It will return the new user credentials. Valid values for `<new-auth-mode>` are `caching_sha2_password` and `mysql_native_password`.", Writer, aliasOpt("rs"))
+		"Resets a user's MySQL auth plugin", "This command resets the MySQL auth plugin for a given user. It will return the new user credentials. Valid values for `<new-auth-mode>` are `caching_sha2_password` and `mysql_native_password`.", Writer, aliasOpt("rs"), displayerType(&displayers.DatabaseUsers{}))
 
 	cmdDatabaseUserDelete := CmdBuilder(cmd, RunDatabaseUserDelete,
 		"delete <database-id> <user-id>", "Delete a database user", `This command deletes the user with the username you specify, whose account was given access to the database cluster you specify.
@@ -667,7 +680,7 @@ There’s no perfect formula to determine how large your pool should be, but the
 - A pool that’s much smaller than the number of clients communicating with the database can act as a bottleneck, reducing the rate when your database receives and responds to transactions.
 
 We recommend starting with a pool size of about half your available connections and adjusting later based on performance. If you see slow query responses, check the CPU usage on the database’s Overview tab. We recommend decreasing your pool size if CPU usage is high, and increasing your pool size if it’s low.`+getPoolDetails, Writer,
-		aliasOpt("c"))
+		aliasOpt("c"), displayerType(&displayers.DatabasePools{}))
 	AddStringFlag(cmdDatabasePoolCreate, doctl.ArgDatabasePoolMode, "",
 		"transaction", "The pool mode for the connection pool, e.g. `session`, `transaction`, and `statement`")
 	AddIntFlag(cmdDatabasePoolCreate, doctl.ArgSizeSlug, "", 0, "pool size",
@@ -822,7 +835,7 @@ You can use these commands to create and delete databases within a cluster, or s
 	CmdBuilder(cmd, RunDatabaseDBGet, "get <database-id> <db-name>", "Retrieve the name of a database within a cluster", "This command retrieves the name of the specified database hosted in the specified database cluster."+getClusterList+getDBList,
 		Writer, aliasOpt("g"), displayerType(&displayers.DatabaseDBs{}))
 	CmdBuilder(cmd, RunDatabaseDBCreate, "create <database-id> <db-name>",
-		"Create a database within a cluster", "This command creates a database with the specified name in the specified database cluster."+getClusterList, Writer, aliasOpt("c"))
+		"Create a database within a cluster", "This command creates a database with the specified name in the specified database cluster."+getClusterList, Writer, aliasOpt("c"), displayerType(&displayers.DatabaseDBs{}))
 
 	cmdDatabaseDBDelete := CmdBuilder(cmd, RunDatabaseDBDelete,
 		"delete <database-id> <db-name>", "Delete the specified database from the cluster", "This command deletes the specified database from the specified database cluster."+getClusterList+getDBList, Writer, aliasOpt("rm"))
@@ -947,7 +960,7 @@ This command requires that you pass in the replica's name, which you can retriev
 
 	cmdDatabaseReplicaCreate := CmdBuilder(cmd, RunDatabaseReplicaCreate,
 		"create <database-id> <replica-name>", "Create a read-only database replica", `This command creates a read-only database replica for the specified database cluster, giving it the specified name.`+databaseListDetails,
-		Writer, aliasOpt("c"))
+		Writer, aliasOpt("c"), displayerType(&displayers.DatabaseReplicas{}))
 	AddStringFlag(cmdDatabaseReplicaCreate, doctl.ArgRegionSlug, "",
 		defaultDatabaseRegion, "Specifies the region (e.g. nyc3, sfo2) in which to create the replica")
 	AddStringFlag(cmdDatabaseReplicaCreate, doctl.ArgSizeSlug, "",
@@ -965,7 +978,7 @@ This command requires that you pass in the replica's name, which you can retriev
 	CmdBuilder(cmd, RunDatabaseReplicaConnectionGet,
 		"connection <database-id> <replica-name>",
 		"Retrieve information for connecting to a read-only database replica",
-		`This command retrieves information for connecting to the specified read-only database replica in the specified database cluster`+howToGetReplica+databaseListDetails, Writer, aliasOpt("conn"))
+		`This command retrieves information for connecting to the specified read-only database replica in the specified database cluster`+howToGetReplica+databaseListDetails, Writer, aliasOpt("conn"), displayerType(&displayers.DatabaseConnection{}))
 
 	return cmd
 }
@@ -1180,14 +1193,14 @@ This command requires the ID of a database cluster, which you can retrieve by ca
 
 	doctl databases list`
 
-	databaseFirewallRulesTxt := "A comma-separated list of firewall rules of format type:value, e.g.: `type:value`"
+	databaseFirewallRulesTxt := "A comma-separated list of firewall rules of format type:value, e.g.: `type:value`. The type may be ip_addr, droplet, k8s, tag, or app"
 
 	databaseFirewallUpdateDetails := `
 Use this command to replace the firewall rules of a given database. This command requires the ID of a database cluster, which you can retrieve by calling:
 
 	doctl databases list 
 	
-This command also requires a --rule flag. You can pass in multiple --rule flags. Each rule passed in to the --rule flag must be of format type:value
+This command also requires a --rule flag or a --rules-file. You can pass in multiple --rule flags. Each rule passed in to the --rule flag must be of format type:value
 	- "type" is the type of resource that the firewall rule allows to access the database cluster. The possible values for type are:  "droplet", "k8s", "ip_addr", or "tag"
 	- "value" is either the ID of the specific resource, the name of a tag applied to a group of resources, or the IP address that the firewall rule allows to access the database cluster
 
@@ -1200,11 +1213,22 @@ For example:
 	databases firewalls replace d1234-1c12-1234-b123-12345c4789 --rule tag:backend,ip_addr:0.0.0.0
 
 This would replace the firewall rules for database of id d1234-1c12-1234-b123-12345c4789 with the two rules passed above (tag:backend, ip_addr:0.0.0.0)
+
+Rules can also be read from a JSON or YAML file with the --rules-file flag, which is useful for keeping allowlists under version control. The file must contain a list of rules, each with a type and a value:
+
+	- type: tag
+	  value: backend
+	- type: ip_addr
+	  value: 192.168.1.2
+
+Rules from the file are combined with any passed to the --rule flag.
+
+To remove specific rules instead, pass them to the --remove-rule flag in the same type:value format, without --rule or --rules-file. The database's other firewall rules are kept, and a warning is shown for each rule that doesn't exist.
 	`
 
 	databaseFirewallAddDetails :=
 		`
-Use this command to append a single rule to the existing firewall rules of a given database. This command requires the ID of a database cluster, which you can retrieve by calling:
+Use this command to append rules to the existing firewall rules of a given database. Rules that already exist are skipped. This command requires the ID of a database cluster, which you can retrieve by calling:
 
 	doctl databases list
 
@@ -1216,7 +1240,15 @@ For example:
 
 	doctl databases firewalls append d1234-1c12-1234-b123-12345c4789 --rule tag:backend
 
-This would append the firewall rule "tag:backend" for database of id d1234-1c12-1234-b123-12345c4789`
+This would append the firewall rule "tag:backend" for database of id d1234-1c12-1234-b123-12345c4789
+
+To append multiple rules, repeat the --rule flag or pass a comma-separated list:
+
+	doctl databases firewalls append d1234-1c12-1234-b123-12345c4789 --rule tag:backend --rule ip_addr:192.168.1.2
+
+To remove existing rules in the same update, pass them to the --remove-rule flag in the same type:value format:
+
+	doctl databases firewalls append d1234-1c12-1234-b123-12345c4789 --rule ip_addr:192.168.1.3 --remove-rule ip_addr:192.168.1.2`
 
 	databaseFirewallRemoveDetails :=
 		`
@@ -1236,19 +1268,32 @@ This would remove the firewall rule of uuid 12345d-1234-123d-123x-123eee456e for
 			`
 
 	CmdBuilder(cmd, RunDatabaseFirewallRulesList, "list <database-id>", "Retrieve a list of firewall rules for a given database", firewallRuleDetails+databaseFirewallRuleDetails,
-		Writer, aliasOpt("ls"))
+		Writer, aliasOpt("ls"), displayerType(&displayers.DatabaseFirewallRules{}))
 
 	cmdDatabaseFirewallUpdate := CmdBuilder(cmd, RunDatabaseFirewallRulesUpdate, "replace <db-id> --rules type:value [--rule type:value]", "Replaces the firewall rules for a given database. The rules passed in to the --rules flag will replace the firewall rules previously assigned to the database,", databaseFirewallUpdateDetails,
-		Writer, aliasOpt("r"))
-	AddStringSliceFlag(cmdDatabaseFirewallUpdate, doctl.ArgDatabaseFirewallRule, "", []string{}, databaseFirewallRulesTxt, requiredOpt())
-
-	cmdDatabaseFirewallCreate := CmdBuilder(cmd, RunDatabaseFirewallRulesAppend, "append <db-id> --rule type:value", "Add a database firewall rule to a given database", databaseFirewallAddDetails,
-		Writer, aliasOpt("a"))
-	AddStringFlag(cmdDatabaseFirewallCreate, doctl.ArgDatabaseFirewallRule, "", "", "", requiredOpt())
+		Writer, aliasOpt("r"), displayerType(&displayers.DatabaseFirewallRules{}))
+	AddStringSliceFlag(cmdDatabaseFirewallUpdate, doctl.ArgDatabaseFirewallRule, "", []string{}, databaseFirewallRulesTxt)
+	AddStringFlag(cmdDatabaseFirewallUpdate, doctl.ArgDatabaseFirewallRulesFile, "", "", "Path to a JSON or YAML file containing a list of firewall rules with type and value fields. Rules in the file are combined with any passed to --rule")
+	AddStringSliceFlag(cmdDatabaseFirewallUpdate, doctl.ArgDatabaseFirewallRemoveRule, "", []string{}, "A comma-separated list of existing firewall rules of format type:value to remove, keeping the others. Cannot be combined with --rule or --rules-file")
+	AddBoolFlag(cmdDatabaseFirewallUpdate, doctl.ArgDryRun, "", false, "Display the resulting firewall rules without changing them")
+
+	cmdDatabaseFirewallCreate := CmdBuilder(cmd, RunDatabaseFirewallRulesAppend, "append <db-id> --rule type:value [--rule type:value] [--remove-rule type:value]", "Add database firewall rules to a given database", databaseFirewallAddDetails,
+		Writer, aliasOpt("a"), displayerType(&displayers.DatabaseFirewallRules{}))
+	AddStringSliceFlag(cmdDatabaseFirewallCreate, doctl.ArgDatabaseFirewallRule, "", []string{}, databaseFirewallRulesTxt)
+	AddStringSliceFlag(cmdDatabaseFirewallCreate, doctl.ArgDatabaseFirewallRemoveRule, "", []string{}, "A comma-separated list of existing firewall rules of format type:value to remove")
+	AddBoolFlag(cmdDatabaseFirewallCreate, doctl.ArgDryRun, "", false, "Display the resulting firewall rules without changing them")
 
 	cmdDatabaseFirewallRemove := CmdBuilder(cmd, RunDatabaseFirewallRulesRemove, "remove <firerule-uuid>", "Remove a firewall rule for a given database", databaseFirewallRemoveDetails,
-		Writer, aliasOpt("rm"))
+		Writer, aliasOpt("rm"), displayerType(&displayers.DatabaseFirewallRules{}))
 	AddStringFlag(cmdDatabaseFirewallRemove, doctl.ArgDatabaseFirewallRuleUUID, "", "", "", requiredOpt())
+	AddBoolFlag(cmdDatabaseFirewallRemove, doctl.ArgDryRun, "", false, "Display the resulting firewall rules without changing them")
+
+	cmdDatabaseFirewallClear := CmdBuilder(cmd, RunDatabaseFirewallsClear, "clear <db-id>", "Remove all firewall rules for a given database", `
+Use this command to remove every firewall rule of a given database, leaving its firewall rule list empty. You are asked to confirm before the rules are removed unless --force is passed. This command requires the ID of a database cluster, which you can retrieve by calling:
+
+	doctl databases list`,
+		Writer, displayerType(&displayers.DatabaseFirewallRules{}))
+	AddBoolFlag(cmdDatabaseFirewallClear, doctl.ArgForce, doctl.ArgShortForce, false, "Remove the firewall rules without a confirmation prompt")
 
 	return cmd
 
@@ -1268,6 +1313,54 @@ func displayDatabaseFirewallRules(c *CmdConfig, short bool, id string) error {
 	return c.Display(item)
 }
 
+// updateDatabaseFirewallRules replaces the firewall rules of a database with
+// rules and displays the result. With --dry-run, rules are displayed without
+// changing the database's firewall rules.
+func updateDatabaseFirewallRules(c *CmdConfig, databaseID string, rules []*godo.DatabaseFirewallRule) error {
+	dryRun, err := c.Doit.GetBool(c.NS, doctl.ArgDryRun)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		notice("Dry run: no changes were made. The firewall rules of database %s would be:", databaseID)
+		list := make(do.DatabaseFirewallRules, 0, len(rules))
+		for _, rule := range rules {
+			list = append(list, do.DatabaseFirewallRule{DatabaseFirewallRule: rule})
+		}
+		return c.Display(&displayers.DatabaseFirewallRules{DatabaseFirewallRules: list})
+	}
+
+	if err := c.Databases().UpdateFirewallRules(databaseID, &godo.DatabaseUpdateFirewallRulesRequest{
+		Rules: rules,
+	}); err != nil {
+		return err
+	}
+
+	return displayDatabaseFirewallRules(c, true, databaseID)
+}
+
+// RunDatabaseFirewallsClear removes every firewall rule of a database cluster.
+func RunDatabaseFirewallsClear(c *CmdConfig) error {
+	err := firewallRulesArgumentCheck(c)
+	if err != nil {
+		return err
+	}
+
+	databaseID := c.Args[0]
+
+	force, err := c.Doit.GetBool(c.NS, doctl.ArgForce)
+	if err != nil {
+		return err
+	}
+
+	if !force && AskForConfirm(fmt.Sprintf("remove all firewall rules from database cluster %s?", databaseID)) != nil {
+		return fmt.Errorf("Operation aborted.")
+	}
+
+	return updateDatabaseFirewallRules(c, databaseID, []*godo.DatabaseFirewallRule{})
+}
+
 // All firewall rules require the databaseID
 func firewallRulesArgumentCheck(c *CmdConfig) error {
 	if len(c.Args) == 0 {
@@ -1299,18 +1392,60 @@ func RunDatabaseFirewallRulesUpdate(c *CmdConfig) error {
 	}
 
 	id := c.Args[0]
+
+	removeRuleArgs, err := c.Doit.GetStringSlice(c.NS, doctl.ArgDatabaseFirewallRemoveRule)
+	if err != nil {
+		return err
+	}
+	if len(removeRuleArgs) > 0 {
+		return removeDatabaseFirewallRules(c, id, removeRuleArgs)
+	}
+
 	r, err := buildDatabaseUpdateFirewallRulesRequestFromArgs(c)
 	if err != nil {
 		return err
 	}
 
-	err = c.Databases().UpdateFirewallRules(id, r)
+	return updateDatabaseFirewallRules(c, id, r.Rules)
+
+}
+
+// removeDatabaseFirewallRules removes the rules matching removeRuleArgs from
+// the existing firewall rules of a database cluster. As the remaining rules
+// are kept, it cannot be combined with rules that replace them.
+func removeDatabaseFirewallRules(c *CmdConfig, databaseID string, removeRuleArgs []string) error {
+	firewallRules, err := c.Doit.GetStringSlice(c.NS, doctl.ArgDatabaseFirewallRule)
+	if err != nil {
+		return err
+	}
+	rulesFile, err := c.Doit.GetString(c.NS, doctl.ArgDatabaseFirewallRulesFile)
+	if err != nil {
+		return err
+	}
+	if len(firewallRules) > 0 || rulesFile != "" {
+		return fmt.Errorf("--%s cannot be combined with --%s or --%s; use append to add and remove rules in one command",
+			doctl.ArgDatabaseFirewallRemoveRule, doctl.ArgDatabaseFirewallRule, doctl.ArgDatabaseFirewallRulesFile)
+	}
+
+	removeRules, err := extractFirewallRules(removeRuleArgs)
 	if err != nil {
 		return err
 	}
 
-	return displayDatabaseFirewallRules(c, true, id)
+	existingRules, err := c.Databases().GetFirewallRules(databaseID)
+	if err != nil {
+		return err
+	}
+	rules := make([]*godo.DatabaseFirewallRule, 0, len(existingRules))
+	for _, rule := range existingRules {
+		rules = append(rules, rule.DatabaseFirewallRule)
+	}
 
+	rules, removed := removeFirewallRules(rules, removeRules)
+	if !removed {
+		return displayDatabaseFirewallRules(c, true, databaseID)
+	}
+	return updateDatabaseFirewallRules(c, databaseID, rules)
 }
 
 // buildDatabaseUpdateFirewallRulesRequestFromArgs will ingest the --rules arguments into a DatabaseUpdateFirewallRulesRequest object.
@@ -1322,27 +1457,66 @@ func buildDatabaseUpdateFirewallRulesRequestFromArgs(c *CmdConfig) (*godo.Databa
 		return nil, err
 	}
 
-	if len(firewallRules) == 0 {
-		return nil, errors.New("Must pass in a key:value pair for the --rule flag")
+	rulesFile, err := c.Doit.GetString(c.NS, doctl.ArgDatabaseFirewallRulesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(firewallRules) == 0 && rulesFile == "" {
+		return nil, errors.New("Must pass in a key:value pair for the --rule flag or a --rules-file")
+	}
+
+	if rulesFile != "" {
+		r.Rules, err = readFirewallRulesFile(rulesFile)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	firewallRulesList, err := extractFirewallRules(firewallRules)
 	if err != nil {
 		return nil, err
 	}
-	r.Rules = firewallRulesList
+	r.Rules = dedupeFirewallRules(append(r.Rules, firewallRulesList...))
+
+	// Replacing the rules with an empty list would remove every rule, which
+	// is what firewalls clear is for.
+	if len(r.Rules) == 0 {
+		return nil, errors.New("No firewall rules to replace the existing rules with; to remove every firewall rule, use doctl databases firewalls clear")
+	}
 
 	return r, nil
 
 }
 
+// dedupeFirewallRules returns rules without repeated type:value pairs,
+// keeping the first occurrence of each.
+func dedupeFirewallRules(rules []*godo.DatabaseFirewallRule) []*godo.DatabaseFirewallRule {
+	seen := make(map[string]bool, len(rules))
+	deduped := make([]*godo.DatabaseFirewallRule, 0, len(rules))
+	for _, rule := range rules {
+		key := rule.Type + ":" + rule.Value
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		deduped = append(deduped, rule)
+	}
+	return deduped
+}
+
 // extractFirewallRules will ingest the --rules arguments into a list of DatabaseFirewallRule objects.
 func extractFirewallRules(rulesStringList []string) (rules []*godo.DatabaseFirewallRule, err error) {
 	for _, rule := range rulesStringList {
-		pair := strings.SplitN(rule, ":", 2)
+		pair := strings.SplitN(strings.TrimSpace(rule), ":", 2)
 		if len(pair) != 2 {
 			return nil, fmt.Errorf("Unexpected input value [%v], must be a key:value pair", pair)
 		}
+		pair[0], pair[1] = strings.TrimSpace(pair[0]), strings.TrimSpace(pair[1])
+
+		if err := validateFirewallRule(pair[0], pair[1]); err != nil {
+			return nil, err
+		}
 
 		firewallRule := new(godo.DatabaseFirewallRule)
 		firewallRule.Type = pair[0]
@@ -1355,7 +1529,85 @@ func extractFirewallRules(rulesStringList []string) (rules []*godo.DatabaseFirew
 
 }
 
-// RunDatabaseFirewallRulesAppend creates a firewall rule for a database cluster.
+// readFirewallRulesFile reads a JSON or YAML list of firewall rules from path.
+// Errors include the line of the offending rule.
+func readFirewallRulesFile(path string) ([]*godo.DatabaseFirewallRule, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("Failed to parse firewall rules file %s: %v", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	list := doc.Content[0]
+	if list.Kind != yamlv3.SequenceNode {
+		return nil, fmt.Errorf("%s:%d: firewall rules file must contain a list of rules", path, list.Line)
+	}
+
+	var rules []*godo.DatabaseFirewallRule
+	for _, item := range list.Content {
+		var rule struct {
+			Type  string `yaml:"type"`
+			Value string `yaml:"value"`
+		}
+		if err := item.Decode(&rule); err != nil {
+			return nil, fmt.Errorf("%s:%d: rule must have type and value fields", path, item.Line)
+		}
+		rule.Type, rule.Value = strings.TrimSpace(rule.Type), strings.TrimSpace(rule.Value)
+		if err := validateFirewallRule(rule.Type, rule.Value); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, item.Line, err)
+		}
+
+		rules = append(rules, &godo.DatabaseFirewallRule{
+			Type:  rule.Type,
+			Value: rule.Value,
+		})
+	}
+	return rules, nil
+}
+
+var (
+	databaseFirewallRuleTypes = []string{"ip_addr", "droplet", "k8s", "tag", "app"}
+
+	uuidPattern    = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	tagNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_\-:]{1,255}$`)
+)
+
+// validateFirewallRule checks that value is valid for a firewall rule of the
+// given type.
+func validateFirewallRule(ruleType, value string) error {
+	switch ruleType {
+	case "ip_addr":
+		if net.ParseIP(value) == nil {
+			if _, _, err := net.ParseCIDR(value); err != nil {
+				return fmt.Errorf("Invalid ip_addr firewall rule value %q, must be an IP address or CIDR block", value)
+			}
+		}
+	case "droplet":
+		if id, err := strconv.Atoi(value); err != nil || id <= 0 {
+			return fmt.Errorf("Invalid droplet firewall rule value %q, must be a Droplet ID", value)
+		}
+	case "k8s", "app":
+		if !uuidPattern.MatchString(value) {
+			return fmt.Errorf("Invalid %s firewall rule value %q, must be a UUID", ruleType, value)
+		}
+	case "tag":
+		if !tagNamePattern.MatchString(value) {
+			return fmt.Errorf("Invalid tag firewall rule value %q, must be a tag name", value)
+		}
+	default:
+		return fmt.Errorf("Unknown firewall rule type %q, must be one of: %s", ruleType, strings.Join(databaseFirewallRuleTypes, ", "))
+	}
+	return nil
+}
+
+// RunDatabaseFirewallRulesAppend creates firewall rules for a database cluster.
 //
 // Any new rules will be appended to the existing rules. If you want to replace
 // rules, use RunDatabaseFirewallRulesUpdate.
@@ -1366,32 +1618,66 @@ func RunDatabaseFirewallRulesAppend(c *CmdConfig) error {
 	}
 
 	databaseID := c.Args[0]
-	firewallRuleArg, err := c.Doit.GetString(c.NS, doctl.ArgDatabaseFirewallRule)
+	firewallRuleArgs, err := c.Doit.GetStringSlice(c.NS, doctl.ArgDatabaseFirewallRule)
 	if err != nil {
 		return err
 	}
-
-	pair := strings.SplitN(firewallRuleArg, ":", 2)
-	if len(pair) != 2 {
-		return fmt.Errorf("Unexpected input value [%v], must be a key:value pair", pair)
+	removeRuleArgs, err := c.Doit.GetStringSlice(c.NS, doctl.ArgDatabaseFirewallRemoveRule)
+	if err != nil {
+		return err
+	}
+	if len(firewallRuleArgs) == 0 && len(removeRuleArgs) == 0 {
+		return errors.New("Must pass in a key:value pair for the --rule or --remove-rule flag")
 	}
 
-	// Slice will house old rules and new rule
-	allRules := []*godo.DatabaseFirewallRule{}
-
-	// Adding new rule to slice.
-	allRules = append(allRules, &godo.DatabaseFirewallRule{
-		Type:        pair[0],
-		Value:       pair[1],
-		ClusterUUID: databaseID,
-	})
+	newRules, err := extractFirewallRules(firewallRuleArgs)
+	if err != nil {
+		return err
+	}
+	removeRules, err := extractFirewallRules(removeRuleArgs)
+	if err != nil {
+		return err
+	}
 
 	// Retrieve any existing firewall rules so that we don't destroy existing
 	// rules in the create request.
-	oldRules, err := c.Databases().GetFirewallRules(databaseID)
+	existingRules, err := c.Databases().GetFirewallRules(databaseID)
 	if err != nil {
 		return err
 	}
+	oldRules := make([]*godo.DatabaseFirewallRule, 0, len(existingRules))
+	for _, rule := range existingRules {
+		oldRules = append(oldRules, rule.DatabaseFirewallRule)
+	}
+	oldRules, removed := removeFirewallRules(oldRules, removeRules)
+
+	// Track rules by type and value so that duplicates aren't submitted.
+	seen := make(map[string]bool)
+	for _, rule := range oldRules {
+		seen[rule.Type+":"+rule.Value] = true
+	}
+
+	// Slice will house old rules and new rules
+	allRules := []*godo.DatabaseFirewallRule{}
+
+	// Adding new rules to slice.
+	for _, rule := range newRules {
+		key := rule.Type + ":" + rule.Value
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		rule.ClusterUUID = databaseID
+		allRules = append(allRules, rule)
+	}
+
+	if len(allRules) == 0 && !removed {
+		if len(newRules) > 0 {
+			notice("All of the given firewall rules already exist")
+		}
+		return displayDatabaseFirewallRules(c, true, databaseID)
+	}
 
 	// Add old rules to allRules slice.
 	for _, rule := range oldRules {
@@ -1405,14 +1691,42 @@ func RunDatabaseFirewallRulesAppend(c *CmdConfig) error {
 		allRules = append(allRules, firewallRule)
 	}
 
-	// Run update firewall rules with old rules + new rule
-	if err := c.Databases().UpdateFirewallRules(databaseID, &godo.DatabaseUpdateFirewallRulesRequest{
-		Rules: allRules,
-	}); err != nil {
-		return err
+	// Run update firewall rules with old rules + new rules
+	return updateDatabaseFirewallRules(c, databaseID, allRules)
+}
+
+// removeFirewallRules returns rules without those matching the type and value
+// of a rule in remove, and whether any were removed. A warning is shown for
+// each rule in remove that matches none of rules.
+func removeFirewallRules(rules, remove []*godo.DatabaseFirewallRule) ([]*godo.DatabaseFirewallRule, bool) {
+	if len(remove) == 0 {
+		return rules, false
 	}
 
-	return displayDatabaseFirewallRules(c, true, databaseID)
+	matched := make(map[string]bool)
+	for _, rule := range remove {
+		matched[rule.Type+":"+rule.Value] = false
+	}
+
+	kept := make([]*godo.DatabaseFirewallRule, 0, len(rules))
+	for _, rule := range rules {
+		key := rule.Type + ":" + rule.Value
+		if _, ok := matched[key]; ok {
+			matched[key] = true
+			continue
+		}
+		kept = append(kept, rule)
+	}
+
+	for _, rule := range remove {
+		key := rule.Type + ":" + rule.Value
+		if !matched[key] {
+			warn("Firewall rule %s does not match any rule and was not removed", key)
+			// Only warn once for duplicate removals.
+			matched[key] = true
+		}
+	}
+	return kept, len(kept) < len(rules)
 }
 
 // RunDatabaseFirewallRulesRemove removes a firewall rule for a database cluster via Firewall rule UUID
@@ -1451,11 +1765,5 @@ func RunDatabaseFirewallRulesRemove(c *CmdConfig) error {
 		}
 	}
 
-	if err := c.Databases().UpdateFirewallRules(databaseID, &godo.DatabaseUpdateFirewallRulesRequest{
-		Rules: firewallRules,
-	}); err != nil {
-		return err
-	}
-
-	return displayDatabaseFirewallRules(c, true, databaseID)
+	return updateDatabaseFirewallRules(c, databaseID, firewallRules)
 }
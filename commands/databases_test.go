@@ -1,13 +1,16 @@
 package commands
 
 import (
+	"bytes"
 	"errors"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/digitalocean/doctl"
 	"github.com/digitalocean/doctl/do"
 	"github.com/digitalocean/godo"
+	"github.com/fatih/color"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 )
@@ -980,3 +983,340 @@ func TestDatabaseSetSQLModes(t *testing.T) {
 		})
 	})
 }
+
+func TestDatabaseFirewallRulesList(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			rules := do.DatabaseFirewallRules{
+				{DatabaseFirewallRule: &godo.DatabaseFirewallRule{
+					UUID:        "cdb689c2-56e6-48e6-869d-306c85af178d",
+					ClusterUUID: testDBCluster.ID,
+					Type:        "tag",
+					Value:       "backend",
+				}},
+			}
+			tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(rules, nil)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+
+			err := RunDatabaseFirewallRulesList(config)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(nil, errTest)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+
+			err := RunDatabaseFirewallRulesList(config)
+			assert.Error(t, err)
+		})
+	})
+}
+
+func TestDatabaseFirewallRulesAppend(t *testing.T) {
+	existing := do.DatabaseFirewallRules{
+		{DatabaseFirewallRule: &godo.DatabaseFirewallRule{
+			UUID:        "cdb689c2-56e6-48e6-869d-306c85af178d",
+			ClusterUUID: testDBCluster.ID,
+			Type:        "tag",
+			Value:       "backend",
+		}},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(existing, nil).Times(2)
+			tm.databases.EXPECT().UpdateFirewallRules(testDBCluster.ID, &godo.DatabaseUpdateFirewallRulesRequest{
+				Rules: []*godo.DatabaseFirewallRule{
+					{ClusterUUID: testDBCluster.ID, Type: "ip_addr", Value: "192.168.1.2"},
+					{UUID: "cdb689c2-56e6-48e6-869d-306c85af178d", ClusterUUID: testDBCluster.ID, Type: "tag", Value: "backend"},
+				},
+			}).Return(nil)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRule, []string{"ip_addr:192.168.1.2", "tag:backend", "ip_addr:192.168.1.2"})
+
+			err := RunDatabaseFirewallRulesAppend(config)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("RemoveRule", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(existing, nil).Times(2)
+			tm.databases.EXPECT().UpdateFirewallRules(testDBCluster.ID, &godo.DatabaseUpdateFirewallRulesRequest{
+				Rules: []*godo.DatabaseFirewallRule{
+					{ClusterUUID: testDBCluster.ID, Type: "ip_addr", Value: "192.168.1.2"},
+				},
+			}).Return(nil)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRule, []string{"ip_addr:192.168.1.2"})
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRemoveRule, []string{"tag:backend"})
+
+			err := RunDatabaseFirewallRulesAppend(config)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("RemoveOnly", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(existing, nil).Times(2)
+			tm.databases.EXPECT().UpdateFirewallRules(testDBCluster.ID, &godo.DatabaseUpdateFirewallRulesRequest{
+				Rules: []*godo.DatabaseFirewallRule{},
+			}).Return(nil)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRemoveRule, []string{"tag:backend"})
+
+			err := RunDatabaseFirewallRulesAppend(config)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("RemoveUnmatched", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(existing, nil).Times(2)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRemoveRule, []string{"tag:frontend"})
+
+			err := RunDatabaseFirewallRulesAppend(config)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		defer func(a io.Writer) { color.Output = a }(color.Output)
+		var buf bytes.Buffer
+		color.Output = &buf
+
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(existing, nil)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRule, []string{"ip_addr:192.168.1.2"})
+			config.Doit.Set(config.NS, doctl.ArgDryRun, true)
+
+			err := RunDatabaseFirewallRulesAppend(config)
+			assert.NoError(t, err)
+			assert.Contains(t, buf.String(), "Dry run: no changes were made")
+		})
+	})
+
+	t.Run("AlreadyExists", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(existing, nil).Times(2)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRule, []string{"tag:backend"})
+
+			err := RunDatabaseFirewallRulesAppend(config)
+			assert.NoError(t, err)
+		})
+	})
+}
+
+func TestExtractFirewallRules(t *testing.T) {
+	rules, err := extractFirewallRules([]string{
+		"ip_addr:192.168.1.2",
+		"ip_addr:10.0.0.0/8",
+		"droplet:123456",
+		"k8s:d168d635-1c88-4616-b9b4-793b7c573927",
+		"tag:backend",
+		"app:ea3c2a2c-3fd1-41e6-a5d0-3b2a9d0a4a1f",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, rules, 6)
+
+	invalid := map[string]string{
+		"ip_addr:300.1.1.1": `Invalid ip_addr firewall rule value "300.1.1.1", must be an IP address or CIDR block`,
+		"droplet:web-1":     `Invalid droplet firewall rule value "web-1", must be a Droplet ID`,
+		"k8s:123":           `Invalid k8s firewall rule value "123", must be a UUID`,
+		"tag:has space":     `Invalid tag firewall rule value "has space", must be a tag name`,
+		"vpc:default":       `Unknown firewall rule type "vpc", must be one of: ip_addr, droplet, k8s, tag, app`,
+	}
+	for rule, msg := range invalid {
+		_, err := extractFirewallRules([]string{rule})
+		assert.EqualError(t, err, msg)
+	}
+}
+
+func TestDatabaseFirewallRulesUpdate(t *testing.T) {
+	t.Run("RulesFile", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			rulesFile := testTempFile(t, []byte(`- type: tag
+  value: backend
+- type: ip_addr
+  value: 10.0.0.0/8
+`))
+
+			tm.databases.EXPECT().UpdateFirewallRules(testDBCluster.ID, &godo.DatabaseUpdateFirewallRulesRequest{
+				Rules: []*godo.DatabaseFirewallRule{
+					{Type: "tag", Value: "backend"},
+					{Type: "ip_addr", Value: "10.0.0.0/8"},
+					{Type: "droplet", Value: "123456"},
+				},
+			}).Return(nil)
+			tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(do.DatabaseFirewallRules{}, nil)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRule, []string{"droplet:123456"})
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRulesFile, rulesFile)
+
+			err := RunDatabaseFirewallRulesUpdate(config)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("RemoveRule", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			existing := do.DatabaseFirewallRules{
+				{DatabaseFirewallRule: &godo.DatabaseFirewallRule{UUID: "a", ClusterUUID: testDBCluster.ID, Type: "tag", Value: "backend"}},
+				{DatabaseFirewallRule: &godo.DatabaseFirewallRule{UUID: "b", ClusterUUID: testDBCluster.ID, Type: "ip_addr", Value: "10.0.0.0/8"}},
+			}
+
+			gomock.InOrder(
+				tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(existing, nil),
+				tm.databases.EXPECT().UpdateFirewallRules(testDBCluster.ID, &godo.DatabaseUpdateFirewallRulesRequest{
+					Rules: []*godo.DatabaseFirewallRule{
+						{UUID: "a", ClusterUUID: testDBCluster.ID, Type: "tag", Value: "backend"},
+					},
+				}).Return(nil),
+				tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(existing[:1], nil),
+			)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRemoveRule, []string{"ip_addr:10.0.0.0/8"})
+
+			err := RunDatabaseFirewallRulesUpdate(config)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("RemoveRuleWithRules", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRule, []string{"tag:backend"})
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRemoveRule, []string{"ip_addr:10.0.0.0/8"})
+
+			err := RunDatabaseFirewallRulesUpdate(config)
+			assert.EqualError(t, err, "--remove-rule cannot be combined with --rule or --rules-file; use append to add and remove rules in one command")
+		})
+	})
+
+	t.Run("EmptyRulesFile", func(t *testing.T) {
+		for _, content := range []string{"", "[]\n"} {
+			withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+				config.Args = append(config.Args, testDBCluster.ID)
+				config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRulesFile, testTempFile(t, []byte(content)))
+
+				err := RunDatabaseFirewallRulesUpdate(config)
+				assert.EqualError(t, err, "No firewall rules to replace the existing rules with; to remove every firewall rule, use doctl databases firewalls clear")
+			})
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRule, []string{"droplet:123456"})
+			config.Doit.Set(config.NS, doctl.ArgDryRun, true)
+
+			err := RunDatabaseFirewallRulesUpdate(config)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("DuplicateAndPaddedRules", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			rulesFile := testTempFile(t, []byte(`- type: tag
+  value: " backend "
+`))
+
+			tm.databases.EXPECT().UpdateFirewallRules(testDBCluster.ID, &godo.DatabaseUpdateFirewallRulesRequest{
+				Rules: []*godo.DatabaseFirewallRule{
+					{Type: "tag", Value: "backend"},
+					{Type: "ip_addr", Value: "10.0.0.1"},
+				},
+			}).Return(nil)
+			tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(do.DatabaseFirewallRules{}, nil)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRulesFile, rulesFile)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRule, []string{" ip_addr: 10.0.0.1", "tag :backend", "ip_addr:10.0.0.1 "})
+
+			err := RunDatabaseFirewallRulesUpdate(config)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("InvalidRulesFile", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			rulesFile := testTempFile(t, []byte(`[
+  {"type": "tag", "value": "backend"},
+  {"type": "vpc", "value": "default"}
+]`))
+
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgDatabaseFirewallRulesFile, rulesFile)
+
+			err := RunDatabaseFirewallRulesUpdate(config)
+			assert.EqualError(t, err, rulesFile+`:3: Unknown firewall rule type "vpc", must be one of: ip_addr, droplet, k8s, tag, app`)
+		})
+	})
+}
+
+func TestDatabaseFirewallsClear(t *testing.T) {
+	t.Run("Force", func(t *testing.T) {
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			tm.databases.EXPECT().UpdateFirewallRules(testDBCluster.ID, &godo.DatabaseUpdateFirewallRulesRequest{
+				Rules: []*godo.DatabaseFirewallRule{},
+			}).Return(nil)
+			tm.databases.EXPECT().GetFirewallRules(testDBCluster.ID).Return(do.DatabaseFirewallRules{}, nil)
+
+			config.Args = append(config.Args, testDBCluster.ID)
+			config.Doit.Set(config.NS, doctl.ArgForce, true)
+
+			err := RunDatabaseFirewallsClear(config)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("Declined", func(t *testing.T) {
+		defer func(f func(string) (string, error)) { retrieveUserInput = f }(retrieveUserInput)
+		retrieveUserInput = func(string) (string, error) {
+			return "no", nil
+		}
+
+		withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
+			config.Args = append(config.Args, testDBCluster.ID)
+
+			err := RunDatabaseFirewallsClear(config)
+			assert.EqualError(t, err, "Operation aborted.")
+		})
+	})
+}
+
+func TestRemoveFirewallRules(t *testing.T) {
+	defer func(a io.Writer) { color.Output = a }(color.Output)
+	var buf bytes.Buffer
+	color.Output = &buf
+
+	rules := []*godo.DatabaseFirewallRule{
+		{Type: "tag", Value: "backend"},
+		{Type: "ip_addr", Value: "10.0.0.0/8"},
+	}
+
+	kept, removed := removeFirewallRules(rules, []*godo.DatabaseFirewallRule{
+		{Type: "ip_addr", Value: "10.0.0.0/8"},
+		{Type: "tag", Value: "frontend"},
+		{Type: "tag", Value: "frontend"},
+	})
+	assert.True(t, removed)
+	assert.Equal(t, []*godo.DatabaseFirewallRule{{Type: "tag", Value: "backend"}}, kept)
+	assert.Equal(t, "Warning: Firewall rule tag:frontend does not match any rule and was not removed\n", buf.String())
+}
@@ -4,9 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/digitalocean/doctl/do"
 	"github.com/digitalocean/godo"
 )
 
@@ -74,6 +78,101 @@ func (a Apps) JSON(w io.Writer) error {
 	return e.Encode(a)
 }
 
+// AppsWide displays apps with additional columns.
+// appSortKeys are the fields apps can be sorted by, mapped to a function
+// reporting whether app a sorts before app b.
+var appSortKeys = map[string]func(a, b *godo.App) bool{
+	"name": func(a, b *godo.App) bool {
+		return appName(a) < appName(b)
+	},
+	"created": func(a, b *godo.App) bool {
+		return a.CreatedAt.Before(b.CreatedAt)
+	},
+	"updated": func(a, b *godo.App) bool {
+		return a.UpdatedAt.Before(b.UpdatedAt)
+	},
+}
+
+// SortApps sorts apps in place by field, one of name, created, or updated.
+func SortApps(apps []*godo.App, field string, desc bool) error {
+	less, ok := appSortKeys[field]
+	if !ok {
+		return fmt.Errorf("invalid sort field %q, must be one of: name, created, updated", field)
+	}
+
+	sort.SliceStable(apps, func(i, j int) bool {
+		if desc {
+			return less(apps[j], apps[i])
+		}
+		return less(apps[i], apps[j])
+	})
+	return nil
+}
+
+func appName(app *godo.App) string {
+	if app.Spec == nil {
+		return ""
+	}
+	return app.Spec.Name
+}
+
+type AppsWide []*godo.App
+
+var _ Displayable = (*AppsWide)(nil)
+
+func (a AppsWide) Cols() []string {
+	return []string{
+		"ID",
+		"Spec.Name",
+		"DefaultIngress",
+		"LiveURL",
+		"Region",
+		"TierSlug",
+		"ActiveDeployment.ID",
+		"ActiveDeployment.Phase",
+		"InProgressDeployment.ID",
+		"Created",
+		"Updated",
+	}
+}
+
+func (a AppsWide) ColMap() map[string]string {
+	cols := Apps(a).ColMap()
+	cols["LiveURL"] = "Live URL"
+	cols["Region"] = "Region"
+	cols["TierSlug"] = "Tier"
+	cols["ActiveDeployment.Phase"] = "Active Deployment Phase"
+	return cols
+}
+
+func (a AppsWide) KV() []map[string]interface{} {
+	out := Apps(a).KV()
+
+	for i, app := range a {
+		var region string
+		if app.Region != nil {
+			region = app.Region.Slug
+		} else if app.Spec != nil {
+			region = app.Spec.Region
+		}
+
+		var phase godo.DeploymentPhase
+		if app.ActiveDeployment != nil {
+			phase = app.ActiveDeployment.Phase
+		}
+
+		out[i]["LiveURL"] = app.LiveURL
+		out[i]["Region"] = region
+		out[i]["TierSlug"] = app.TierSlug
+		out[i]["ActiveDeployment.Phase"] = phase
+	}
+	return out
+}
+
+func (a AppsWide) JSON(w io.Writer) error {
+	return Apps(a).JSON(w)
+}
+
 type Deployments []*godo.Deployment
 
 var _ Displayable = (*Deployments)(nil)
@@ -124,6 +223,56 @@ func (d Deployments) JSON(w io.Writer) error {
 	return e.Encode(d)
 }
 
+// AppEvent is a single entry in the timeline of an app's deployments.
+type AppEvent struct {
+	Time         time.Time `json:"time"`
+	DeploymentID string    `json:"deployment_id"`
+	Type         string    `json:"type"`
+	Detail       string    `json:"detail,omitempty"`
+}
+
+type AppEvents []AppEvent
+
+var _ Displayable = (*AppEvents)(nil)
+
+func (e AppEvents) Cols() []string {
+	return []string{
+		"Time",
+		"DeploymentID",
+		"Type",
+		"Detail",
+	}
+}
+
+func (e AppEvents) ColMap() map[string]string {
+	return map[string]string{
+		"Time":         "Time",
+		"DeploymentID": "Deployment ID",
+		"Type":         "Event",
+		"Detail":       "Detail",
+	}
+}
+
+func (e AppEvents) KV() []map[string]interface{} {
+	out := make([]map[string]interface{}, len(e))
+
+	for i, event := range e {
+		out[i] = map[string]interface{}{
+			"Time":         event.Time,
+			"DeploymentID": event.DeploymentID,
+			"Type":         event.Type,
+			"Detail":       event.Detail,
+		}
+	}
+	return out
+}
+
+func (e AppEvents) JSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
 type AppRegions []*godo.AppRegion
 
 var _ Displayable = (*AppRegions)(nil)
@@ -175,7 +324,12 @@ func (r AppRegions) JSON(w io.Writer) error {
 	return e.Encode(r)
 }
 
-type AppTiers []*godo.AppTier
+// AppTiers displays app tiers. MonthlyPrices maps tier slugs to the tier's
+// starting monthly price in cents.
+type AppTiers struct {
+	Tiers         []*godo.AppTier
+	MonthlyPrices map[string]int64
+}
 
 var _ Displayable = (*AppTiers)(nil)
 
@@ -185,6 +339,7 @@ func (t AppTiers) Cols() []string {
 		"Slug",
 		"EgressBandwidthBytes",
 		"BuildSeconds",
+		"MonthlyPrice",
 	}
 }
 
@@ -194,28 +349,152 @@ func (t AppTiers) ColMap() map[string]string {
 		"Slug":                 "Slug",
 		"EgressBandwidthBytes": "Egress Bandwidth",
 		"BuildSeconds":         "Build Seconds",
+		"MonthlyPrice":         "Monthly Price",
 	}
 }
 
 func (t AppTiers) KV() []map[string]interface{} {
-	out := make([]map[string]interface{}, len(t))
+	out := make([]map[string]interface{}, len(t.Tiers))
 
-	for i, tier := range t {
+	for i, tier := range t.Tiers {
 		egressBandwidth, _ := strconv.ParseUint(tier.EgressBandwidthBytes, 10, 64)
 		out[i] = map[string]interface{}{
 			"Name":                 tier.Name,
 			"Slug":                 tier.Slug,
 			"EgressBandwidthBytes": BytesToHumanReadibleUnitBinary(egressBandwidth),
 			"BuildSeconds":         tier.BuildSeconds,
+			"MonthlyPrice":         t.monthlyPrice(tier.Slug),
 		}
 	}
 	return out
 }
 
+func (t AppTiers) monthlyPrice(slug string) string {
+	cents, ok := t.MonthlyPrices[slug]
+	if !ok {
+		return ""
+	}
+	return FormatUSDCents(cents)
+}
+
 func (t AppTiers) JSON(w io.Writer) error {
+	type tier struct {
+		*godo.AppTier
+		MonthlyPrice *int64 `json:"monthly_price,omitempty"`
+	}
+	out := make([]tier, len(t.Tiers))
+	for i, appTier := range t.Tiers {
+		out[i] = tier{AppTier: appTier}
+		if cents, ok := t.MonthlyPrices[appTier.Slug]; ok {
+			out[i].MonthlyPrice = &cents
+		}
+	}
+
 	e := json.NewEncoder(w)
 	e.SetIndent("", "  ")
-	return e.Encode(t)
+	return e.Encode(out)
+}
+
+// AppTierComparison displays two app tiers side by side along with the
+// difference between their monthly prices.
+type AppTierComparison struct {
+	From, To      *godo.AppTier
+	MonthlyPrices map[string]int64
+}
+
+var _ Displayable = (*AppTierComparison)(nil)
+
+func (c AppTierComparison) Cols() []string {
+	return []string{
+		"Attribute",
+		"From",
+		"To",
+	}
+}
+
+func (c AppTierComparison) ColMap() map[string]string {
+	return map[string]string{
+		"Attribute": "Tier",
+		"From":      c.From.Slug,
+		"To":        c.To.Slug,
+	}
+}
+
+func (c AppTierComparison) KV() []map[string]interface{} {
+	tiers := AppTiers{
+		Tiers:         []*godo.AppTier{c.From, c.To},
+		MonthlyPrices: c.MonthlyPrices,
+	}
+	kv := tiers.KV()
+	cols := tiers.ColMap()
+
+	var out []map[string]interface{}
+	for _, col := range tiers.Cols() {
+		out = append(out, map[string]interface{}{
+			"Attribute": cols[col],
+			"From":      kv[0][col],
+			"To":        kv[1][col],
+		})
+	}
+
+	delta := ""
+	if from, ok := c.MonthlyPrices[c.From.Slug]; ok {
+		if to, ok := c.MonthlyPrices[c.To.Slug]; ok {
+			delta = FormatUSDCents(to - from)
+			if to > from {
+				delta = "+" + delta
+			}
+		}
+	}
+	out = append(out, map[string]interface{}{
+		"Attribute": "Price Delta",
+		"From":      "",
+		"To":        delta,
+	})
+
+	return out
+}
+
+func (c AppTierComparison) JSON(w io.Writer) error {
+	type tier struct {
+		*godo.AppTier
+		MonthlyPriceCents *int64 `json:"monthly_price_cents,omitempty"`
+	}
+	priced := func(t *godo.AppTier) tier {
+		out := tier{AppTier: t}
+		if cents, ok := c.MonthlyPrices[t.Slug]; ok {
+			out.MonthlyPriceCents = &cents
+		}
+		return out
+	}
+
+	out := struct {
+		From                   tier   `json:"from"`
+		To                     tier   `json:"to"`
+		MonthlyPriceDeltaCents *int64 `json:"monthly_price_delta_cents,omitempty"`
+	}{
+		From: priced(c.From),
+		To:   priced(c.To),
+	}
+	if out.From.MonthlyPriceCents != nil && out.To.MonthlyPriceCents != nil {
+		delta := *out.To.MonthlyPriceCents - *out.From.MonthlyPriceCents
+		out.MonthlyPriceDeltaCents = &delta
+	}
+
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(out)
+}
+
+// FormatUSDCents formats an amount of US cents as dollars, e.g. 1250 becomes
+// $12.50.
+func FormatUSDCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s$%d.%02d", sign, cents/100, cents%100)
 }
 
 type AppInstanceSizes []*godo.AppInstanceSize
@@ -285,6 +564,58 @@ func (is AppInstanceSizes) JSON(w io.Writer) error {
 	return e.Encode(is)
 }
 
+// AppInstanceSizeTotals displays app instance sizes followed by a row with
+// their combined price.
+type AppInstanceSizeTotals struct {
+	Sizes AppInstanceSizes
+}
+
+var _ Displayable = (*AppInstanceSizeTotals)(nil)
+
+func (t AppInstanceSizeTotals) Cols() []string {
+	return t.Sizes.Cols()
+}
+
+func (t AppInstanceSizeTotals) ColMap() map[string]string {
+	return t.Sizes.ColMap()
+}
+
+func (t AppInstanceSizeTotals) KV() []map[string]interface{} {
+	perMonth, perSecond := t.totals()
+	total := make(map[string]interface{})
+	for _, col := range t.Cols() {
+		total[col] = ""
+	}
+	total["Name"] = "Total"
+	total["USDPerMonth"] = perMonth
+	total["USDPerSecond"] = perSecond
+	return append(t.Sizes.KV(), total)
+}
+
+func (t AppInstanceSizeTotals) JSON(w io.Writer) error {
+	perMonth, perSecond := t.totals()
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(struct {
+		InstanceSizes AppInstanceSizes `json:"instance_sizes"`
+		USDPerMonth   string           `json:"usd_per_month"`
+		USDPerSecond  string           `json:"usd_per_second"`
+	}{t.Sizes, perMonth, perSecond})
+}
+
+// totals returns the combined monthly and per-second prices of the sizes.
+func (t AppInstanceSizeTotals) totals() (perMonth, perSecond string) {
+	var cents int64
+	var usdPerSecond float64
+	for _, size := range t.Sizes {
+		usd, _ := strconv.ParseFloat(size.USDPerMonth, 64)
+		cents += int64(math.Round(usd * 100))
+		usd, _ = strconv.ParseFloat(size.USDPerSecond, 64)
+		usdPerSecond += usd
+	}
+	return strconv.FormatFloat(float64(cents)/100, 'f', -1, 64), fmt.Sprintf("%.7f", usdPerSecond)
+}
+
 type AppProposeResponse struct {
 	Res *godo.AppProposeResponse
 }
@@ -369,3 +700,353 @@ func (r AppProposeResponse) JSON(w io.Writer) error {
 	e.SetIndent("", "  ")
 	return e.Encode(r.Res)
 }
+
+type AppProposeCost struct {
+	Res *godo.AppProposeResponse
+}
+
+var _ Displayable = (*AppProposeCost)(nil)
+
+func (r AppProposeCost) Cols() []string {
+	return []string{
+		"AppCost",
+		"AppTierUpgradeCost",
+		"AppTierDowngradeCost",
+	}
+}
+
+func (r AppProposeCost) ColMap() map[string]string {
+	return map[string]string{
+		"AppCost":              "$/month",
+		"AppTierUpgradeCost":   "$/month on higher tier",
+		"AppTierDowngradeCost": "$/month on lower tier",
+	}
+}
+
+func (r AppProposeCost) KV() []map[string]interface{} {
+	return AppProposeResponse(r).KV()
+}
+
+func (r AppProposeCost) JSON(w io.Writer) error {
+	e := json.NewEncoder(w)
+	e.SetIndent("", "  ")
+	return e.Encode(struct {
+		AppCost              float32 `json:"app_cost"`
+		AppTierUpgradeCost   float32 `json:"app_tier_upgrade_cost"`
+		AppTierDowngradeCost float32 `json:"app_tier_downgrade_cost"`
+	}{r.Res.AppCost, r.Res.AppTierUpgradeCost, r.Res.AppTierDowngradeCost})
+}
+
+type AppEnvs []*godo.AppVariableDefinition
+
+var _ Displayable = (*AppEnvs)(nil)
+
+func (e AppEnvs) Cols() []string {
+	return []string{
+		"Key",
+		"Value",
+		"Scope",
+		"Type",
+	}
+}
+
+func (e AppEnvs) ColMap() map[string]string {
+	return map[string]string{
+		"Key":   "Key",
+		"Value": "Value",
+		"Scope": "Scope",
+		"Type":  "Type",
+	}
+}
+
+func (e AppEnvs) KV() []map[string]interface{} {
+	out := make([]map[string]interface{}, len(e))
+
+	for i, env := range e {
+		out[i] = map[string]interface{}{
+			"Key":   env.Key,
+			"Value": env.Value,
+			"Scope": env.Scope,
+			"Type":  env.Type,
+		}
+	}
+	return out
+}
+
+func (e AppEnvs) JSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// AppComponentURL is a URL or hostname at which an app component can be
+// reached. Type is either "public" or "internal".
+type AppComponentURL struct {
+	Component string `json:"component"`
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+}
+
+type AppComponentURLs []AppComponentURL
+
+var _ Displayable = (*AppComponentURLs)(nil)
+
+func (u AppComponentURLs) Cols() []string {
+	return []string{
+		"Component",
+		"Type",
+		"URL",
+	}
+}
+
+func (u AppComponentURLs) ColMap() map[string]string {
+	return map[string]string{
+		"Component": "Component",
+		"Type":      "Type",
+		"URL":       "URL",
+	}
+}
+
+func (u AppComponentURLs) KV() []map[string]interface{} {
+	out := make([]map[string]interface{}, len(u))
+
+	for i, url := range u {
+		out[i] = map[string]interface{}{
+			"Component": url.Component,
+			"Type":      url.Type,
+			"URL":       url.URL,
+		}
+	}
+	return out
+}
+
+func (u AppComponentURLs) JSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(u)
+}
+
+type AppDomains []*godo.AppDomain
+
+var _ Displayable = (*AppDomains)(nil)
+
+func (d AppDomains) Cols() []string {
+	return []string{
+		"Domain",
+		"Type",
+		"Wildcard",
+		"Zone",
+		"Phase",
+	}
+}
+
+func (d AppDomains) ColMap() map[string]string {
+	return map[string]string{
+		"Domain":   "Domain",
+		"Type":     "Type",
+		"Wildcard": "Wildcard",
+		"Zone":     "Zone",
+		"Phase":    "Certificate Status",
+	}
+}
+
+func (d AppDomains) KV() []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(d))
+
+	for _, domain := range d {
+		if domain.Spec == nil {
+			continue
+		}
+		out = append(out, map[string]interface{}{
+			"Domain":   domain.Spec.Domain,
+			"Type":     domain.Spec.Type,
+			"Wildcard": domain.Spec.Wildcard,
+			"Zone":     domain.Spec.Zone,
+			"Phase":    domain.Phase,
+		})
+	}
+	return out
+}
+
+func (d AppDomains) JSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// AppBandwidthUsage is the bandwidth used by an app over a period.
+type AppBandwidthUsage struct {
+	AppID          string `json:"app_id"`
+	Name           string `json:"name"`
+	BandwidthBytes uint64 `json:"bandwidth_bytes"`
+}
+
+// GiB returns the bandwidth used in GiB, to two decimal places.
+func (u AppBandwidthUsage) GiB() string {
+	return fmt.Sprintf("%.2f", float64(u.BandwidthBytes)/(1<<30))
+}
+
+type AppBandwidthUsages []AppBandwidthUsage
+
+var _ Displayable = (*AppBandwidthUsages)(nil)
+
+func (u AppBandwidthUsages) Cols() []string {
+	return []string{
+		"AppID",
+		"Name",
+		"GiB",
+	}
+}
+
+func (u AppBandwidthUsages) ColMap() map[string]string {
+	return map[string]string{
+		"AppID": "App ID",
+		"Name":  "Name",
+		"GiB":   "GiB Used",
+	}
+}
+
+func (u AppBandwidthUsages) KV() []map[string]interface{} {
+	out := make([]map[string]interface{}, len(u))
+
+	for i, usage := range u {
+		out[i] = map[string]interface{}{
+			"AppID": usage.AppID,
+			"Name":  usage.Name,
+			"GiB":   usage.GiB(),
+		}
+	}
+	return out
+}
+
+func (u AppBandwidthUsages) JSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(u)
+}
+
+// AppComponentMetrics is the average and maximum CPU and memory usage of an
+// app component over a period, as percentages of its instance size.
+type AppComponentMetrics struct {
+	Component     string  `json:"component"`
+	CPUAverage    float64 `json:"cpu_average"`
+	CPUMax        float64 `json:"cpu_max"`
+	MemoryAverage float64 `json:"memory_average"`
+	MemoryMax     float64 `json:"memory_max"`
+}
+
+type AppMetrics []AppComponentMetrics
+
+var _ Displayable = (*AppMetrics)(nil)
+
+func (m AppMetrics) Cols() []string {
+	return []string{
+		"Component",
+		"CPUAverage",
+		"CPUMax",
+		"MemoryAverage",
+		"MemoryMax",
+	}
+}
+
+func (m AppMetrics) ColMap() map[string]string {
+	return map[string]string{
+		"Component":     "Component",
+		"CPUAverage":    "CPU Avg %",
+		"CPUMax":        "CPU Max %",
+		"MemoryAverage": "Memory Avg %",
+		"MemoryMax":     "Memory Max %",
+	}
+}
+
+func (m AppMetrics) KV() []map[string]interface{} {
+	out := make([]map[string]interface{}, len(m))
+
+	for i, c := range m {
+		out[i] = map[string]interface{}{
+			"Component":     c.Component,
+			"CPUAverage":    fmt.Sprintf("%.2f", c.CPUAverage),
+			"CPUMax":        fmt.Sprintf("%.2f", c.CPUMax),
+			"MemoryAverage": fmt.Sprintf("%.2f", c.MemoryAverage),
+			"MemoryMax":     fmt.Sprintf("%.2f", c.MemoryMax),
+		}
+	}
+	return out
+}
+
+func (m AppMetrics) JSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+type AppAlerts []*do.AppAlert
+
+var _ Displayable = (*AppAlerts)(nil)
+
+func (a AppAlerts) Cols() []string {
+	return []string{
+		"ID",
+		"Component",
+		"Rule",
+		"Trigger",
+		"Disabled",
+		"Emails",
+		"SlackWebhooks",
+		"Phase",
+	}
+}
+
+func (a AppAlerts) ColMap() map[string]string {
+	return map[string]string{
+		"ID":            "ID",
+		"Component":     "Component",
+		"Rule":          "Rule",
+		"Trigger":       "Trigger",
+		"Disabled":      "Disabled",
+		"Emails":        "Emails",
+		"SlackWebhooks": "Slack Webhooks",
+		"Phase":         "Phase",
+	}
+}
+
+func (a AppAlerts) KV() []map[string]interface{} {
+	out := make([]map[string]interface{}, len(a))
+
+	for i, alert := range a {
+		var rule, trigger string
+		var disabled bool
+		if alert.Spec != nil {
+			rule, disabled = alert.Spec.Rule, alert.Spec.Disabled
+			if alert.Spec.Operator != "" {
+				trigger = fmt.Sprintf("%s %g %s", alert.Spec.Operator, alert.Spec.Value, alert.Spec.Window)
+			}
+		}
+
+		webhooks := make([]string, len(alert.SlackWebhooks))
+		for j, w := range alert.SlackWebhooks {
+			webhooks[j] = w.URL
+			if w.Channel != "" {
+				webhooks[j] = w.Channel
+			}
+		}
+
+		out[i] = map[string]interface{}{
+			"ID":            alert.ID,
+			"Component":     alert.ComponentName,
+			"Rule":          rule,
+			"Trigger":       strings.TrimSpace(trigger),
+			"Disabled":      disabled,
+			"Emails":        strings.Join(alert.Emails, ","),
+			"SlackWebhooks": strings.Join(webhooks, ","),
+			"Phase":         alert.Phase,
+		}
+	}
+	return out
+}
+
+func (a AppAlerts) JSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a)
+}
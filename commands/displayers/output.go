@@ -21,6 +21,9 @@ import (
 	"reflect"
 	"strings"
 	"text/tabwriter"
+	"text/template"
+
+	"k8s.io/client-go/util/jsonpath"
 )
 
 // Displayable is a displable entity. These are used for printing results.
@@ -36,13 +39,26 @@ type Displayer struct {
 	OutputType string
 	ColumnList string
 	NoHeaders  bool
+	Template   string
+
+	// IgnoreNotFound suppresses the error returned when a jsonpath output
+	// expression matches nothing.
+	IgnoreNotFound bool
 
 	Item Displayable
 	Out  io.Writer
 }
 
-// Display ends up rendering the content in one of two formats (text|json)
+// Display ends up rendering the content in one of two formats (text|json),
+// unless a Go template or jsonpath expression is given to format it with.
 func (d *Displayer) Display() error {
+	if d.Template != "" {
+		return DisplayTemplate(d.Item, d.Out, d.Template)
+	}
+	if strings.HasPrefix(d.OutputType, "jsonpath=") {
+		return DisplayJSONPath(d.Item, d.Out, strings.TrimPrefix(d.OutputType, "jsonpath="), d.IgnoreNotFound)
+	}
+
 	switch d.OutputType {
 	case "json":
 		if containsOnlyNilSlice(d.Item) {
@@ -64,6 +80,42 @@ func (d *Displayer) Display() error {
 	}
 }
 
+// JSONArrayWriter writes a JSON array to Out one element at a time, with the
+// same indentation as the JSON output of a Displayable. Close must be called
+// once all elements have been written.
+type JSONArrayWriter struct {
+	Out io.Writer
+	n   int
+}
+
+// Write writes v as the next element of the array.
+func (a *JSONArrayWriter) Write(v interface{}) error {
+	b, err := json.MarshalIndent(v, "  ", "  ")
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n  "
+	if a.n == 0 {
+		sep = "[\n  "
+	}
+	a.n++
+
+	_, err = fmt.Fprintf(a.Out, "%s%s", sep, b)
+	return err
+}
+
+// Close ends the array. An empty array is written as "[]\n", like the JSON
+// output of an empty Displayable slice.
+func (a *JSONArrayWriter) Close() error {
+	end := "\n]\n"
+	if a.n == 0 {
+		end = "[]\n"
+	}
+	_, err := io.WriteString(a.Out, end)
+	return err
+}
+
 // DisplayText writes tabbed content to the passed in io.Writer
 // while potentially adding or removing headers.
 func DisplayText(item Displayable, out io.Writer, noHeaders bool, includeCols []string) error {
@@ -117,6 +169,101 @@ func DisplayText(item Displayable, out io.Writer, noHeaders bool, includeCols []
 	return w.Flush()
 }
 
+// DisplayTemplate executes the Go template tmpl once for each of the objects
+// underlying item, writing a newline after each.
+func DisplayTemplate(item Displayable, out io.Writer, tmpl string) error {
+	t, err := template.New("format").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("invalid template: %v", err)
+	}
+
+	for _, obj := range templateObjects(item) {
+		if err := t.Execute(out, obj); err != nil {
+			return err
+		}
+		fmt.Fprintln(out)
+	}
+	return nil
+}
+
+// DisplayJSONPath writes the result of evaluating the jsonpath expression expr
+// against the JSON representation of item. Unless ignoreNotFound is true, an
+// error is returned if expr matches nothing.
+func DisplayJSONPath(item Displayable, out io.Writer, expr string, ignoreNotFound bool) error {
+	j := jsonpath.New("output")
+	j.AllowMissingKeys(ignoreNotFound)
+	// Most results are lists, which the parser only indexes at the root
+	// without a leading dot, so accept {.[*].id} as well as {[*].id}.
+	if err := j.Parse(strings.ReplaceAll(expr, "{.[", "{[")); err != nil {
+		return fmt.Errorf("invalid jsonpath expression: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := item.JSON(&buf); err != nil {
+		return err
+	}
+	var data interface{}
+	if err := json.Unmarshal(buf.Bytes(), &data); err != nil {
+		return err
+	}
+
+	results, err := j.FindResults(data)
+	if err != nil {
+		return err
+	}
+
+	var found bool
+	for _, r := range results {
+		if len(r) > 0 {
+			found = true
+			break
+		}
+	}
+	if !found {
+		if ignoreNotFound {
+			return nil
+		}
+		return fmt.Errorf("jsonpath expression %s matched nothing", expr)
+	}
+
+	for _, r := range results {
+		if err := j.PrintResults(out, r); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintln(out)
+	return err
+}
+
+// templateObjects returns the objects underlying item. These are the elements
+// of item if it is a slice, or of its first slice field if it is a struct.
+// Otherwise, a struct's only field or item itself is returned.
+func templateObjects(item Displayable) []interface{} {
+	v := reflect.Indirect(reflect.ValueOf(item))
+
+	if v.Kind() == reflect.Struct {
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath == "" && v.Field(i).Kind() == reflect.Slice {
+				v = v.Field(i)
+				break
+			}
+		}
+	}
+
+	switch {
+	case v.Kind() == reflect.Slice:
+		objs := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			objs = append(objs, v.Index(i).Interface())
+		}
+		return objs
+	case v.Kind() == reflect.Struct && v.NumField() == 1 && v.Type().Field(0).PkgPath == "":
+		return []interface{}{v.Field(0).Interface()}
+	default:
+		return []interface{}{item}
+	}
+}
+
 func writeJSON(item interface{}, w io.Writer) error {
 	b, err := json.Marshal(item)
 	if err != nil {
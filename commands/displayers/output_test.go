@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/digitalocean/doctl/do"
+	"github.com/digitalocean/godo"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -46,3 +47,106 @@ func TestDisplayerDisplay(t *testing.T) {
 		})
 	}
 }
+
+func TestJSONArrayWriter(t *testing.T) {
+	t.Run("matches the JSON output of a Displayable", func(t *testing.T) {
+		apps := Apps{
+			{ID: "1", Spec: &godo.AppSpec{Name: "web"}},
+			{ID: "2", Spec: &godo.AppSpec{Name: "api"}},
+		}
+		for _, items := range []Apps{apps, {}} {
+			buffered := &bytes.Buffer{}
+			assert.NoError(t, items.JSON(buffered))
+
+			streamed := &bytes.Buffer{}
+			w := &JSONArrayWriter{Out: streamed}
+			for _, app := range items {
+				assert.NoError(t, w.Write(app))
+			}
+			assert.NoError(t, w.Close())
+
+			assert.Equal(t, buffered.String(), streamed.String())
+		}
+	})
+}
+
+func TestDisplayerDisplayTemplate(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     Displayable
+		template string
+		expected string
+	}{
+		{
+			name: "a struct wrapping a slice executes the template for each element",
+			item: &Volume{Volumes: []do.Volume{
+				{Volume: &godo.Volume{ID: "1", Name: "one"}},
+				{Volume: &godo.Volume{ID: "2", Name: "two"}},
+			}},
+			template: "{{.ID}} {{.Name}}",
+			expected: "1 one\n2 two\n",
+		},
+		{
+			name: "a slice executes the template for each element",
+			item: Apps{
+				{ID: "1", Spec: &godo.AppSpec{Name: "web"}},
+			},
+			template: "{{.ID}} {{.Spec.Name}}",
+			expected: "1 web\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+
+			displayer := Displayer{
+				OutputType: "text",
+				Template:   tt.template,
+				Item:       tt.item,
+				Out:        out,
+			}
+
+			err := displayer.Display()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, out.String())
+		})
+	}
+}
+
+func TestDisplayerDisplayJSONPath(t *testing.T) {
+	item := Apps{
+		{ID: "1", Spec: &godo.AppSpec{Name: "web"}},
+		{ID: "2", Spec: &godo.AppSpec{Name: "api"}},
+	}
+
+	t.Run("matches", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		displayer := Displayer{
+			OutputType: "jsonpath={.[*].spec.name}",
+			Item:       item,
+			Out:        out,
+		}
+
+		err := displayer.Display()
+		assert.NoError(t, err)
+		assert.Equal(t, "web api\n", out.String())
+	})
+
+	t.Run("matches nothing", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		displayer := Displayer{
+			OutputType: "jsonpath={.[*].missing}",
+			Item:       item,
+			Out:        out,
+		}
+
+		err := displayer.Display()
+		assert.Error(t, err)
+
+		displayer.IgnoreNotFound = true
+		err = displayer.Display()
+		assert.NoError(t, err)
+		assert.Empty(t, out.String())
+	})
+}
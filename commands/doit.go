@@ -19,10 +19,12 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/digitalocean/doctl"
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -73,13 +75,22 @@ func init() {
 	rootPFlagSet.StringVarP(&Token, doctl.ArgAccessToken, "t", "", "API V2 access token")
 	viper.BindPFlag(doctl.ArgAccessToken, rootPFlagSet.Lookup(doctl.ArgAccessToken))
 
-	rootPFlagSet.StringVarP(&Output, doctl.ArgOutput, "o", "text", "Desired output format [text|json]")
+	rootPFlagSet.StringVarP(&Output, doctl.ArgOutput, "o", "text", "Desired output format [text|json|jsonpath=<expression>]")
 	viper.BindPFlag("output", rootPFlagSet.Lookup(doctl.ArgOutput))
 
 	rootPFlagSet.StringVarP(&Context, doctl.ArgContext, "", "", "Specify a custom authentication context name")
 	rootPFlagSet.BoolVarP(&Trace, "trace", "", false, "Show a log of network activity while performing a command")
 	rootPFlagSet.BoolVarP(&Verbose, doctl.ArgVerbose, "v", false, "Enable verbose output")
 
+	rootPFlagSet.IntP(doctl.ArgMaxRetries, "", 3, "Maximum number of times to retry an API request that was rejected by rate limiting")
+	viper.BindPFlag(doctl.ArgMaxRetries, rootPFlagSet.Lookup(doctl.ArgMaxRetries))
+	rootPFlagSet.DurationP(doctl.ArgRequestTimeout, "", 0, "Maximum duration of each HTTP request made by doctl, e.g. 30s or 2m. 0 means no timeout")
+	viper.BindPFlag(doctl.ArgRequestTimeout, rootPFlagSet.Lookup(doctl.ArgRequestTimeout))
+	rootPFlagSet.BoolP(doctl.ArgQuiet, "", false, "Suppress notices and warnings. Errors are still displayed")
+	viper.BindPFlag(doctl.ArgQuiet, rootPFlagSet.Lookup(doctl.ArgQuiet))
+	rootPFlagSet.BoolP(doctl.ArgInsecureSkipVerify, "", false, "Skip TLS certificate verification, e.g. for an API endpoint with a self-signed certificate. This is insecure")
+	viper.BindPFlag(doctl.ArgInsecureSkipVerify, rootPFlagSet.Lookup(doctl.ArgInsecureSkipVerify))
+
 	addCommands()
 
 	cobra.OnInitialize(initConfig)
@@ -104,6 +115,49 @@ func initConfig() {
 	}
 }
 
+// applyContextDefaults sets any flag of cmd that was not passed on the
+// command line to the default configured for the active auth context under
+// context-defaults.<context>. Defaults for global flags are keyed by the
+// flag name and defaults for command flags by the command's namespace, e.g.
+//
+//	context-defaults:
+//	  prod:
+//	    output: json
+//	    apps:
+//	      list:
+//	        format: ID,Spec.Name
+func applyContextDefaults(cmd *cobra.Command) error {
+	context := Context
+	if context == "" {
+		context = viper.GetString(doctl.ArgContext)
+	}
+	prefix := "context-defaults." + context + "."
+
+	var err error
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || f.Name == doctl.ArgContext {
+			return
+		}
+
+		key := f.Name
+		if cmd.LocalFlags().Lookup(f.Name) != nil {
+			key = cmdNS(cmd) + "." + f.Name
+		}
+		if !viper.IsSet(prefix + key) {
+			return
+		}
+
+		value := viper.GetString(prefix + key)
+		if _, ok := viper.Get(prefix + key).([]interface{}); ok {
+			value = strings.Join(viper.GetStringSlice(prefix+key), ",")
+		}
+		if serr := cmd.Flags().Set(f.Name, value); serr != nil {
+			err = fmt.Errorf("invalid %s default for context %s: %v", key, context, serr)
+		}
+	})
+	return err
+}
+
 // in case we ever want to change this, or let folks configure it...
 func defaultConfigHome() string {
 	cfgDir, err := os.UserConfigDir()
@@ -232,6 +286,17 @@ func AddBoolFlag(cmd *Command, name, shorthand string, def bool, desc string, op
 	}
 }
 
+// AddDurationFlag adds a duration flag to a command.
+func AddDurationFlag(cmd *Command, name, shorthand string, def time.Duration, desc string, opts ...flagOpt) {
+	fn := flagName(cmd, name)
+	cmd.Flags().DurationP(name, shorthand, def, desc)
+	viper.BindPFlag(fn, cmd.Flags().Lookup(name))
+
+	for _, o := range opts {
+		o(cmd, name, fn)
+	}
+}
+
 // AddStringSliceFlag adds a string slice flag to a command.
 func AddStringSliceFlag(cmd *Command, name, shorthand string, def []string, desc string, opts ...flagOpt) {
 	fn := flagName(cmd, name)
@@ -243,6 +308,18 @@ func AddStringSliceFlag(cmd *Command, name, shorthand string, def []string, desc
 	}
 }
 
+// AddStringArrayFlag adds a string array flag to a command. Unlike a string
+// slice flag, its values are not split on commas.
+func AddStringArrayFlag(cmd *Command, name, shorthand string, def []string, desc string, opts ...flagOpt) {
+	fn := flagName(cmd, name)
+	cmd.Flags().StringArrayP(name, shorthand, def, desc)
+	viper.BindPFlag(fn, cmd.Flags().Lookup(name))
+
+	for _, o := range opts {
+		o(cmd, name, fn)
+	}
+}
+
 // AddStringMapStringFlag adds a map of strings by strings flag to a command.
 func AddStringMapStringFlag(cmd *Command, name, shorthand string, def map[string]string, desc string, opts ...flagOpt) {
 	fn := flagName(cmd, name)
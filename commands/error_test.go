@@ -17,23 +17,28 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
 	"regexp"
 	"testing"
 
+	"github.com/digitalocean/doctl"
 	"github.com/fatih/color"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 )
 
 func Test_checkErr(t *testing.T) {
-	defer func(a func()) { errAction = a }(errAction)
+	defer func(a func(int)) { errAction = a }(errAction)
 	defer func(a io.Writer) { color.Output = a }(color.Output)
 
 	var b bytes.Buffer
 	w := bufio.NewWriter(&b)
 	color.Output = w
 
-	errAction = func() {
+	var code int
+	errAction = func(c int) {
+		code = c
 	}
 
 	e := errors.New("an error")
@@ -43,4 +48,26 @@ func Test_checkErr(t *testing.T) {
 
 	re := regexp.MustCompile(`an error`)
 	assert.True(t, re.Match(b.Bytes()))
+	assert.Equal(t, 1, code)
+
+	checkErr(fmt.Errorf("wrapped: %w", &exitCodeErr{err: e, code: 3}))
+	assert.Equal(t, 3, code)
+}
+
+func TestQuiet(t *testing.T) {
+	defer func(a io.Writer) { color.Output = a }(color.Output)
+	defer viper.Set(doctl.ArgQuiet, false)
+
+	var b bytes.Buffer
+	color.Output = &b
+
+	notice("a notice")
+	warn("a warning")
+	assert.Equal(t, "Notice: a notice\nWarning: a warning\n", b.String())
+
+	b.Reset()
+	viper.Set(doctl.ArgQuiet, true)
+	notice("a notice")
+	warn("a warning")
+	assert.Empty(t, b.String())
 }
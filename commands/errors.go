@@ -15,6 +15,7 @@ package commands
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 
@@ -29,12 +30,39 @@ var (
 	colorWarn   = color.YellowString("Warning")
 	colorNotice = color.GreenString("Notice")
 
-	// errAction specifies what should happen when an error occurs
-	errAction = func() {
-		os.Exit(1)
+	// errAction specifies what should happen when an error occurs. code is
+	// the exit code doctl should exit with.
+	errAction = func(code int) {
+		os.Exit(code)
 	}
 )
 
+// exitCodeErr is an error that causes doctl to exit with a specific code
+// instead of 1, allowing scripts to tell failure reasons apart.
+type exitCodeErr struct {
+	err  error
+	code int
+}
+
+var _ error = &exitCodeErr{}
+
+func (e *exitCodeErr) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitCodeErr) Unwrap() error {
+	return e.err
+}
+
+// exitCode returns the code doctl exits with after failing with err.
+func exitCode(err error) int {
+	var e *exitCodeErr
+	if errors.As(err, &e) {
+		return e.code
+	}
+	return 1
+}
+
 func init() {
 	color.Output = ansicolor.NewAnsiColorWriter(os.Stderr)
 }
@@ -68,7 +96,7 @@ func checkErr(err error) {
 		fmt.Println(string(b))
 	}
 
-	errAction()
+	errAction(exitCode(err))
 }
 
 func ensureOneArg(c *CmdConfig) error {
@@ -82,13 +110,21 @@ func ensureOneArg(c *CmdConfig) error {
 	}
 }
 
+// warn prints a warning to stderr unless --quiet is set.
 func warn(msg string, args ...interface{}) {
+	if viper.GetBool(doctl.ArgQuiet) {
+		return
+	}
 	fmt.Fprintf(color.Output, "%s: %s\n", colorWarn, fmt.Sprintf(msg, args...))
 }
 func warnConfirm(msg string, args ...interface{}) {
 	fmt.Fprintf(color.Output, "%s: %s", colorWarn, fmt.Sprintf(msg, args...))
 }
 
+// notice prints a notice to stderr unless --quiet is set.
 func notice(msg string, args ...interface{}) {
+	if viper.GetBool(doctl.ArgQuiet) {
+		return
+	}
 	fmt.Fprintf(color.Output, "%s: %s\n", colorNotice, fmt.Sprintf(msg, args...))
 }
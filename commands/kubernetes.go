@@ -913,16 +913,20 @@ func (s *KubernetesCommandService) RunKubernetesClusterDelete(c *CmdConfig) erro
 
 	kube := c.Kubernetes()
 
-	for _, cluster := range c.Args {
-		clusterID, err := clusterIDize(c, cluster)
+	for _, idOrName := range c.Args {
+		clusterID, err := clusterIDize(c, idOrName)
 		if err != nil {
 			return err
 		}
 
-		if force || AskForConfirmDelete("Kubernetes cluster", 1) == nil {
-			// continue
-		} else {
-			return fmt.Errorf("Operation aborted")
+		if !force {
+			cluster, err := kube.Get(clusterID)
+			if err != nil {
+				return err
+			}
+			if AskForConfirmDeleteNamed("Kubernetes cluster", cluster.Name, clusterID) != nil {
+				return fmt.Errorf("Operation aborted")
+			}
 		}
 
 		var kubeconfig []byte
@@ -992,13 +996,16 @@ func (s *KubernetesCommandService) RunKubernetesClusterDeleteSelective(c *CmdCon
 		return err
 	}
 
-	if force || AskForConfirmDelete("Kubernetes cluster", 1) == nil {
-		// continue
-	} else {
-		return fmt.Errorf("Operation aborted")
+	kube := c.Kubernetes()
+
+	cluster, err := kube.Get(clusterID)
+	if err != nil {
+		return err
 	}
 
-	kube := c.Kubernetes()
+	if !force && AskForConfirmDeleteNamed("Kubernetes cluster", cluster.Name, clusterID) != nil {
+		return fmt.Errorf("Operation aborted")
+	}
 
 	var kubeconfig []byte
 	if update {
@@ -1010,11 +1017,6 @@ func (s *KubernetesCommandService) RunKubernetesClusterDeleteSelective(c *CmdCon
 		}
 	}
 
-	cluster, err := kube.Get(clusterID)
-	if err != nil {
-		return err
-	}
-
 	var volIDs, snapshotIDs, lbIDs []string
 	for _, v := range volumes {
 		volumeID, err := iDize(c, v, "volume", cluster.RegionSlug)
@@ -647,13 +647,23 @@ func TestKubernetesUpgrade(t *testing.T) {
 }
 
 func TestKubernetesDelete(t *testing.T) {
+	defer func(f func(string) (string, error)) { retrieveUserInput = f }(retrieveUserInput)
+	var prompt string
+	retrieveUserInput = func(message string) (string, error) {
+		prompt = message
+		return "no", nil
+	}
+
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
 		// should'nt call `DeleteNodePool` so we don't set any expectations
+		tm.kubernetes.EXPECT().Get(testCluster.ID).Return(&testCluster, nil)
+
 		config.Doit.Set(config.NS, doctl.ArgForce, "false")
 		config.Args = append(config.Args, testCluster.ID)
 
 		err := testK8sCmdService().RunKubernetesClusterDelete(config)
 		assert.Error(t, err, "should have been challenged before deletion")
+		assert.Equal(t, `delete Kubernetes cluster "antoine_s_cluster" (`+testCluster.ID+`)?`, prompt)
 	})
 	// by id
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
@@ -706,13 +716,23 @@ func TestKubernetesDelete(t *testing.T) {
 }
 
 func TestKubernetesDeleteSelective(t *testing.T) {
+	defer func(f func(string) (string, error)) { retrieveUserInput = f }(retrieveUserInput)
+	var prompt string
+	retrieveUserInput = func(message string) (string, error) {
+		prompt = message
+		return "no", nil
+	}
+
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
 		// should'nt call `DeleteNodePool` so we don't set any expectations
+		tm.kubernetes.EXPECT().Get(testCluster.ID).Return(&testCluster, nil)
+
 		config.Doit.Set(config.NS, doctl.ArgForce, "false")
 		config.Args = append(config.Args, testCluster.ID)
 
 		err := testK8sCmdService().RunKubernetesClusterDeleteSelective(config)
 		assert.Error(t, err, "should have been challenged before deletion")
+		assert.Equal(t, `delete Kubernetes cluster "antoine_s_cluster" (`+testCluster.ID+`)?`, prompt)
 	})
 	// by id
 	withTestClient(t, func(config *CmdConfig, tm *tcMocks) {
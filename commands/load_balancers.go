@@ -69,7 +69,7 @@ With the load-balancer command, you can list, create, or delete load balancers,
 		aliasOpt("g"), displayerType(&displayers.LoadBalancer{}))
 
 	cmdRecordCreate := CmdBuilder(cmd, RunLoadBalancerCreate, "create",
-		"Create a new load balancer", "Use this command to create a new load balancer on your account. Valid forwarding rules are:"+forwardingDetail, Writer, aliasOpt("c"))
+		"Create a new load balancer", "Use this command to create a new load balancer on your account. Valid forwarding rules are:"+forwardingDetail, Writer, aliasOpt("c"), displayerType(&displayers.LoadBalancer{}))
 	AddStringFlag(cmdRecordCreate, doctl.ArgLoadBalancerName, "", "",
 		"The load balancer's name", requiredOpt())
 	AddStringFlag(cmdRecordCreate, doctl.ArgRegionSlug, "", "",
@@ -96,7 +96,7 @@ With the load-balancer command, you can list, create, or delete load balancers,
 		forwardingRulesTxt)
 
 	cmdRecordUpdate := CmdBuilder(cmd, RunLoadBalancerUpdate, "update <id>",
-		"Update a load balancer's configuration", `Use this command to update the configuration of a specified load balancer.`, Writer, aliasOpt("u"))
+		"Update a load balancer's configuration", `Use this command to update the configuration of a specified load balancer.`, Writer, aliasOpt("u"), displayerType(&displayers.LoadBalancer{}))
 	AddStringFlag(cmdRecordUpdate, doctl.ArgLoadBalancerName, "", "",
 		"The load balancer's name", requiredOpt())
 	AddStringFlag(cmdRecordUpdate, doctl.ArgRegionSlug, "", "",
@@ -232,7 +232,15 @@ func RunLoadBalancerDelete(c *CmdConfig) error {
 		return err
 	}
 
-	if force || AskForConfirmDelete("load balancer", 1) == nil {
+	if !force {
+		lb, err := c.LoadBalancers().Get(lbID)
+		if err != nil {
+			return err
+		}
+		force = AskForConfirmDeleteNamed("load balancer", lb.Name, lbID) == nil
+	}
+
+	if force {
 		lbs := c.LoadBalancers()
 		if err := lbs.Delete(lbID); err != nil {
 			return err
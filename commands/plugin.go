@@ -38,7 +38,7 @@ func Plugin() *Command {
 	}
 
 	CmdBuilder(cmd, RunPluginList, "list", "List plugins", "List plugins", Writer,
-		aliasOpt("ls"))
+		aliasOpt("ls"), displayerType(&displayers.Plugin{}))
 
 	CmdBuilder(cmd, RunPluginRun, "run", "Run a plugin", "Run a plugin", Writer)
 
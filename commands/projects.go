@@ -124,7 +124,7 @@ A valid URN has the format: ` + "`" + `do:resource_type:resource_id` + "`" + `.
 		"assign <project-id> --resource=<urn> [--resource=<urn> ...]",
 		"Assign one or more resources to a project",
 		"Assign one or more resources to a project by specifying the resource's uniform resource name (\"URN\")."+urnDesc,
-		Writer, aliasOpt("a"))
+		Writer, aliasOpt("a"), displayerType(&displayers.ProjectResource{}))
 	AddStringSliceFlag(cmdProjectResourcesAssign, doctl.ArgProjectResource, "",
 		[]string{}, "URNs specifying resources to assign to the project")
 
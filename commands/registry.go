@@ -267,7 +267,7 @@ func RegistryOptions() *Command {
 	}
 
 	tiersDesc := "List available container registry subscription tiers"
-	CmdBuilder(cmd, RunRegistryOptionsTiers, "subscription-tiers", tiersDesc, tiersDesc, Writer, aliasOpt("tiers"))
+	CmdBuilder(cmd, RunRegistryOptionsTiers, "subscription-tiers", tiersDesc, tiersDesc, Writer, aliasOpt("tiers"), displayerType(&displayers.RegistrySubscriptionTiers{}))
 
 	return cmd
 }
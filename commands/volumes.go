@@ -215,8 +215,16 @@ func RunVolumeDelete(c *CmdConfig) error {
 		return err
 	}
 
-	if force || AskForConfirmDelete("volume", 1) == nil {
-		id := c.Args[0]
+	id := c.Args[0]
+	if !force {
+		volume, err := c.Volumes().Get(id)
+		if err != nil {
+			return err
+		}
+		force = AskForConfirmDeleteNamed("volume", volume.Name, id) == nil
+	}
+
+	if force {
 		return c.Volumes().DeleteVolume(id)
 	}
 	return fmt.Errorf("Operation aborted.")
@@ -52,7 +52,7 @@ With the vpcs command, you can list, create, or delete VPCs, and manage their co
 		aliasOpt("g"), displayerType(&displayers.VPC{}))
 
 	cmdRecordCreate := CmdBuilder(cmd, RunVPCCreate, "create",
-		"Create a new VPC", "Use this command to create a new VPC on your account.", Writer, aliasOpt("c"))
+		"Create a new VPC", "Use this command to create a new VPC on your account.", Writer, aliasOpt("c"), displayerType(&displayers.VPC{}))
 	AddStringFlag(cmdRecordCreate, doctl.ArgVPCName, "", "",
 		"The VPC's name", requiredOpt())
 	AddStringFlag(cmdRecordCreate, doctl.ArgVPCDescription, "", "", "The VPC's name")
@@ -61,7 +61,7 @@ With the vpcs command, you can list, create, or delete VPCs, and manage their co
 	AddStringFlag(cmdRecordCreate, doctl.ArgRegionSlug, "", "", "The VPC's region slug, e.g.: `nyc1`", requiredOpt())
 
 	cmdRecordUpdate := CmdBuilder(cmd, RunVPCUpdate, "update <id>",
-		"Update a VPC's configuration", `Use this command to update the configuration of a specified VPC.`, Writer, aliasOpt("u"))
+		"Update a VPC's configuration", `Use this command to update the configuration of a specified VPC.`, Writer, aliasOpt("u"), displayerType(&displayers.VPC{}))
 	AddStringFlag(cmdRecordUpdate, doctl.ArgVPCName, "", "",
 		"The VPC's name")
 	AddStringFlag(cmdRecordUpdate, doctl.ArgVPCDescription, "", "",
@@ -207,7 +207,15 @@ func RunVPCDelete(c *CmdConfig) error {
 		return err
 	}
 
-	if force || AskForConfirmDelete("VPC", 1) == nil {
+	if !force {
+		vpc, err := c.VPCs().Get(vpcUUID)
+		if err != nil {
+			return err
+		}
+		force = AskForConfirmDeleteNamed("VPC", vpc.Name, vpcUUID) == nil
+	}
+
+	if force {
 		vpcs := c.VPCs()
 		if err := vpcs.Delete(vpcUUID); err != nil {
 			return err
@@ -0,0 +1,163 @@
+/*
+Copyright 2018 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/digitalocean/doctl"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var (
+	// spinnerFrames are the frames of the spinner shown while waiting.
+	spinnerFrames = []string{"|", "/", "-", "\\"}
+	// spinnerInterval is the time between spinner frames.
+	spinnerInterval = 100 * time.Millisecond
+
+	// stderrIsTerminal reports whether stderr is a terminal. In test, you can
+	// replace this to force either kind of progress output.
+	stderrIsTerminal = func() bool {
+		return terminal.IsTerminal(int(os.Stderr.Fd()))
+	}
+)
+
+// waitProgress reports progress while polling a long running operation. On a
+// terminal it shows a spinner with the current status, such as a deployment's
+// phase, that is redrawn in place. Lines after the first line of the status
+// are drawn below the spinner. Otherwise it prints a dot per poll so that
+// logs aren't filled with redraws. With --quiet nothing is printed.
+type waitProgress struct {
+	out     io.Writer
+	spinner bool
+	quiet   bool
+
+	dots bool
+
+	mu      sync.Mutex
+	status  string
+	frame   int
+	lines   int
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// newWaitProgress returns a waitProgress that writes to stderr.
+func newWaitProgress() *waitProgress {
+	return &waitProgress{
+		out:     os.Stderr,
+		spinner: stderrIsTerminal(),
+		quiet:   viper.GetBool(doctl.ArgQuiet),
+	}
+}
+
+// tick records that the operation is being polled again.
+func (p *waitProgress) tick() {
+	if p.quiet || p.spinner {
+		return
+	}
+	fmt.Fprint(p.out, ".")
+	p.dots = true
+}
+
+// update sets the status shown next to the spinner, starting the spinner if
+// it isn't running yet.
+func (p *waitProgress) update(status string) {
+	if p.quiet || !p.spinner {
+		return
+	}
+
+	p.mu.Lock()
+	p.status = status
+	p.draw()
+	p.mu.Unlock()
+
+	if p.stop == nil {
+		p.stop = make(chan struct{})
+		p.stopped = make(chan struct{})
+		go p.spin()
+	}
+}
+
+func (p *waitProgress) spin() {
+	defer close(p.stopped)
+
+	ticker := time.NewTicker(spinnerInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			p.frame++
+			p.draw()
+			p.mu.Unlock()
+		}
+	}
+}
+
+// draw redraws the spinner line and any lines below it. p.mu must be held.
+func (p *waitProgress) draw() {
+	p.rewind()
+	lines := strings.Split(p.status, "\n")
+	fmt.Fprintf(p.out, "\r\033[K%s %s...", spinnerFrames[p.frame%len(spinnerFrames)], lines[0])
+	for _, line := range lines[1:] {
+		fmt.Fprintf(p.out, "\n\r\033[K%s", line)
+	}
+	p.lines = len(lines)
+}
+
+// rewind moves the cursor back up to the spinner line.
+func (p *waitProgress) rewind() {
+	if p.lines > 1 {
+		fmt.Fprintf(p.out, "\033[%dA", p.lines-1)
+	}
+}
+
+// done stops the spinner and clears its line, or ends the line of dots.
+func (p *waitProgress) done() {
+	if p.stop != nil {
+		close(p.stop)
+		<-p.stopped
+		p.stop = nil
+		if p.lines > 1 {
+			p.rewind()
+			fmt.Fprint(p.out, "\r\033[J")
+		} else {
+			fmt.Fprint(p.out, "\r\033[K")
+		}
+		p.lines = 0
+	}
+	if p.dots {
+		fmt.Fprintln(p.out)
+		p.dots = false
+	}
+}
+
+// phaseStatus formats a phase such as PENDING_BUILD as a status, e.g.
+// "Pending build".
+func phaseStatus(phase string) string {
+	s := strings.ToLower(strings.ReplaceAll(phase, "_", " "))
+	if s == "" {
+		return "Waiting"
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2018 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitProgress(t *testing.T) {
+	t.Run("dots", func(t *testing.T) {
+		var buf bytes.Buffer
+		p := &waitProgress{out: &buf}
+
+		p.update("Building")
+		p.tick()
+		p.tick()
+		p.done()
+		assert.Equal(t, "..\n", buf.String())
+	})
+
+	t.Run("no polls", func(t *testing.T) {
+		var buf bytes.Buffer
+		p := &waitProgress{out: &buf}
+
+		p.done()
+		assert.Empty(t, buf.String())
+	})
+
+	t.Run("spinner", func(t *testing.T) {
+		var buf bytes.Buffer
+		p := &waitProgress{out: &buf, spinner: true}
+
+		p.tick()
+		p.update("Building")
+		p.update("Deploying")
+		p.done()
+
+		out := buf.String()
+		assert.True(t, strings.HasPrefix(out, "\r\033[K| Building..."), "unexpected output %q", out)
+		assert.Contains(t, out, "Deploying...")
+		assert.True(t, strings.HasSuffix(out, "\r\033[K"), "unexpected output %q", out)
+		assert.NotContains(t, out, ".\n")
+	})
+
+	t.Run("spinner with lines", func(t *testing.T) {
+		defer func(d time.Duration) { spinnerInterval = d }(spinnerInterval)
+		spinnerInterval = time.Hour
+
+		var buf bytes.Buffer
+		p := &waitProgress{out: &buf, spinner: true}
+
+		p.update("Deploying\n  web")
+		p.update("Deploying\n  web\n  worker")
+		p.done()
+
+		assert.Equal(t, "\r\033[K| Deploying...\n\r\033[K  web"+
+			"\033[1A\r\033[K| Deploying...\n\r\033[K  web\n\r\033[K  worker"+
+			"\033[2A\r\033[J", buf.String())
+	})
+
+	t.Run("quiet", func(t *testing.T) {
+		for _, spinner := range []bool{false, true} {
+			var buf bytes.Buffer
+			p := &waitProgress{out: &buf, spinner: spinner, quiet: true}
+
+			p.tick()
+			p.update("Building")
+			p.done()
+			assert.Empty(t, buf.String())
+		}
+	})
+}
+
+func TestPhaseStatus(t *testing.T) {
+	assert.Equal(t, "Pending build", phaseStatus("PENDING_BUILD"))
+	assert.Equal(t, "Deploying", phaseStatus("DEPLOYING"))
+	assert.Equal(t, "Waiting", phaseStatus(""))
+}
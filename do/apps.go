@@ -15,6 +15,11 @@ package do
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/digitalocean/godo"
 )
@@ -31,6 +36,10 @@ type AppsService interface {
 	CreateDeployment(appID string, forceRebuild bool) (*godo.Deployment, error)
 	GetDeployment(appID, deploymentID string) (*godo.Deployment, error)
 	ListDeployments(appID string) ([]*godo.Deployment, error)
+	ListDeploymentsPage(appID string, page, perPage int) ([]*godo.Deployment, error)
+	ForEachDeployment(appID string, fn func(*godo.Deployment) error) error
+	CancelDeployment(appID, deploymentID string) (*godo.Deployment, error)
+	Restart(appID string, components []string) (*godo.Deployment, error)
 
 	GetLogs(appID, deploymentID, component string, logType godo.AppLogType, follow bool) (*godo.AppLogs, error)
 
@@ -41,6 +50,73 @@ type AppsService interface {
 
 	ListInstanceSizes() ([]*godo.AppInstanceSize, error)
 	GetInstanceSize(slug string) (*godo.AppInstanceSize, error)
+
+	ListBandwidthUsage(appIDs []string, date time.Time) ([]*AppBandwidthUsage, error)
+	GetMetric(appID, metric string, start, end time.Time) ([]*AppMetricSample, error)
+
+	ListAlerts(appID string) ([]*AppAlert, error)
+	UpdateAlertDestinations(appID, alertID string, destinations *AppAlertDestinations) (*AppAlert, error)
+
+	GetExec(appID, component string) (*AppExec, error)
+}
+
+// AppBandwidthUsage is the bandwidth used by an app on a day.
+type AppBandwidthUsage struct {
+	AppID          string `json:"app_id"`
+	BandwidthBytes uint64 `json:"bandwidth_bytes,string"`
+}
+
+// AppAlert is an alert on an app, or on one of its components if
+// ComponentName is set, and the destinations it is sent to.
+type AppAlert struct {
+	ID            string                  `json:"id"`
+	Spec          *AppAlertSpec           `json:"spec"`
+	ComponentName string                  `json:"component_name,omitempty"`
+	Emails        []string                `json:"emails"`
+	SlackWebhooks []*AppAlertSlackWebhook `json:"slack_webhooks"`
+	Phase         string                  `json:"phase"`
+}
+
+// AppAlertSpec is the rule that triggers an app alert.
+type AppAlertSpec struct {
+	Rule     string  `json:"rule"`
+	Disabled bool    `json:"disabled"`
+	Operator string  `json:"operator,omitempty"`
+	Value    float64 `json:"value,omitempty"`
+	Window   string  `json:"window,omitempty"`
+}
+
+// AppAlertSlackWebhook is a Slack incoming webhook an app alert is sent to.
+type AppAlertSlackWebhook struct {
+	URL     string `json:"url"`
+	Channel string `json:"channel,omitempty"`
+}
+
+// AppAlertDestinations are the email addresses and Slack webhooks an app
+// alert is sent to.
+type AppAlertDestinations struct {
+	Emails        []string                `json:"emails"`
+	SlackWebhooks []*AppAlertSlackWebhook `json:"slack_webhooks"`
+}
+
+// AppExec is the websocket URL of a console session on an instance of an app
+// component.
+type AppExec struct {
+	URL string `json:"url"`
+}
+
+// App metrics that can be fetched with GetMetric. Both are percentages of the
+// instance size's allowance.
+const (
+	AppMetricCPUPercentage    = "cpu_percentage"
+	AppMetricMemoryPercentage = "memory_percentage"
+)
+
+// AppMetricSample is a sample of a metric of an instance of an app component.
+type AppMetricSample struct {
+	Component string
+	Time      time.Time
+	Value     float64
 }
 
 type appsService struct {
@@ -171,6 +247,75 @@ func (s *appsService) ListDeployments(appID string) ([]*godo.Deployment, error)
 	return list, nil
 }
 
+func (s *appsService) ListDeploymentsPage(appID string, page, perPage int) ([]*godo.Deployment, error) {
+	opt := &godo.ListOptions{Page: page, PerPage: perPage}
+	list, _, err := s.client.Apps.ListDeployments(s.ctx, appID, opt)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// ForEachDeployment calls fn with each of the app's deployments, fetching one
+// page at a time so that the full history is never held in memory. It stops
+// at the first error returned by fn and returns it.
+func (s *appsService) ForEachDeployment(appID string, fn func(*godo.Deployment) error) error {
+	opt := &godo.ListOptions{Page: 1, PerPage: perPage}
+	for {
+		list, resp, err := s.client.Apps.ListDeployments(s.ctx, appID, opt)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range list {
+			if err := fn(d); err != nil {
+				return err
+			}
+		}
+
+		if len(list) == 0 || resp.Links == nil || resp.Links.IsLastPage() {
+			return nil
+		}
+		opt.Page++
+	}
+}
+
+type appDeploymentRoot struct {
+	Deployment *godo.Deployment `json:"deployment"`
+}
+
+func (s *appsService) CancelDeployment(appID, deploymentID string) (*godo.Deployment, error) {
+	path := fmt.Sprintf("/v2/apps/%s/deployments/%s/cancel", appID, deploymentID)
+	req, err := s.client.NewRequest(s.ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	root := new(appDeploymentRoot)
+	if _, err := s.client.Do(s.ctx, req, root); err != nil {
+		return nil, err
+	}
+	return root.Deployment, nil
+}
+
+type appRestartRequest struct {
+	Components []string `json:"components,omitempty"`
+}
+
+func (s *appsService) Restart(appID string, components []string) (*godo.Deployment, error) {
+	path := fmt.Sprintf("/v2/apps/%s/restart", appID)
+	req, err := s.client.NewRequest(s.ctx, http.MethodPost, path, &appRestartRequest{Components: components})
+	if err != nil {
+		return nil, err
+	}
+
+	root := new(appDeploymentRoot)
+	if _, err := s.client.Do(s.ctx, req, root); err != nil {
+		return nil, err
+	}
+	return root.Deployment, nil
+}
+
 func (s *appsService) GetLogs(appID, deploymentID, component string, logType godo.AppLogType, follow bool) (*godo.AppLogs, error) {
 	logs, _, err := s.client.Apps.GetLogs(s.ctx, appID, deploymentID, component, logType, follow)
 	if err != nil {
@@ -218,3 +363,136 @@ func (s *appsService) GetInstanceSize(slug string) (*godo.AppInstanceSize, error
 	}
 	return instanceSize, nil
 }
+
+type appBandwidthUsageRequest struct {
+	AppIDs []string  `json:"app_ids"`
+	Date   time.Time `json:"date"`
+}
+
+type appBandwidthUsageRoot struct {
+	Usage []*AppBandwidthUsage `json:"app_bandwidth_usage"`
+}
+
+// ListBandwidthUsage returns the bandwidth used by each of the apps on the
+// UTC day that contains date.
+func (s *appsService) ListBandwidthUsage(appIDs []string, date time.Time) ([]*AppBandwidthUsage, error) {
+	req, err := s.client.NewRequest(s.ctx, http.MethodPost, "/v2/apps/metrics/bandwidth_daily", &appBandwidthUsageRequest{
+		AppIDs: appIDs,
+		Date:   date.UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	root := new(appBandwidthUsageRoot)
+	if _, err := s.client.Do(s.ctx, req, root); err != nil {
+		return nil, err
+	}
+	return root.Usage, nil
+}
+
+type appMetricRoot struct {
+	Data struct {
+		Result []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]interface{}  `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// GetMetric returns the samples of an app metric between start and end, for
+// every instance of every component of the app.
+func (s *appsService) GetMetric(appID, metric string, start, end time.Time) ([]*AppMetricSample, error) {
+	q := url.Values{
+		"app_id": {appID},
+		"start":  {strconv.FormatInt(start.Unix(), 10)},
+		"end":    {strconv.FormatInt(end.Unix(), 10)},
+	}
+	path := fmt.Sprintf("/v2/monitoring/metrics/apps/%s?%s", metric, q.Encode())
+	req, err := s.client.NewRequest(s.ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	root := new(appMetricRoot)
+	if _, err := s.client.Do(s.ctx, req, root); err != nil {
+		return nil, err
+	}
+
+	var samples []*AppMetricSample
+	for _, series := range root.Data.Result {
+		for _, v := range series.Values {
+			ts, ok := v[0].(float64)
+			if !ok {
+				return nil, fmt.Errorf("unexpected %s sample time %v", metric, v[0])
+			}
+			value, ok := v[1].(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected %s sample value %v", metric, v[1])
+			}
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("unexpected %s sample value %q", metric, value)
+			}
+			samples = append(samples, &AppMetricSample{
+				Component: series.Metric["app_component"],
+				Time:      time.Unix(int64(ts), 0),
+				Value:     f,
+			})
+		}
+	}
+	return samples, nil
+}
+
+type appAlertsRoot struct {
+	Alerts []*AppAlert `json:"alerts"`
+}
+
+type appAlertRoot struct {
+	Alert *AppAlert `json:"alert"`
+}
+
+func (s *appsService) ListAlerts(appID string) ([]*AppAlert, error) {
+	path := fmt.Sprintf("/v2/apps/%s/alerts", appID)
+	req, err := s.client.NewRequest(s.ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	root := new(appAlertsRoot)
+	if _, err := s.client.Do(s.ctx, req, root); err != nil {
+		return nil, err
+	}
+	return root.Alerts, nil
+}
+
+// UpdateAlertDestinations replaces the destinations of an app alert.
+func (s *appsService) UpdateAlertDestinations(appID, alertID string, destinations *AppAlertDestinations) (*AppAlert, error) {
+	path := fmt.Sprintf("/v2/apps/%s/alerts/%s/destinations", appID, alertID)
+	req, err := s.client.NewRequest(s.ctx, http.MethodPost, path, destinations)
+	if err != nil {
+		return nil, err
+	}
+
+	root := new(appAlertRoot)
+	if _, err := s.client.Do(s.ctx, req, root); err != nil {
+		return nil, err
+	}
+	return root.Alert, nil
+}
+
+// GetExec starts a console session on an instance of an app component and
+// returns the websocket URL to connect to it.
+func (s *appsService) GetExec(appID, component string) (*AppExec, error) {
+	path := fmt.Sprintf("/v2/apps/%s/components/%s/exec", appID, component)
+	req, err := s.client.NewRequest(s.ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	exec := new(AppExec)
+	if _, err := s.client.Do(s.ctx, req, exec); err != nil {
+		return nil, err
+	}
+	return exec, nil
+}
@@ -7,8 +7,10 @@ package mocks
 import (
 	reflect "reflect"
 
+	do "github.com/digitalocean/doctl/do"
 	godo "github.com/digitalocean/godo"
 	gomock "github.com/golang/mock/gomock"
+	time "time"
 )
 
 // MockAppsService is a mock of AppsService interface.
@@ -168,6 +170,65 @@ func (mr *MockAppsServiceMockRecorder) ListDeployments(appID interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeployments", reflect.TypeOf((*MockAppsService)(nil).ListDeployments), appID)
 }
 
+// ListDeploymentsPage mocks base method.
+func (m *MockAppsService) ListDeploymentsPage(appID string, page, perPage int) ([]*godo.Deployment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeploymentsPage", appID, page, perPage)
+	ret0, _ := ret[0].([]*godo.Deployment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeploymentsPage indicates an expected call of ListDeploymentsPage.
+func (mr *MockAppsServiceMockRecorder) ListDeploymentsPage(appID, page, perPage interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeploymentsPage", reflect.TypeOf((*MockAppsService)(nil).ListDeploymentsPage), appID, page, perPage)
+}
+
+// ForEachDeployment mocks base method.
+func (m *MockAppsService) ForEachDeployment(appID string, fn func(*godo.Deployment) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachDeployment", appID, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachDeployment indicates an expected call of ForEachDeployment.
+func (mr *MockAppsServiceMockRecorder) ForEachDeployment(appID, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachDeployment", reflect.TypeOf((*MockAppsService)(nil).ForEachDeployment), appID, fn)
+}
+
+// CancelDeployment mocks base method.
+func (m *MockAppsService) CancelDeployment(appID, deploymentID string) (*godo.Deployment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CancelDeployment", appID, deploymentID)
+	ret0, _ := ret[0].(*godo.Deployment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CancelDeployment indicates an expected call of CancelDeployment.
+func (mr *MockAppsServiceMockRecorder) CancelDeployment(appID, deploymentID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CancelDeployment", reflect.TypeOf((*MockAppsService)(nil).CancelDeployment), appID, deploymentID)
+}
+
+// Restart mocks base method.
+func (m *MockAppsService) Restart(appID string, components []string) (*godo.Deployment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restart", appID, components)
+	ret0, _ := ret[0].(*godo.Deployment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restart indicates an expected call of Restart.
+func (mr *MockAppsServiceMockRecorder) Restart(appID, components interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restart", reflect.TypeOf((*MockAppsService)(nil).Restart), appID, components)
+}
+
 // GetLogs mocks base method.
 func (m *MockAppsService) GetLogs(appID, deploymentID, component string, logType godo.AppLogType, follow bool) (*godo.AppLogs, error) {
 	m.ctrl.T.Helper()
@@ -257,3 +318,78 @@ func (mr *MockAppsServiceMockRecorder) GetInstanceSize(slug interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInstanceSize", reflect.TypeOf((*MockAppsService)(nil).GetInstanceSize), slug)
 }
+
+// ListBandwidthUsage mocks base method.
+func (m *MockAppsService) ListBandwidthUsage(appIDs []string, date time.Time) ([]*do.AppBandwidthUsage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBandwidthUsage", appIDs, date)
+	ret0, _ := ret[0].([]*do.AppBandwidthUsage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBandwidthUsage indicates an expected call of ListBandwidthUsage.
+func (mr *MockAppsServiceMockRecorder) ListBandwidthUsage(appIDs, date interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBandwidthUsage", reflect.TypeOf((*MockAppsService)(nil).ListBandwidthUsage), appIDs, date)
+}
+
+// GetMetric mocks base method.
+func (m *MockAppsService) GetMetric(appID, metric string, start, end time.Time) ([]*do.AppMetricSample, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetric", appID, metric, start, end)
+	ret0, _ := ret[0].([]*do.AppMetricSample)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetric indicates an expected call of GetMetric.
+func (mr *MockAppsServiceMockRecorder) GetMetric(appID, metric, start, end interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetric", reflect.TypeOf((*MockAppsService)(nil).GetMetric), appID, metric, start, end)
+}
+
+// ListAlerts mocks base method.
+func (m *MockAppsService) ListAlerts(appID string) ([]*do.AppAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAlerts", appID)
+	ret0, _ := ret[0].([]*do.AppAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAlerts indicates an expected call of ListAlerts.
+func (mr *MockAppsServiceMockRecorder) ListAlerts(appID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAlerts", reflect.TypeOf((*MockAppsService)(nil).ListAlerts), appID)
+}
+
+// UpdateAlertDestinations mocks base method.
+func (m *MockAppsService) UpdateAlertDestinations(appID, alertID string, destinations *do.AppAlertDestinations) (*do.AppAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateAlertDestinations", appID, alertID, destinations)
+	ret0, _ := ret[0].(*do.AppAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateAlertDestinations indicates an expected call of UpdateAlertDestinations.
+func (mr *MockAppsServiceMockRecorder) UpdateAlertDestinations(appID, alertID, destinations interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateAlertDestinations", reflect.TypeOf((*MockAppsService)(nil).UpdateAlertDestinations), appID, alertID, destinations)
+}
+
+// GetExec mocks base method.
+func (m *MockAppsService) GetExec(appID, component string) (*do.AppExec, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExec", appID, component)
+	ret0, _ := ret[0].(*do.AppExec)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExec indicates an expected call of GetExec.
+func (mr *MockAppsServiceMockRecorder) GetExec(appID, component interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExec", reflect.TypeOf((*MockAppsService)(nil).GetExec), appID, component)
+}
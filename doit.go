@@ -16,10 +16,13 @@ package doctl
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
@@ -28,6 +31,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blang/semver"
 	"github.com/digitalocean/doctl/pkg/listen"
@@ -174,7 +178,7 @@ func (glv *GithubLatestVersioner) LatestVersion() (string, error) {
 type Config interface {
 	GetGodoClient(trace bool, accessToken string) (*godo.Client, error)
 	SSH(user, host, keyPath string, port int, opts ssh.Options) runner.Runner
-	Listen(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer) listen.ListenerService
+	Listen(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService
 	Set(ns, key string, val interface{})
 	IsSet(key string) bool
 	GetString(ns, key string) (string, error)
@@ -182,7 +186,9 @@ type Config interface {
 	GetBoolPtr(ns, key string) (*bool, error)
 	GetInt(ns, key string) (int, error)
 	GetIntPtr(ns, key string) (*int, error)
+	GetDuration(ns, key string) (time.Duration, error)
 	GetStringSlice(ns, key string) ([]string, error)
+	GetStringArray(ns, key string) ([]string, error)
 	GetStringMapString(ns, key string) (map[string]string, error)
 }
 
@@ -199,8 +205,10 @@ func (c *LiveConfig) GetGodoClient(trace bool, accessToken string) (*godo.Client
 		return nil, fmt.Errorf("access token is required. (hint: run 'doctl auth init')")
 	}
 
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{Transport: HTTPTransport()})
 	tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: accessToken})
-	oauthClient := oauth2.NewClient(context.Background(), tokenSource)
+	oauthClient := oauth2.NewClient(ctx, tokenSource)
+	oauthClient.Timeout = viper.GetDuration(ArgRequestTimeout)
 
 	if trace {
 		r := newRecorder(oauthClient.Transport)
@@ -219,6 +227,14 @@ func (c *LiveConfig) GetGodoClient(trace bool, accessToken string) (*godo.Client
 		oauthClient.Transport = r
 	}
 
+	if maxRetries := viper.GetInt(ArgMaxRetries); maxRetries > 0 {
+		rt := newRetryTransport(oauthClient.Transport, maxRetries)
+		if viper.GetBool(ArgQuiet) {
+			rt.out = ioutil.Discard
+		}
+		oauthClient.Transport = rt
+	}
+
 	args := []godo.ClientOpt{godo.SetUserAgent(userAgent())}
 
 	apiURL := viper.GetString("api-url")
@@ -229,6 +245,18 @@ func (c *LiveConfig) GetGodoClient(trace bool, accessToken string) (*godo.Client
 	return godo.New(oauthClient, args...)
 }
 
+// HTTPTransport returns the transport for HTTP requests made by doctl. TLS
+// certificates are not verified when --insecure-skip-verify is set.
+func HTTPTransport() http.RoundTripper {
+	if !viper.GetBool(ArgInsecureSkipVerify) {
+		return http.DefaultTransport
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	return t
+}
+
 func userAgent() string {
 	return fmt.Sprintf("doctl/%s (%s %s)", DoitVersion.String(), runtime.GOOS, runtime.GOARCH)
 }
@@ -246,8 +274,8 @@ func (c *LiveConfig) SSH(user, host, keyPath string, port int, opts ssh.Options)
 }
 
 // Listen creates a websocket connection
-func (c *LiveConfig) Listen(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer) listen.ListenerService {
-	return listen.NewListener(url, token, schemaFunc, out)
+func (c *LiveConfig) Listen(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService {
+	return listen.NewListener(url, token, schemaFunc, out, inputCh)
 }
 
 // Set sets a config key.
@@ -322,6 +350,11 @@ func (c *LiveConfig) GetIntPtr(ns, key string) (*int, error) {
 	return &val, nil
 }
 
+// GetDuration returns a config value as a time.Duration.
+func (c *LiveConfig) GetDuration(ns, key string) (time.Duration, error) {
+	return viper.GetDuration(nskey(ns, key)), nil
+}
+
 // GetStringSlice returns a config value as a string slice.
 func (c *LiveConfig) GetStringSlice(ns, key string) ([]string, error) {
 	nskey := nskey(ns, key)
@@ -347,6 +380,35 @@ func (c *LiveConfig) GetStringSlice(ns, key string) ([]string, error) {
 	return out, nil
 }
 
+// GetStringArray returns a config value as a string array. Unlike
+// GetStringSlice, items are not split on commas.
+func (c *LiveConfig) GetStringArray(ns, key string) ([]string, error) {
+	nskey := nskey(ns, key)
+
+	var out []string
+	switch val := viper.Get(nskey).(type) {
+	case string:
+		// viper hands back pflag's string array as its CSV encoded value,
+		// e.g. [a,"b,c"], so decode it rather than splitting on commas.
+		val = strings.TrimPrefix(val, "[")
+		val = strings.TrimSuffix(val, "]")
+		if val != "" {
+			items, err := csv.NewReader(strings.NewReader(val)).Read()
+			if err != nil {
+				return nil, err
+			}
+			out = items
+		}
+	default:
+		out = viper.GetStringSlice(nskey)
+	}
+
+	if isRequired(nskey) && emptyStringSlice(out) {
+		return nil, NewMissingArgsErr(nskey)
+	}
+	return out, nil
+}
+
 // GetStringMapString returns a config value as a string to string map.
 func (c *LiveConfig) GetStringMapString(ns, key string) (map[string]string, error) {
 	nskey := nskey(ns, key)
@@ -386,7 +448,7 @@ func isRequired(key string) bool {
 // TestConfig is an implementation of Config for testing.
 type TestConfig struct {
 	SSHFn    func(user, host, keyPath string, port int, opts ssh.Options) runner.Runner
-	ListenFn func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer) listen.ListenerService
+	ListenFn func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService
 	v        *viper.Viper
 	IsSetMap map[string]bool
 }
@@ -399,7 +461,7 @@ func NewTestConfig() *TestConfig {
 		SSHFn: func(u, h, kp string, p int, opts ssh.Options) runner.Runner {
 			return &MockRunner{}
 		},
-		ListenFn: func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer) listen.ListenerService {
+		ListenFn: func(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService {
 			return &MockListener{}
 		},
 		v:        viper.New(),
@@ -419,8 +481,8 @@ func (c *TestConfig) SSH(user, host, keyPath string, port int, opts ssh.Options)
 }
 
 // Listen returns a mock websocket listener
-func (c *TestConfig) Listen(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer) listen.ListenerService {
-	return c.ListenFn(url, token, schemaFunc, out)
+func (c *TestConfig) Listen(url *url.URL, token string, schemaFunc listen.SchemaFunc, out io.Writer, inputCh <-chan []byte) listen.ListenerService {
+	return c.ListenFn(url, token, schemaFunc, out, inputCh)
 }
 
 // Set sets a config key.
@@ -460,6 +522,13 @@ func (c *TestConfig) GetIntPtr(ns, key string) (*int, error) {
 	return &val, nil
 }
 
+// GetDuration returns the duration value for the key in the given namespace.
+// Because this is a mock implementation, and error will never be returned.
+func (c *TestConfig) GetDuration(ns, key string) (time.Duration, error) {
+	nskey := fmt.Sprintf("%s-%s", ns, key)
+	return c.v.GetDuration(nskey), nil
+}
+
 // GetStringSlice returns the string slice value for the key in the given
 // namespace. Because this is a mock implementation, and error will never be
 // returned.
@@ -468,6 +537,14 @@ func (c *TestConfig) GetStringSlice(ns, key string) ([]string, error) {
 	return c.v.GetStringSlice(nskey), nil
 }
 
+// GetStringArray returns the string array value for the key in the given
+// namespace. Because this is a mock implementation, and error will never be
+// returned.
+func (c *TestConfig) GetStringArray(ns, key string) ([]string, error) {
+	nskey := fmt.Sprintf("%s-%s", ns, key)
+	return c.v.GetStringSlice(nskey), nil
+}
+
 // GetStringMapString returns the string-to-string value for the key in the
 // given namespace. Because this is a mock implementation, and error will never
 // be returned.
@@ -14,9 +14,15 @@ limitations under the License.
 package doctl
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"regexp"
 	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
 )
 
 func TestMain(m *testing.M) {
@@ -117,3 +123,39 @@ type stubLatestRelease struct {
 func (slr stubLatestRelease) LatestVersion() (string, error) {
 	return slr.version, nil
 }
+
+func TestHTTPTransport(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{Transport: HTTPTransport()}
+	_, err := client.Get(server.URL)
+	require.Error(t, err)
+
+	viper.Set(ArgInsecureSkipVerify, true)
+	defer viper.Set(ArgInsecureSkipVerify, false)
+
+	client = &http.Client{Transport: HTTPTransport()}
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestLiveConfigGetStringArray(t *testing.T) {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.StringArray("set", nil, "")
+	require.NoError(t, viper.BindPFlag("test.set", flags.Lookup("set")))
+
+	c := &LiveConfig{}
+	out, err := c.GetStringArray("test", "set")
+	require.NoError(t, err)
+	require.Empty(t, out)
+
+	err = flags.Parse([]string{"--set", `envs.0.value=a,b`, "--set", `name="quoted"`})
+	require.NoError(t, err)
+
+	out, err = c.GetStringArray("test", "set")
+	require.NoError(t, err)
+	require.Equal(t, []string{"envs.0.value=a,b", `name="quoted"`}, out)
+}
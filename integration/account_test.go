@@ -122,6 +122,67 @@ var _ = suite("account/ratelimit", func(t *testing.T, when spec.G, it spec.S) {
 	})
 })
 
+var _ = suite("account/get/insecure-skip-verify", func(t *testing.T, when spec.G, it spec.S) {
+	var (
+		expect *require.Assertions
+		server *httptest.Server
+	)
+
+	it.Before(func() {
+		expect = require.New(t)
+
+		server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Add("content-type", "application/json")
+
+			switch req.URL.Path {
+			case "/v2/account":
+				w.Write([]byte(accountGetResponse))
+			default:
+				dump, err := httputil.DumpRequest(req, true)
+				if err != nil {
+					t.Fatal("failed to dump request")
+				}
+
+				t.Fatalf("received unknown request: %s", dump)
+			}
+		}))
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	it("fails to verify a self-signed certificate", func() {
+		cmd := exec.Command(builtBinaryPath,
+			"-t", "some-magic-token",
+			"-u", server.URL,
+			"--max-retries", "0",
+			"account",
+			"get",
+		)
+
+		output, err := cmd.CombinedOutput()
+		expect.Error(err)
+		expect.Contains(string(output), "certificate")
+	})
+
+	it("skips verification and warns about it", func() {
+		cmd := exec.Command(builtBinaryPath,
+			"-t", "some-magic-token",
+			"-u", server.URL,
+			"--insecure-skip-verify",
+			"account",
+			"get",
+		)
+
+		output, err := cmd.CombinedOutput()
+		expect.NoError(err)
+
+		expected := "Warning: TLS certificate verification is disabled by --insecure-skip-verify. Connections are not secure.\n" + strings.TrimSpace(accountOutput)
+		expect.Equal(expected, strings.TrimSpace(string(output)))
+	})
+})
+
 const (
 	accountGetResponse = `
 {
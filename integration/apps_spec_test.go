@@ -234,7 +234,7 @@ services:
 		output, err := cmd.CombinedOutput()
 		expect.Equal("exit status 1", err.Error())
 
-		expectedOutput := "Error: parsing app spec: json: cannot unmarshal object into Go struct field AppSpec.services of type []*godo.AppServiceSpec"
+		expectedOutput := "Notice: The app spec has invalid fields; see https://www.digitalocean.com/docs/app-platform/concepts/app-spec for the supported fields\nError: <stdin>:3: services: expected array, but got object"
 		expect.Equal(expectedOutput, strings.TrimSpace(string(output)))
 	})
 })
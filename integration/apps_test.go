@@ -211,6 +211,32 @@ var _ = suite("apps/create", func(t *testing.T, when spec.G, it spec.S) {
 		expectedOutput := "Notice: App created\n" + testAppsOutput
 		expect.Equal(expectedOutput, strings.TrimSpace(string(output)))
 	})
+
+	it("creates an app quietly", func() {
+		specFile, err := ioutil.TempFile("", "spec")
+		require.NoError(t, err)
+		defer func() {
+			os.Remove(specFile.Name())
+			specFile.Close()
+		}()
+
+		err = json.NewEncoder(specFile).Encode(&testAppSpec)
+		require.NoError(t, err)
+
+		cmd := exec.Command(builtBinaryPath,
+			"-t", "some-magic-token",
+			"-u", server.URL,
+			"--quiet",
+			"apps",
+			"create",
+			"--spec",
+			specFile.Name(),
+		)
+
+		output, err := cmd.CombinedOutput()
+		expect.NoError(err)
+		expect.Equal(testAppsOutput, strings.TrimSpace(string(output)))
+	})
 })
 
 var _ = suite("apps/get", func(t *testing.T, when spec.G, it spec.S) {
@@ -318,6 +344,75 @@ var _ = suite("apps/list", func(t *testing.T, when spec.G, it spec.S) {
 		expectedOutput := testAppsOutput
 		expect.Equal(expectedOutput, strings.TrimSpace(string(output)))
 	})
+
+	when("the active context has flag defaults", func() {
+		var configPath string
+
+		it.Before(func() {
+			f, err := ioutil.TempFile("", "doctl-context-defaults-*.yaml")
+			expect.NoError(err)
+			defer f.Close()
+			configPath = f.Name()
+
+			_, err = f.WriteString(`context: prod
+auth-contexts:
+  prod: some-magic-token
+  dev: some-magic-token
+context-defaults:
+  prod:
+    apps.list.format: ID
+    apps:
+      list:
+        no-header: true
+`)
+			expect.NoError(err)
+		})
+
+		it.After(func() {
+			os.Remove(configPath)
+		})
+
+		it("uses them", func() {
+			cmd := exec.Command(builtBinaryPath,
+				"-c", configPath,
+				"-u", server.URL,
+				"apps",
+				"list",
+			)
+
+			output, err := cmd.CombinedOutput()
+			expect.NoError(err, string(output))
+			expect.Equal(testAppUUID, strings.TrimSpace(string(output)))
+		})
+
+		it("prefers flags passed on the command line", func() {
+			cmd := exec.Command(builtBinaryPath,
+				"-c", configPath,
+				"-u", server.URL,
+				"apps",
+				"list",
+				"--format", "ID,Spec.Name",
+			)
+
+			output, err := cmd.CombinedOutput()
+			expect.NoError(err, string(output))
+			expect.Equal(testAppUUID+"    test", strings.TrimSpace(string(output)))
+		})
+
+		it("ignores the defaults of other contexts", func() {
+			cmd := exec.Command(builtBinaryPath,
+				"-c", configPath,
+				"-u", server.URL,
+				"--context", "dev",
+				"apps",
+				"list",
+			)
+
+			output, err := cmd.CombinedOutput()
+			expect.NoError(err, string(output))
+			expect.Equal(testAppsOutput, strings.TrimSpace(string(output)))
+		})
+	})
 })
 
 var _ = suite("apps/update", func(t *testing.T, when spec.G, it spec.S) {
@@ -340,6 +435,11 @@ var _ = suite("apps/update", func(t *testing.T, when spec.G, it spec.S) {
 					return
 				}
 
+				if req.Method == http.MethodGet {
+					json.NewEncoder(w).Encode(testAppResponse)
+					return
+				}
+
 				if req.Method != http.MethodPut {
 					w.WriteHeader(http.StatusMethodNotAllowed)
 					return
@@ -758,6 +858,7 @@ var _ = suite("apps/get-logs", func(t *testing.T, when spec.G, it spec.S) {
 			"--deployment="+testDeploymentUUID,
 			"--type=run",
 			"-f",
+			"--no-reconnect",
 		)
 
 		output, err := cmd.CombinedOutput()
@@ -766,6 +867,28 @@ var _ = suite("apps/get-logs", func(t *testing.T, when spec.G, it spec.S) {
 		expectedOutput := "fake logs\nfake logs\nfake logs\nfake logs\nfake logs"
 		expect.Equal(expectedOutput, strings.TrimSpace(string(output)))
 	})
+
+	it("writes the raw log stream messages with --json-raw", func() {
+		cmd := exec.Command(builtBinaryPath,
+			"-t", "some-magic-token",
+			"-u", server.URL,
+			"apps",
+			"logs",
+			testAppUUID,
+			"service",
+			"--deployment="+testDeploymentUUID,
+			"--type=run",
+			"-f",
+			"--no-reconnect",
+			"--json-raw",
+		)
+
+		output, err := cmd.CombinedOutput()
+		expect.NoError(err)
+
+		expectedOutput := strings.Repeat(`{"data":"fake logs\n"}`+"\n", 5)
+		expect.Equal(expectedOutput, string(output))
+	})
 })
 
 var _ = suite("apps/list-regions", func(t *testing.T, when spec.G, it spec.S) {
@@ -29,8 +29,16 @@ var (
 		Tiers []*godo.AppTier `json:"tiers"`
 	}{[]*godo.AppTier{testAppTier}}
 
-	testAppTierOutput = `Name    Slug    Egress Bandwidth    Build Seconds
-Test    test    10.00 KiB           3000`
+	testAppTierInstanceSizesResponse = struct {
+		InstanceSizes []*godo.AppInstanceSize `json:"instance_sizes"`
+	}{[]*godo.AppInstanceSize{{
+		Slug:        "test-xs",
+		USDPerMonth: "5",
+		TierSlug:    testAppTier.Slug,
+	}}}
+
+	testAppTierOutput = `Name    Slug    Egress Bandwidth    Build Seconds    Monthly Price
+Test    test    10.00 KiB           3000             $5.00`
 
 	testAppInstanceSize = &godo.AppInstanceSize{
 		Name:            "Basic XXS",
@@ -82,6 +90,8 @@ var _ = suite("apps/tier/get", func(t *testing.T, when spec.G, it spec.S) {
 				}
 
 				json.NewEncoder(w).Encode(testAppTierResponse)
+			case "/v2/apps/tiers/instance_sizes":
+				json.NewEncoder(w).Encode(testAppTierInstanceSizesResponse)
 			default:
 				dump, err := httputil.DumpRequest(req, true)
 				if err != nil {
@@ -137,6 +147,8 @@ var _ = suite("apps/tier/list", func(t *testing.T, when spec.G, it spec.S) {
 				}
 
 				json.NewEncoder(w).Encode(testAppTiersResponse)
+			case "/v2/apps/tiers/instance_sizes":
+				json.NewEncoder(w).Encode(testAppTierInstanceSizesResponse)
 			default:
 				dump, err := httputil.DumpRequest(req, true)
 				if err != nil {
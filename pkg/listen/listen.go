@@ -27,6 +27,9 @@ type Listener struct {
 	// Out is an io.Writer to output to.
 	// doctl hint: this should usually be commands.CmdConfig.Out
 	Out io.Writer
+	// InputCh is a channel of messages to send over the websocket. It may be
+	// nil for listeners that only read.
+	InputCh <-chan []byte
 
 	done chan bool
 	stop chan bool
@@ -41,12 +44,13 @@ type ListenerService interface {
 var _ ListenerService = &Listener{}
 
 // NewListener returns a configured Listener
-func NewListener(url *url.URL, token string, schemaFunc SchemaFunc, out io.Writer) ListenerService {
+func NewListener(url *url.URL, token string, schemaFunc SchemaFunc, out io.Writer, inputCh <-chan []byte) ListenerService {
 	return &Listener{
 		URL:        url,
 		Token:      token,
 		SchemaFunc: schemaFunc,
 		Out:        out,
+		InputCh:    inputCh,
 
 		done: make(chan bool),
 		stop: make(chan bool),
@@ -96,6 +100,11 @@ func (l *Listener) Start() error {
 		select {
 		case <-done:
 			return nil
+		case message := <-l.InputCh:
+			err := c.WriteMessage(websocket.TextMessage, message)
+			if err != nil {
+				return err
+			}
 		case <-interrupt:
 			return writeCloseMessage(c)
 		case <-l.stop:
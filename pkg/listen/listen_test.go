@@ -66,7 +66,7 @@ func TestListener(t *testing.T) {
 
 	buffer := &bytes.Buffer{}
 
-	listener := NewListener(url, "", nil, buffer)
+	listener := NewListener(url, "", nil, buffer, nil)
 	err = listener.Start()
 	if err != nil {
 		require.NoError(t, err)
@@ -106,7 +106,7 @@ func TestListenerWithSchemaFunc(t *testing.T) {
 		return r, nil
 	}
 
-	listener := NewListener(url, "", schemaFunc, buffer)
+	listener := NewListener(url, "", schemaFunc, buffer, nil)
 	err = listener.Start()
 	if err != nil {
 		t.Fatalf("%v", err)
@@ -133,10 +133,39 @@ func TestListenerStop(t *testing.T) {
 
 	buffer := &bytes.Buffer{}
 
-	listener := NewListener(url, "", nil, buffer)
+	listener := NewListener(url, "", nil, buffer, nil)
 	go listener.Start()
 	// Stop before any messages have been sent
 	listener.Stop()
 
 	require.Equal(t, "", buffer.String())
 }
+
+func TestListenerInput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer c.Close()
+
+		// Echo a single message back before closing the connection.
+		_, message, err := c.ReadMessage()
+		require.NoError(t, err)
+		err = c.WriteMessage(websocket.TextMessage, append([]byte("echo: "), message...))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	u := "ws" + strings.TrimPrefix(server.URL, "http")
+	url, err := url.Parse(u)
+	require.NoError(t, err)
+
+	buffer := &bytes.Buffer{}
+	inputCh := make(chan []byte, 1)
+	inputCh <- []byte("hello")
+
+	listener := NewListener(url, "", nil, buffer, inputCh)
+	err = listener.Start()
+	require.NoError(t, err)
+
+	require.Equal(t, "echo: hello", buffer.String())
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2018 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doctl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	// minRetryWait is the shortest time waited before retrying a rate limited
+	// request, used when the API doesn't say when the limit resets.
+	minRetryWait = time.Second
+	// maxRetryWait caps the time waited before retrying a rate limited request.
+	maxRetryWait = time.Minute
+)
+
+// retryTransport retries requests that are rejected with 429 Too Many
+// Requests, waiting until the rate limit resets before each attempt.
+type retryTransport struct {
+	wrap       http.RoundTripper
+	maxRetries int
+	out        io.Writer
+	now        func() time.Time
+}
+
+func newRetryTransport(transport http.RoundTripper, maxRetries int) *retryTransport {
+	return &retryTransport{
+		wrap:       transport,
+		maxRetries: maxRetries,
+		out:        os.Stderr,
+		now:        time.Now,
+	}
+}
+
+func (rt *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := rt.wrap.RoundTrip(req)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt > rt.maxRetries {
+			return resp, err
+		}
+
+		// The body of the original request has already been consumed, so it
+		// can only be retried if it can be rebuilt.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+
+		wait := rt.retryWait(resp, attempt)
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		fmt.Fprintf(rt.out, "Notice: API rate limit reached, retrying in %s (retry %d of %d)\n", wait, attempt, rt.maxRetries)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// retryWait returns how long to wait before retrying a rate limited request,
+// based on the Retry-After or RateLimit-Reset headers of resp. Without either,
+// it backs off exponentially.
+func (rt *retryTransport) retryWait(resp *http.Response, attempt int) time.Duration {
+	wait := minRetryWait << (attempt - 1)
+
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		wait = time.Duration(secs) * time.Second
+	} else if remaining := resp.Header.Get("RateLimit-Remaining"); remaining == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("RateLimit-Reset"), 10, 64); err == nil {
+			wait = time.Unix(reset, 0).Sub(rt.now())
+		}
+	}
+
+	if wait < minRetryWait {
+		wait = minRetryWait
+	}
+	if wait > maxRetryWait {
+		wait = maxRetryWait
+	}
+	return wait.Round(time.Second)
+}
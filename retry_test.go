@@ -0,0 +1,135 @@
+/*
+Copyright 2018 The Doctl Authors All rights reserved.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package doctl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryTransport(t *testing.T) {
+	min, max := minRetryWait, maxRetryWait
+	minRetryWait, maxRetryWait = time.Millisecond, time.Millisecond
+	defer func() {
+		minRetryWait, maxRetryWait = min, max
+	}()
+
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.Equal(t, "payload", string(body))
+
+		if requests < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	t.Run("retries until success", func(t *testing.T) {
+		requests = 0
+		var out bytes.Buffer
+		rt := newRetryTransport(http.DefaultTransport, 3)
+		rt.out = &out
+
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+		require.NoError(t, err)
+
+		resp, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 3, requests)
+		assert.Equal(t, 2, strings.Count(out.String(), "API rate limit reached"))
+	})
+
+	t.Run("gives up after max retries", func(t *testing.T) {
+		requests = 0
+		rt := newRetryTransport(http.DefaultTransport, 1)
+		rt.out = ioutil.Discard
+
+		req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("payload"))
+		require.NoError(t, err)
+
+		resp, err := rt.RoundTrip(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, 2, requests)
+	})
+}
+
+func TestRetryTransportRetryWait(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	rt := newRetryTransport(http.DefaultTransport, 3)
+	rt.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		attempt int
+		want    time.Duration
+	}{
+		{
+			name:    "exponential backoff",
+			attempt: 3,
+			want:    4 * time.Second,
+		},
+		{
+			name:    "retry after",
+			headers: map[string]string{"Retry-After": "7"},
+			attempt: 1,
+			want:    7 * time.Second,
+		},
+		{
+			name: "rate limit reset",
+			headers: map[string]string{
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     strconv.FormatInt(now.Add(12*time.Second).Unix(), 10),
+			},
+			attempt: 1,
+			want:    12 * time.Second,
+		},
+		{
+			name: "rate limit reset is capped",
+			headers: map[string]string{
+				"RateLimit-Remaining": "0",
+				"RateLimit-Reset":     strconv.FormatInt(now.Add(time.Hour).Unix(), 10),
+			},
+			attempt: 1,
+			want:    time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			for k, v := range tt.headers {
+				resp.Header.Set(k, v)
+			}
+			assert.Equal(t, tt.want, rt.retryWait(resp, tt.attempt))
+		})
+	}
+}